@@ -55,6 +55,9 @@ export default function Sandbox() {
       cpp: 'cpp',
       csharp: 'cs',
       json: 'json',
+      yaml: 'yaml',
+      go: 'go',
+      shell: 'sh',
     }
     return extensions[type] || 'txt'
   }
@@ -77,6 +80,9 @@ export default function Sandbox() {
             <option value="cpp">C++</option>
             <option value="csharp">C#</option>
             <option value="json">JSON</option>
+            <option value="yaml">YAML</option>
+            <option value="go">Go</option>
+            <option value="shell">Shell</option>
           </select>
           {isAnalyzing && <span className="text-gray-500">Analyzing...</span>}
         </div>
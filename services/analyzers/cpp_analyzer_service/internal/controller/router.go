@@ -7,6 +7,7 @@ import (
 func NewRouter(analyzerController *AnalyzerController) *mux.Router {
 	router := mux.NewRouter()
 	router.HandleFunc("/api/analyzer/cpp", analyzerController.Analyze).Methods("POST")
+	router.HandleFunc("/api/analyzer/cpp/cache", analyzerController.CacheStats).Methods("GET")
 	return router
 }
 
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cpp_analyzer_service/internal/models"
+)
+
+const defaultCacheSize = 1000
+
+// resultCache is an LRU cache of file results keyed by a hash of everything
+// that determines a result: tool version, options, the request context and
+// the file's path and content. When dir is set, entries are also stored as
+// JSON files there so they survive restarts. A nil cache caches nothing.
+type resultCache struct {
+	mu       sync.Mutex
+	capacity int
+	dir      string
+	order    *list.List // front = most recently used
+	entries  map[string]*list.Element
+	hits     uint64
+	misses   uint64
+}
+
+type cacheEntry struct {
+	key    string
+	result models.FileResult
+}
+
+func newResultCache(capacity int, dir string) *resultCache {
+	if capacity <= 0 {
+		return nil
+	}
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			dir = ""
+		}
+	}
+	return &resultCache{
+		capacity: capacity,
+		dir:      dir,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// cacheFromEnv configures the cache from CACHE_SIZE (entries, 0 or less
+// disables caching, invalid values keep the default) and CACHE_DIR
+// (optional persistence directory).
+func cacheFromEnv() *resultCache {
+	if size, err := strconv.Atoi(os.Getenv("CACHE_SIZE")); err == nil && size <= 0 {
+		return nil
+	}
+	return newResultCache(intFromEnv("CACHE_SIZE", defaultCacheSize), os.Getenv("CACHE_DIR"))
+}
+
+// cacheKey hashes the given parts; lengths are included so that different
+// splits of the same bytes never collide.
+func cacheKey(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		fmt.Fprintf(h, "%d:%s;", len(part), part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// requestContext hashes the parts of a request besides the file itself that
+// can change its result: the options, the set of paths and the content of
+// the files selected by withContent.
+func requestContext(req *models.AnalyzeRequest, withContent func(i int) bool) string {
+	options, _ := json.Marshal(req.Options)
+	parts := []string{string(options)}
+
+	indices := make([]int, len(req.Files))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.Slice(indices, func(a, b int) bool { return req.Files[indices[a]].Path < req.Files[indices[b]].Path })
+	for _, i := range indices {
+		parts = append(parts, req.Files[i].Path)
+		if withContent(i) {
+			parts = append(parts, req.Files[i].Content)
+		}
+	}
+
+	return cacheKey(parts...)
+}
+
+// toolVersion runs "name args..." and returns its trimmed output, which is
+// part of every cache key so upgrading the tool invalidates old results.
+func toolVersion(timeout time.Duration, name string, args ...string) (string, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(output)), true
+}
+
+func (c *resultCache) get(key string) (models.FileResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.order.MoveToFront(element)
+		c.hits++
+		return element.Value.(*cacheEntry).result, true
+	}
+
+	if c.dir != "" {
+		if data, err := os.ReadFile(c.filePath(key)); err == nil {
+			var result models.FileResult
+			if json.Unmarshal(data, &result) == nil {
+				c.add(key, result)
+				c.hits++
+				return result, true
+			}
+		}
+	}
+
+	c.misses++
+	return models.FileResult{}, false
+}
+
+func (c *resultCache) put(key string, result models.FileResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*cacheEntry).result = result
+		c.order.MoveToFront(element)
+	} else {
+		c.add(key, result)
+	}
+
+	if c.dir != "" {
+		if data, err := json.Marshal(result); err == nil {
+			// Write to a temporary file first so readers never see partial entries.
+			tmp := c.filePath(key) + ".tmp"
+			if os.WriteFile(tmp, data, 0644) == nil {
+				os.Rename(tmp, c.filePath(key))
+			}
+		}
+	}
+}
+
+// add inserts a new entry and evicts the least recently used one when the
+// cache is full. The caller holds c.mu.
+func (c *resultCache) add(key string, result models.FileResult) {
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, result: result})
+	if c.order.Len() <= c.capacity {
+		return
+	}
+
+	oldest := c.order.Back()
+	c.order.Remove(oldest)
+	evicted := oldest.Value.(*cacheEntry).key
+	delete(c.entries, evicted)
+	if c.dir != "" {
+		os.Remove(c.filePath(evicted))
+	}
+}
+
+func (c *resultCache) filePath(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// lookup fills results from the cache for the files with a key and returns
+// the indices of pending that still have to be analyzed.
+func (c *resultCache) lookup(keys map[int]string, files []models.FileInput, results []models.FileResult, pending []int) []int {
+	if c == nil || keys == nil {
+		return pending
+	}
+
+	var missed []int
+	for _, i := range pending {
+		result, ok := c.get(keys[i])
+		if !ok {
+			missed = append(missed, i)
+			continue
+		}
+		result.Path = files[i].Path
+		if result.Status == "" {
+			// Stored before results carried a status.
+			result.Status = resultStatus(result.LineComments)
+		}
+		results[i] = result
+	}
+	return missed
+}
+
+// store caches the results of the given indices. Only results of completed
+// analyses are cached; timeouts and tool errors are analyzed again next time.
+func (c *resultCache) store(keys map[int]string, results []models.FileResult, indices []int) {
+	if c == nil || keys == nil {
+		return
+	}
+
+	for _, i := range indices {
+		if results[i].Status != models.StatusOK && results[i].Status != models.StatusIssues {
+			continue
+		}
+		c.put(keys[i], results[i])
+	}
+}
+
+func (c *resultCache) stats() models.CacheStats {
+	if c == nil {
+		return models.CacheStats{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return models.CacheStats{
+		Enabled:    true,
+		Persistent: c.dir != "",
+		Entries:    c.order.Len(),
+		Capacity:   c.capacity,
+		Hits:       c.hits,
+		Misses:     c.misses,
+	}
+}
+
+// cacheVersion returns the cppcheck version used in cache keys; ok is false
+// when cppcheck cannot be run, in which case nothing is cached.
+func (s *CppAnalyzerService) cacheVersion() (string, bool) {
+	s.versionOnce.Do(func() {
+		s.version, s.versionOK = toolVersion(s.fileTimeout, s.cppcheckPath, "--version")
+	})
+	return s.version, s.versionOK
+}
+
+// cacheKeys returns the cache key of every file to analyze, or nil when
+// results are not cached.
+func (s *CppAnalyzerService) cacheKeys(req *models.AnalyzeRequest, pending []int) map[int]string {
+	if s.cache == nil {
+		return nil
+	}
+	version, ok := s.cacheVersion()
+	if !ok {
+		return nil
+	}
+
+	// Included headers can change the findings of any file, so the content of every file is part of the context.
+	reqContext := requestContext(req, func(int) bool { return true })
+
+	keys := make(map[int]string, len(pending))
+	for _, i := range pending {
+		keys[i] = cacheKey(version, reqContext, req.Files[i].Path, req.Files[i].Content)
+	}
+	return keys
+}
+
+// CacheStats reports the hit/miss counters and size of the result cache.
+func (s *CppAnalyzerService) CacheStats() models.CacheStats {
+	return s.cache.stats()
+}
@@ -0,0 +1,20 @@
+package service
+
+// cppcheckSuppressionFiles are the project suppression lists we look for,
+// relative to the project root, in order of preference.
+var cppcheckSuppressionFiles = []string{
+	".cppcheck-suppressions",
+	"cppcheck-suppressions.txt",
+	".cppcheck/suppressions.txt",
+}
+
+// configArgs returns the cppcheck arguments that apply the project's own
+// suppressions: inline "// cppcheck-suppress id" comments are always honoured
+// and a suppressions list is passed when the project has one.
+func (s *CppAnalyzerService) configArgs(ws *workspace) []string {
+	args := []string{"--inline-suppr"}
+	if name := ws.findConfig(cppcheckSuppressionFiles...); name != "" {
+		args = append(args, "--suppressions-list="+name)
+	}
+	return args
+}
@@ -1,18 +1,41 @@
 package service
 
 import (
+	"bytes"
+	"context"
+	"encoding/xml"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"cpp_analyzer_service/internal/models"
 )
 
+const (
+	defaultFileTimeout    = 30 * time.Second
+	defaultRequestTimeout = 2 * time.Minute
+
+	// waitDelay bounds how long we wait for output pipes after the tool
+	// process has been killed on cancellation.
+	waitDelay = 2 * time.Second
+)
+
 type CppAnalyzerService struct {
-	cppcheckPath string
+	cppcheckPath   string
+	fileTimeout    time.Duration
+	requestTimeout time.Duration
+	maxWorkers     int
+	processes      processLimiter
+	cache          *resultCache
+
+	versionOnce sync.Once
+	version     string
+	versionOK   bool
 }
 
 func NewCppAnalyzerService() *CppAnalyzerService {
@@ -22,99 +45,255 @@ func NewCppAnalyzerService() *CppAnalyzerService {
 	}
 
 	return &CppAnalyzerService{
-		cppcheckPath: cppcheckPath,
+		cppcheckPath:   cppcheckPath,
+		fileTimeout:    durationFromEnv("ANALYZER_FILE_TIMEOUT", defaultFileTimeout),
+		requestTimeout: durationFromEnv("ANALYZER_REQUEST_TIMEOUT", defaultRequestTimeout),
+		maxWorkers:     intFromEnv("MAX_WORKERS", runtime.NumCPU()),
+		processes:      newProcessLimiter(intFromEnv("MAX_PROCESSES", runtime.NumCPU())),
+		cache:          cacheFromEnv(),
 	}
 }
 
-func (s *CppAnalyzerService) Analyze(req *models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
-	var results []models.FileResult
+// durationFromEnv parses a Go duration (e.g. "30s") from the environment,
+// falling back to the default when the variable is unset or invalid.
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
 
-	for _, file := range req.Files {
+// intFromEnv parses a positive integer from the environment, falling back to
+// the default when the variable is unset or invalid.
+func intFromEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func (s *CppAnalyzerService) Analyze(ctx context.Context, req *models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
+	if err := validateOptions(req.Options); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	results := make([]models.FileResult, len(req.Files))
+	var pending []int
+
+	for i, file := range req.Files {
 		ext := strings.ToLower(filepath.Ext(file.Path))
 		if ext != ".cpp" && ext != ".c" && ext != ".cc" && ext != ".cxx" && ext != ".h" && ext != ".hpp" {
-			results = append(results, models.FileResult{
+			results[i] = models.FileResult{
 				Path:         file.Path,
+				Status:       models.StatusUnsupported,
 				Comment:      "Not a C/C++ file",
 				LineComments: []models.LineComment{},
-			})
+			}
 			continue
 		}
+		pending = append(pending, i)
+	}
+
+	keys := s.cacheKeys(req, pending)
+	pending = s.cache.lookup(keys, req.Files, results, pending)
+	if len(pending) == 0 {
+		return &models.AnalyzeResponse{Files: results}, nil
+	}
 
-		result := s.analyzeFile(file.Path, file.Content)
-		results = append(results, result)
+	// Every file of the request is written to the workspace, including headers that are not analyzed themselves, so includes resolve.
+	ws, err := newWorkspace(req.Files)
+	if err != nil {
+		for _, i := range pending {
+			results[i] = models.FileResult{
+				Path:         req.Files[i].Path,
+				Status:       models.StatusToolError,
+				Comment:      fmt.Sprintf("Error: failed to create workspace: %v", err),
+				LineComments: []models.LineComment{},
+			}
+		}
+		return &models.AnalyzeResponse{Files: results}, nil
 	}
+	defer ws.Close()
+
+	toolArgs := append(s.configArgs(ws), optionArgs(req.Options)...)
+	paths := make([]string, len(pending))
+	for j, i := range pending {
+		paths[j] = req.Files[i].Path
+	}
+	for j, result := range s.analyzeFiles(ctx, ws, toolArgs, paths) {
+		results[pending[j]] = result
+	}
+
+	for i := range results {
+		results[i] = applyOptions(results[i], req.Options)
+	}
+	s.cache.store(keys, results, pending)
 
 	return &models.AnalyzeResponse{Files: results}, nil
 }
 
-func (s *CppAnalyzerService) analyzeFile(path, content string) models.FileResult {
-	tmpDir := os.TempDir()
-	tmpFile := filepath.Join(tmpDir, fmt.Sprintf("analyze_%d_%s", time.Now().UnixNano(), filepath.Base(path)))
-	defer os.Remove(tmpFile)
+// analyzeFiles runs cppcheck once over the given files inside the workspace,
+// with the project configuration and request option arguments, and returns one result per path,
+// in the same order. Checks across translation units, such as unusedFunction,
+// need every file in the same run, so cppcheck parallelizes the run itself
+// with -j; the build directory keeps those checks working with it.
+func (s *CppAnalyzerService) analyzeFiles(ctx context.Context, ws *workspace, toolArgs []string, paths []string) []models.FileResult {
+	// Waiting for a free process slot does not count against the deadline.
+	if err := s.processes.acquire(ctx); err != nil {
+		return timeoutResults(paths)
+	}
+	defer s.processes.release()
 
-	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
-		return models.FileResult{
-			Path:         path,
-			Comment:      fmt.Sprintf("Error: failed to create temp file: %v", err),
-			LineComments: []models.LineComment{},
+	ctx, cancel := context.WithTimeout(ctx, s.fileTimeout*time.Duration(len(paths)))
+	defer cancel()
+
+	// The workspace root is an include path, so project-relative includes
+	// resolve next to the usual paths relative to the including file.
+	args := []string{"--enable=all", "--xml", "-I", "."}
+	if s.maxWorkers > 1 {
+		buildDir := filepath.Join(ws.dir, "cppcheck-build")
+		if err := os.Mkdir(buildDir, 0755); err != nil {
+			return toolErrorResults(paths, "cppcheck", err, nil)
 		}
+		args = append(args, "-j", strconv.Itoa(s.maxWorkers), "--cppcheck-build-dir="+buildDir)
 	}
+	args = append(args, toolArgs...)
+	for _, path := range paths {
+		args = append(args, ws.relPath(path))
+	}
+	// cppcheck prints progress to stdout and the XML report to stderr.
+	progress, output, runErr := ws.run(ctx, s.cppcheckPath, args...)
 
-	cmd := exec.Command(s.cppcheckPath, "--enable=all", "--xml", tmpFile)
-	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return timeoutResults(paths)
+	}
 
-	if err != nil && len(output) == 0 {
-		return models.FileResult{
-			Path:         path,
-			Comment:      "OK",
-			LineComments: []models.LineComment{},
+	findings, err := s.parseOutput(ws, output)
+	if runErr != nil {
+		// Without --error-exitcode, cppcheck only exits non-zero on failure.
+		err = runErr
+	}
+	if err != nil {
+		// Without a report, cppcheck's own errors are on stdout.
+		if len(bytes.TrimSpace(output)) == 0 {
+			output = progress
 		}
+		return toolErrorResults(paths, "cppcheck", err, output)
 	}
 
-	return s.parseOutput(path, string(output))
+	return fileResults(paths, findings)
+}
+
+// cppcheckResults mirrors the cppcheck XML version 2 report.
+type cppcheckResults struct {
+	Errors []cppcheckError `xml:"errors>error"`
 }
 
-func (s *CppAnalyzerService) parseOutput(path, output string) models.FileResult {
-	// Simple XML parsing for cppcheck output
-	lines := strings.Split(strings.TrimSpace(output), "\n")
-	var lineComments []models.LineComment
-	comment := "OK"
-
-	for _, line := range lines {
-		if strings.Contains(line, "<error") {
-			// Extract line number and message from XML
-			var lineNum int
-			fmt.Sscanf(line, "%*[^l]line=\"%d\"", &lineNum)
-			if lineNum > 0 {
-				msg := extractMessage(line)
-				lineComments = append(lineComments, models.LineComment{
-					Line:    lineNum,
-					Comment: msg,
-				})
-				if comment == "OK" {
-					comment = "Issues found"
-				}
+type cppcheckError struct {
+	ID           string             `xml:"id,attr"`
+	Severity     string             `xml:"severity,attr"`
+	Msg          string             `xml:"msg,attr"`
+	Verbose      string             `xml:"verbose,attr"`
+	CWE          int                `xml:"cwe,attr"`
+	Inconclusive bool               `xml:"inconclusive,attr"`
+	Locations    []cppcheckLocation `xml:"location"`
+}
+
+type cppcheckLocation struct {
+	File   string `xml:"file,attr"`
+	Line   int    `xml:"line,attr"`
+	Column int    `xml:"column,attr"`
+	Info   string `xml:"info,attr"`
+}
+
+// parseOutput decodes a cppcheck XML v2 report and groups the findings by
+// the original path of the file they were reported for. cppcheck lists the
+// primary location first; errors without any location in the workspace
+// (e.g. missingIncludeSystem) are skipped. Output that is not a report
+// means cppcheck failed.
+func (s *CppAnalyzerService) parseOutput(ws *workspace, output []byte) (map[string][]models.LineComment, error) {
+	findings := make(map[string][]models.LineComment)
+
+	var report cppcheckResults
+	if err := xml.Unmarshal(output, &report); err != nil {
+		return nil, fmt.Errorf("invalid XML report: %w", err)
+	}
+
+	for _, e := range report.Errors {
+		var path string
+		lineComment := models.LineComment{
+			RuleID:       e.ID,
+			Severity:     cppcheckSeverity(e.Severity),
+			Tool:         "cppcheck",
+			Comment:      e.Msg,
+			CWE:          e.CWE,
+			Inconclusive: e.Inconclusive,
+		}
+		if lineComment.Comment == "" {
+			lineComment.Comment = "Issue found"
+		}
+		if e.Verbose != "" && e.Verbose != e.Msg {
+			lineComment.Detail = e.Verbose
+		}
+
+		for _, location := range e.Locations {
+			locationPath, ok := ws.originalPath(location.File)
+			if !ok || location.Line <= 0 {
+				continue
+			}
+			if path == "" {
+				path = locationPath
+				lineComment.Line = location.Line
+				lineComment.Column = location.Column
 			}
+			lineComment.Locations = append(lineComment.Locations, models.Location{
+				Path:   locationPath,
+				Line:   location.Line,
+				Column: location.Column,
+				Info:   location.Info,
+			})
 		}
-	}
 
-	return models.FileResult{
-		Path:         path,
-		Comment:      comment,
-		LineComments: lineComments,
+		if path == "" {
+			continue
+		}
+		// A single location is already the finding's own position.
+		if len(lineComment.Locations) == 1 && lineComment.Locations[0].Info == "" {
+			lineComment.Locations = nil
+		}
+		findings[path] = append(findings[path], lineComment)
 	}
+
+	return findings, nil
 }
 
-func extractMessage(line string) string {
-	start := strings.Index(line, "msg=\"")
-	if start == -1 {
-		return "Issue found"
+// cppcheckSeverity maps cppcheck severities onto error/warning/info.
+func cppcheckSeverity(severity string) string {
+	switch severity {
+	case "error":
+		return models.SeverityError
+	case "warning", "performance", "portability":
+		return models.SeverityWarning
+	default:
+		return models.SeverityInfo
 	}
-	start += 5
-	end := strings.Index(line[start:], "\"")
-	if end == -1 {
-		return line[start:]
+}
+
+func timeoutResults(paths []string) []models.FileResult {
+	results := make([]models.FileResult, len(paths))
+	for i, path := range paths {
+		results[i] = models.FileResult{
+			Path:         path,
+			Status:       models.StatusTimeout,
+			Comment:      "Analysis timed out",
+			LineComments: []models.LineComment{},
+		}
 	}
-	return line[start : start+end]
+	return results
 }
 
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"testing"
+
+	"cpp_analyzer_service/internal/models"
+)
+
+const cppcheckReport = `<?xml version="1.0" encoding="UTF-8"?>
+<results version="2">
+    <cppcheck version="2.13.0"/>
+    <errors>
+        <error id="nullPointer" severity="error"
+               msg="Null pointer dereference: p"
+               verbose="Null pointer dereference: p. The pointer &apos;p&apos; is assigned NULL &amp; then dereferenced."
+               cwe="476">
+            <location file="main.c" line="7" column="6" info="Null pointer dereference"/>
+            <location file="util.h" line="3" column="12" info="Assignment &apos;p=NULL&apos;"/>
+            <symbol>p</symbol>
+        </error>
+        <error id="uninitvar" severity="warning" msg="Uninitialized variable: x &lt; 0" verbose="Uninitialized variable: x &lt; 0" cwe="457" inconclusive="true">
+            <location file="util.h" line="5" column="9"/>
+        </error>
+        <error id="missingIncludeSystem" severity="information" msg="Include file: &lt;stdio.h&gt; not found."/>
+        <error id="unusedFunction" severity="style" msg="The function &apos;f&apos; is never used." cwe="561">
+            <location file="/usr/include/other.h" line="1" column="1"/>
+        </error>
+    </errors>
+</results>
+`
+
+func TestCppAnalyzerService_ParseOutput(t *testing.T) {
+	service := NewCppAnalyzerService()
+
+	ws, err := newWorkspace([]models.FileInput{
+		{Path: "src/main.c", Content: "#include \"util.h\"\n"},
+		{Path: "src/util.h", Content: ""},
+	})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer ws.Close()
+
+	findings, err := service.parseOutput(ws, []byte(cppcheckReport))
+	if err != nil {
+		t.Fatalf("parseOutput() error = %v", err)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("parseOutput() returned findings for %d files, want 2: %v", len(findings), findings)
+	}
+
+	main := findings["src/main.c"]
+	if len(main) != 1 {
+		t.Fatalf("parseOutput() returned %d findings for src/main.c, want 1", len(main))
+	}
+	got := main[0]
+	if got.Line != 7 || got.Column != 6 || got.RuleID != "nullPointer" || got.Severity != models.SeverityError || got.CWE != 476 {
+		t.Errorf("finding = %+v, want nullPointer error at 7:6 with CWE 476", got)
+	}
+	if got.Comment != "Null pointer dereference: p" {
+		t.Errorf("finding.Comment = %q", got.Comment)
+	}
+	if got.Detail != "Null pointer dereference: p. The pointer 'p' is assigned NULL & then dereferenced." {
+		t.Errorf("finding.Detail = %q, want the unescaped verbose message", got.Detail)
+	}
+	if len(got.Locations) != 2 || got.Locations[1].Path != "src/util.h" || got.Locations[1].Line != 3 || got.Locations[1].Info != "Assignment 'p=NULL'" {
+		t.Errorf("finding.Locations = %+v, want both locations mapped to the submitted paths", got.Locations)
+	}
+
+	util := findings["src/util.h"]
+	if len(util) != 1 {
+		t.Fatalf("parseOutput() returned %d findings for src/util.h, want 1", len(util))
+	}
+	got = util[0]
+	if !got.Inconclusive || got.Comment != "Uninitialized variable: x < 0" || got.Detail != "" || got.Locations != nil {
+		t.Errorf("finding = %+v, want an inconclusive finding without extra details", got)
+	}
+}
+
+func TestCppAnalyzerService_ParseOutputInvalid(t *testing.T) {
+	service := NewCppAnalyzerService()
+
+	ws, err := newWorkspace([]models.FileInput{{Path: "main.c", Content: ""}})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer ws.Close()
+
+	if findings, err := service.parseOutput(ws, []byte("Checking main.c ...\n")); err == nil || len(findings) != 0 {
+		t.Errorf("parseOutput() = %v, %v, want an error and no findings for non-XML output", findings, err)
+	}
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"cpp_analyzer_service/internal/models"
+)
+
+// ErrInvalidOptions is returned by Analyze when the request options cannot
+// be applied; the controller reports it as a bad request.
+var ErrInvalidOptions = errors.New("invalid options")
+
+// severityRank orders severities for the min_severity option.
+var severityRank = map[string]int{
+	models.SeverityInfo:    0,
+	models.SeverityWarning: 1,
+	models.SeverityError:   2,
+}
+
+func validateOptions(opts *models.AnalyzeOptions) error {
+	if opts == nil {
+		return nil
+	}
+	if _, ok := severityRank[opts.MinSeverity]; opts.MinSeverity != "" && !ok {
+		return fmt.Errorf("%w: min_severity must be one of error, warning, info", ErrInvalidOptions)
+	}
+	if opts.MaxFindings < 0 {
+		return fmt.Errorf("%w: max_findings must not be negative", ErrInvalidOptions)
+	}
+	for _, rules := range [][]string{opts.EnableRules, opts.DisableRules} {
+		for _, rule := range rules {
+			if strings.TrimSpace(rule) == "" {
+				return fmt.Errorf("%w: rule IDs must not be empty", ErrInvalidOptions)
+			}
+		}
+	}
+	return nil
+}
+
+// applyOptions filters the findings of a result by the request options.
+// Disabled checks are also suppressed through --suppress; the filter covers
+// the enable_rules allowlist, min_severity and max_findings.
+func applyOptions(result models.FileResult, opts *models.AnalyzeOptions) models.FileResult {
+	if opts == nil || len(result.LineComments) == 0 {
+		return result
+	}
+
+	lineComments := make([]models.LineComment, 0, len(result.LineComments))
+	for _, lineComment := range result.LineComments {
+		if opts.MaxFindings > 0 && len(lineComments) == opts.MaxFindings {
+			break
+		}
+		if keepFinding(lineComment, opts) {
+			lineComments = append(lineComments, lineComment)
+		}
+	}
+
+	result.LineComments = lineComments
+	if len(lineComments) == 0 {
+		result.Status = models.StatusOK
+		result.Comment = "OK"
+	}
+	return result
+}
+
+func keepFinding(lineComment models.LineComment, opts *models.AnalyzeOptions) bool {
+	if len(opts.EnableRules) > 0 && !matchesAnyRule(lineComment.RuleID, opts.EnableRules) {
+		return false
+	}
+	if matchesAnyRule(lineComment.RuleID, opts.DisableRules) {
+		return false
+	}
+	if opts.MinSeverity != "" && severityRank[lineComment.Severity] < severityRank[opts.MinSeverity] {
+		return false
+	}
+	return true
+}
+
+// optionArgs suppresses disabled checks in cppcheck itself.
+func optionArgs(opts *models.AnalyzeOptions) []string {
+	if opts == nil {
+		return nil
+	}
+
+	var args []string
+	for _, rule := range opts.DisableRules {
+		args = append(args, "--suppress="+rule)
+	}
+	return args
+}
+
+func matchesAnyRule(ruleID string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matchesRule(ruleID, strings.TrimSpace(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesRule(ruleID, pattern string) bool {
+	return ruleID == pattern
+}
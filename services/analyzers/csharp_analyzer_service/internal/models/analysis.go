@@ -1,7 +1,35 @@
 package models
 
+// Statuses of a file result: the tool ran and found nothing (ok) or
+// findings (issues), it failed (tool_error), the file is not one the
+// analyzer handles (unsupported), or the analysis did not finish in time.
+const (
+	StatusOK          = "ok"
+	StatusIssues      = "issues"
+	StatusToolError   = "tool_error"
+	StatusUnsupported = "unsupported"
+	StatusTimeout     = "timeout"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
 type AnalyzeRequest struct {
-	Files []FileInput `json:"files"`
+	Files   []FileInput     `json:"files"`
+	Options *AnalyzeOptions `json:"options,omitempty"`
+}
+
+// AnalyzeOptions tunes which findings are reported. EnableRules restricts the
+// report to the listed rules, DisableRules drops rules, MinSeverity drops
+// findings below the given severity and MaxFindings caps findings per file.
+type AnalyzeOptions struct {
+	EnableRules  []string `json:"enable_rules,omitempty"`
+	DisableRules []string `json:"disable_rules,omitempty"`
+	MinSeverity  string   `json:"min_severity,omitempty"`
+	MaxFindings  int      `json:"max_findings,omitempty"`
 }
 
 type FileInput struct {
@@ -14,13 +42,31 @@ type AnalyzeResponse struct {
 }
 
 type FileResult struct {
-	Path         string       `json:"path"`
-	Comment      string       `json:"comment"`
+	Path         string        `json:"path"`
+	Status       string        `json:"status,omitempty"`
+	Comment      string        `json:"comment"`
+	Stderr       string        `json:"stderr,omitempty"`
 	LineComments []LineComment `json:"line_comments"`
 }
 
 type LineComment struct {
-	Line    int    `json:"line"`
-	Comment string `json:"comment"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+	EndColumn int    `json:"end_column,omitempty"`
+	RuleID    string `json:"rule_id,omitempty"`
+	Severity  string `json:"severity,omitempty"`
+	Tool      string `json:"tool,omitempty"`
+	Comment   string `json:"comment"`
+}
+
+// CacheStats describes the result cache of an analyzer service.
+type CacheStats struct {
+	Enabled    bool   `json:"enabled"`
+	Persistent bool   `json:"persistent"`
+	Entries    int    `json:"entries"`
+	Capacity   int    `json:"capacity"`
+	Hits       uint64 `json:"hits"`
+	Misses     uint64 `json:"misses"`
 }
 
@@ -0,0 +1,222 @@
+package sarif
+
+import (
+	"net/url"
+	"strings"
+
+	"csharp_analyzer_service/internal/models"
+)
+
+const (
+	Version   = "2.1.0"
+	SchemaURI = "https://json.schemastore.org/sarif-2.1.0.json"
+	MediaType = "application/sarif+json"
+)
+
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []Run  `json:"runs"`
+}
+
+type Run struct {
+	Tool        Tool         `json:"tool"`
+	Invocations []Invocation `json:"invocations,omitempty"`
+	Results     []Result     `json:"results"`
+}
+
+// Invocation records whether the tool ran successfully; files it failed on
+// or did not finish in time are listed as notifications.
+type Invocation struct {
+	ExecutionSuccessful        bool           `json:"executionSuccessful"`
+	ToolExecutionNotifications []Notification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type Notification struct {
+	Level     string     `json:"level"`
+	Message   Message    `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+}
+
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+type Driver struct {
+	Name  string `json:"name"`
+	Rules []Rule `json:"rules"`
+}
+
+type Rule struct {
+	ID                   string                  `json:"id"`
+	DefaultConfiguration *ReportingConfiguration `json:"defaultConfiguration,omitempty"`
+}
+
+type ReportingConfiguration struct {
+	Level string `json:"level"`
+}
+
+type Result struct {
+	RuleID    string     `json:"ruleId,omitempty"`
+	RuleIndex *int       `json:"ruleIndex,omitempty"`
+	Level     string     `json:"level"`
+	Message   Message    `json:"message"`
+	Locations []Location `json:"locations"`
+}
+
+type Message struct {
+	Text string `json:"text"`
+}
+
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+type ArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type Region struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// FromResponse converts an analysis response into a SARIF log with one run
+// per tool. Line comments without a tool are attributed to defaultTool, as
+// are files the analysis failed or timed out on, which mark its invocation
+// as unsuccessful.
+func FromResponse(resp *models.AnalyzeResponse, defaultTool string) *Log {
+	var runs []*Run
+	runByTool := make(map[string]*Run)
+	ruleIndex := make(map[string]map[string]int)
+
+	runFor := func(tool string) *Run {
+		if run, ok := runByTool[tool]; ok {
+			return run
+		}
+		run := &Run{
+			Tool:    Tool{Driver: Driver{Name: tool, Rules: []Rule{}}},
+			Results: []Result{},
+		}
+		runByTool[tool] = run
+		ruleIndex[tool] = make(map[string]int)
+		runs = append(runs, run)
+		return run
+	}
+
+	for _, file := range resp.Files {
+		for _, comment := range file.LineComments {
+			tool := comment.Tool
+			if tool == "" {
+				tool = defaultTool
+			}
+			run := runFor(tool)
+
+			result := Result{
+				RuleID:    comment.RuleID,
+				Level:     level(comment.Severity),
+				Message:   Message{Text: comment.Comment},
+				Locations: []Location{location(file.Path, comment)},
+			}
+
+			if comment.RuleID != "" {
+				index, ok := ruleIndex[tool][comment.RuleID]
+				if !ok {
+					index = len(run.Tool.Driver.Rules)
+					ruleIndex[tool][comment.RuleID] = index
+					run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, Rule{
+						ID:                   comment.RuleID,
+						DefaultConfiguration: &ReportingConfiguration{Level: result.Level},
+					})
+				}
+				result.RuleIndex = &index
+			}
+
+			run.Results = append(run.Results, result)
+		}
+	}
+
+	var notifications []Notification
+	for _, file := range resp.Files {
+		if file.Status != models.StatusToolError && file.Status != models.StatusTimeout {
+			continue
+		}
+		text := file.Comment
+		if file.Stderr != "" {
+			text += "\n" + file.Stderr
+		}
+		notifications = append(notifications, Notification{
+			Level:     "error",
+			Message:   Message{Text: text},
+			Locations: []Location{location(file.Path, models.LineComment{})},
+		})
+	}
+	if len(notifications) > 0 {
+		run := runFor(defaultTool)
+		run.Invocations = []Invocation{{
+			ExecutionSuccessful:        false,
+			ToolExecutionNotifications: notifications,
+		}}
+	}
+
+	if len(runs) == 0 {
+		runFor(defaultTool)
+	}
+
+	log := &Log{
+		Schema:  SchemaURI,
+		Version: Version,
+		Runs:    make([]Run, 0, len(runs)),
+	}
+	for _, run := range runs {
+		log.Runs = append(log.Runs, *run)
+	}
+	return log
+}
+
+func location(path string, comment models.LineComment) Location {
+	loc := Location{
+		PhysicalLocation: PhysicalLocation{
+			ArtifactLocation: ArtifactLocation{URI: artifactURI(path)},
+		},
+	}
+
+	// SARIF regions are 1-based; findings without a line apply to the whole file.
+	if comment.Line > 0 {
+		region := &Region{StartLine: comment.Line}
+		if comment.Column > 0 {
+			region.StartColumn = comment.Column
+		}
+		if comment.EndLine >= comment.Line {
+			region.EndLine = comment.EndLine
+			if comment.EndColumn > 0 {
+				region.EndColumn = comment.EndColumn
+			}
+		}
+		loc.PhysicalLocation.Region = region
+	}
+
+	return loc
+}
+
+func artifactURI(path string) string {
+	return (&url.URL{Path: strings.ReplaceAll(path, "\\", "/")}).String()
+}
+
+func level(severity string) string {
+	switch severity {
+	case models.SeverityError:
+		return "error"
+	case models.SeverityInfo:
+		return "note"
+	default:
+		return "warning"
+	}
+}
@@ -1,18 +1,45 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
-	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"csharp_analyzer_service/internal/models"
 )
 
+const (
+	defaultFileTimeout    = 30 * time.Second
+	defaultRequestTimeout = 2 * time.Minute
+
+	// defaultTargetFramework is the target framework of the project generated
+	// around uploaded files; the SDK ships its reference assemblies, so it
+	// builds offline.
+	defaultTargetFramework = "net8.0"
+
+	// waitDelay bounds how long we wait for output pipes after the tool
+	// process has been killed on cancellation.
+	waitDelay = 2 * time.Second
+)
+
 type CsharpAnalyzerService struct {
-	dotnetPath string
+	dotnetPath      string
+	targetFramework string
+	packageSource   string
+	fileTimeout     time.Duration
+	requestTimeout  time.Duration
+	maxWorkers      int
+	processes       processLimiter
+	cache           *resultCache
+
+	versionOnce sync.Once
+	version     string
+	versionOK   bool
 }
 
 func NewCsharpAnalyzerService() *CsharpAnalyzerService {
@@ -21,89 +48,116 @@ func NewCsharpAnalyzerService() *CsharpAnalyzerService {
 		dotnetPath = "dotnet"
 	}
 
+	targetFramework := os.Getenv("DOTNET_TARGET_FRAMEWORK")
+	if targetFramework == "" {
+		targetFramework = defaultTargetFramework
+	}
+
 	return &CsharpAnalyzerService{
-		dotnetPath: dotnetPath,
+		dotnetPath:      dotnetPath,
+		targetFramework: targetFramework,
+		packageSource:   os.Getenv("NUGET_SOURCE"),
+		fileTimeout:     durationFromEnv("ANALYZER_FILE_TIMEOUT", defaultFileTimeout),
+		requestTimeout:  durationFromEnv("ANALYZER_REQUEST_TIMEOUT", defaultRequestTimeout),
+		maxWorkers:      intFromEnv("MAX_WORKERS", runtime.NumCPU()),
+		processes:       newProcessLimiter(intFromEnv("MAX_PROCESSES", runtime.NumCPU())),
+		cache:           cacheFromEnv(),
 	}
 }
 
-func (s *CsharpAnalyzerService) Analyze(req *models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
-	var results []models.FileResult
+// durationFromEnv parses a Go duration (e.g. "30s") from the environment,
+// falling back to the default when the variable is unset or invalid.
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
 
-	for _, file := range req.Files {
+// intFromEnv parses a positive integer from the environment, falling back to
+// the default when the variable is unset or invalid.
+func intFromEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func (s *CsharpAnalyzerService) Analyze(ctx context.Context, req *models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
+	if err := validateOptions(req.Options); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	results := make([]models.FileResult, len(req.Files))
+	var pending []int
+
+	for i, file := range req.Files {
 		if !strings.HasSuffix(strings.ToLower(file.Path), ".cs") {
-			results = append(results, models.FileResult{
+			results[i] = models.FileResult{
 				Path:         file.Path,
+				Status:       models.StatusUnsupported,
 				Comment:      "Not a C# file",
 				LineComments: []models.LineComment{},
-			})
+			}
 			continue
 		}
-
-		result := s.analyzeFile(file.Path, file.Content)
-		results = append(results, result)
+		pending = append(pending, i)
 	}
 
-	return &models.AnalyzeResponse{Files: results}, nil
-}
-
-func (s *CsharpAnalyzerService) analyzeFile(path, content string) models.FileResult {
-	tmpDir := os.TempDir()
-	tmpFile := filepath.Join(tmpDir, fmt.Sprintf("analyze_%d_%s", time.Now().UnixNano(), filepath.Base(path)))
-	defer os.Remove(tmpFile)
+	keys := s.cacheKeys(req, pending)
+	pending = s.cache.lookup(keys, req.Files, results, pending)
+	if len(pending) == 0 {
+		return &models.AnalyzeResponse{Files: results}, nil
+	}
 
-	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
-		return models.FileResult{
-			Path:         path,
-			Comment:      fmt.Sprintf("Error: failed to create temp file: %v", err),
-			LineComments: []models.LineComment{},
+	// Every file of the request is written to the workspace, including project files, so the build sees the real project.
+	ws, err := newWorkspace(req.Files)
+	if err != nil {
+		for _, i := range pending {
+			results[i] = models.FileResult{
+				Path:         req.Files[i].Path,
+				Status:       models.StatusToolError,
+				Comment:      fmt.Sprintf("Error: failed to create workspace: %v", err),
+				LineComments: []models.LineComment{},
+			}
 		}
+		return &models.AnalyzeResponse{Files: results}, nil
+	}
+	defer ws.Close()
+
+	// The compiler analyzes the whole compilation at once, so there is one
+	// build per request rather than one tool run per batch of files; MSBuild
+	// builds the projects of a solution in parallel instead.
+	paths := make([]string, len(pending))
+	for j, i := range pending {
+		paths[j] = req.Files[i].Path
+	}
+	for j, result := range s.analyzeProject(ctx, ws, req.Options, paths) {
+		results[pending[j]] = result
 	}
 
-	// Use dotnet format analyze or similar
-	cmd := exec.Command(s.dotnetPath, "format", "analyze", "--verbosity", "diagnostic", tmpFile)
-	output, err := cmd.CombinedOutput()
-
-	if err != nil && len(output) == 0 {
-		return models.FileResult{
-			Path:         path,
-			Comment:      "OK",
-			LineComments: []models.LineComment{},
-		}
+	for i := range results {
+		results[i] = applyOptions(results[i], req.Options)
 	}
+	s.cache.store(keys, results, pending)
 
-	return s.parseOutput(path, string(output))
+	return &models.AnalyzeResponse{Files: results}, nil
 }
 
-func (s *CsharpAnalyzerService) parseOutput(path, output string) models.FileResult {
-	lines := strings.Split(strings.TrimSpace(output), "\n")
-	var lineComments []models.LineComment
-	comment := "OK"
-
-	for _, line := range lines {
-		if strings.Contains(line, "warning") || strings.Contains(line, "error") {
-			// Parse dotnet format output
-			parts := strings.Split(line, ":")
-			if len(parts) >= 3 {
-				var lineNum int
-				fmt.Sscanf(parts[1], "%d", &lineNum)
-				if lineNum > 0 {
-					msg := strings.Join(parts[2:], ":")
-					lineComments = append(lineComments, models.LineComment{
-						Line:    lineNum,
-						Comment: strings.TrimSpace(msg),
-					})
-					if comment == "OK" {
-						comment = "Issues found"
-					}
-				}
-			}
+func timeoutResults(paths []string) []models.FileResult {
+	results := make([]models.FileResult, len(paths))
+	for i, path := range paths {
+		results[i] = models.FileResult{
+			Path:         path,
+			Status:       models.StatusTimeout,
+			Comment:      "Analysis timed out",
+			LineComments: []models.LineComment{},
 		}
 	}
-
-	return models.FileResult{
-		Path:         path,
-		Comment:      comment,
-		LineComments: lineComments,
-	}
+	return results
 }
-
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"csharp_analyzer_service/internal/models"
+)
+
+// errorLog is the part of a SARIF 2.1 log written by the compiler's
+// /errorlog option that the service reads.
+type errorLog struct {
+	Runs []struct {
+		Results []errorLogResult `json:"results"`
+	} `json:"runs"`
+}
+
+type errorLogResult struct {
+	RuleID  string `json:"ruleId"`
+	Level   string `json:"level"`
+	Message struct {
+		Text string `json:"text"`
+	} `json:"message"`
+	Locations []struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region struct {
+				StartLine   int `json:"startLine"`
+				StartColumn int `json:"startColumn"`
+				EndLine     int `json:"endLine"`
+				EndColumn   int `json:"endColumn"`
+			} `json:"region"`
+		} `json:"physicalLocation"`
+	} `json:"locations"`
+	Suppressions []json.RawMessage `json:"suppressions"`
+}
+
+// readErrorLogs groups the diagnostics of every SARIF log in dir by original
+// path and returns them with the number of logs read. Diagnostics suppressed
+// in source are skipped, and diagnostics reported by several logs, as for
+// projects with more than one target framework, are kept once.
+func readErrorLogs(ws *workspace, dir string) (map[string][]models.LineComment, int, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sarif"))
+	if err != nil {
+		return nil, 0, err
+	}
+	sort.Strings(paths)
+
+	findings := make(map[string][]models.LineComment)
+	seen := make(map[string]map[models.LineComment]bool)
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, 0, err
+		}
+		var log errorLog
+		if err := json.Unmarshal(data, &log); err != nil {
+			return nil, 0, fmt.Errorf("invalid diagnostics log %s: %w", filepath.Base(p), err)
+		}
+
+		for _, run := range log.Runs {
+			for _, result := range run.Results {
+				if len(result.Suppressions) > 0 || len(result.Locations) == 0 {
+					continue
+				}
+				location := result.Locations[0].PhysicalLocation
+				path, ok := ws.originalPath(uriPath(location.ArtifactLocation.URI))
+				if !ok {
+					continue
+				}
+
+				lineComment := models.LineComment{
+					Line:      location.Region.StartLine,
+					Column:    location.Region.StartColumn,
+					EndLine:   location.Region.EndLine,
+					EndColumn: location.Region.EndColumn,
+					RuleID:    result.RuleID,
+					Severity:  dotnetSeverity(result.Level),
+					Tool:      "roslyn",
+					Comment:   strings.TrimSpace(result.Message.Text),
+				}
+				if seen[path] == nil {
+					seen[path] = make(map[models.LineComment]bool)
+				}
+				if seen[path][lineComment] {
+					continue
+				}
+				seen[path][lineComment] = true
+				findings[path] = append(findings[path], lineComment)
+			}
+		}
+	}
+
+	for _, lineComments := range findings {
+		sort.SliceStable(lineComments, func(a, b int) bool {
+			if lineComments[a].Line != lineComments[b].Line {
+				return lineComments[a].Line < lineComments[b].Line
+			}
+			return lineComments[a].Column < lineComments[b].Column
+		})
+	}
+	return findings, len(paths), nil
+}
+
+// uriPath returns the file system path of a SARIF artifact URI such as
+// "file:///tmp/analyze_1/project/Program.cs".
+func uriPath(uri string) string {
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		return filepath.FromSlash(u.Path)
+	}
+	return filepath.FromSlash(uri)
+}
+
+// dotnetSeverity maps SARIF levels onto error/warning/info. The level is
+// optional in SARIF and defaults to warning.
+func dotnetSeverity(level string) string {
+	switch level {
+	case "error":
+		return models.SeverityError
+	case "warning", "":
+		return models.SeverityWarning
+	default:
+		return models.SeverityInfo
+	}
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"csharp_analyzer_service/internal/models"
+)
+
+// errorLogNet8 and errorLogNet9 are the SARIF logs of a project built for
+// two target frameworks; both report the diagnostics of the shared sources.
+const errorLogNet8 = `{
+  "$schema": "http://json.schemastore.org/sarif-2.1.0",
+  "version": "2.1.0",
+  "runs": [{
+    "results": [
+      {"ruleId": "CA1822", "level": "note", "message": {"text": "Member 'Run' does not access instance data and can be marked as static "},
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "file://%[1]s/App/Services/My%%20Service.cs"}, "region": {"startLine": 7, "startColumn": 21, "endLine": 7, "endColumn": 24}}}]},
+      {"ruleId": "CS0168", "level": "warning", "message": {"text": "The variable 'e' is declared but never used"},
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "file://%[1]s/App/Program.cs"}, "region": {"startLine": 9, "startColumn": 30, "endLine": 9, "endColumn": 31}}}]},
+      {"ruleId": "CS1002", "level": "error", "message": {"text": "; expected"},
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "file://%[1]s/App/Program.cs"}, "region": {"startLine": 4, "startColumn": 18, "endLine": 4, "endColumn": 18}}}]},
+      {"ruleId": "IDE0005", "message": {"text": "Using directive is unnecessary."},
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "file://%[1]s/App/Program.cs"}, "region": {"startLine": 1, "startColumn": 1, "endLine": 1, "endColumn": 14}}}],
+       "suppressions": [{"kind": "inSource"}]},
+      {"ruleId": "CS8019", "level": "warning", "message": {"text": "Unnecessary using directive."},
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "file://%[1]s/obj/Debug/net8.0/App.GlobalUsings.g.cs"}, "region": {"startLine": 2, "startColumn": 1}}}]},
+      {"ruleId": "CS8021", "level": "warning", "message": {"text": "No value for RuntimeMetadataVersion found."}}
+    ]
+  }]
+}`
+
+const errorLogNet9 = `{
+  "version": "2.1.0",
+  "runs": [{
+    "results": [
+      {"ruleId": "CS0168", "level": "warning", "message": {"text": "The variable 'e' is declared but never used"},
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "%[1]s/App/Program.cs"}, "region": {"startLine": 9, "startColumn": 30, "endLine": 9, "endColumn": 31}}}]},
+      {"ruleId": "CS0219", "level": "warning", "message": {"text": "The variable 'x' is assigned but its value is never used"},
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "file:///C:/build/App/Program.cs"}, "region": {"startLine": 2, "startColumn": 5}}}]}
+    ]
+  }]
+}`
+
+func TestReadErrorLogs(t *testing.T) {
+	// Paths uploaded from Windows use backslashes; results are reported
+	// under the path as it was submitted.
+	ws, err := newWorkspace([]models.FileInput{
+		{Path: `src\App\Program.cs`, Content: ""},
+		{Path: `src\App\Services\My Service.cs`, Content: ""},
+	})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer ws.Close()
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{"App-net8.0.sarif": errorLogNet8, "App-net9.0.sarif": errorLogNet9} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(fmt.Sprintf(content, ws.root)), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	findings, logs, err := readErrorLogs(ws, dir)
+	if err != nil {
+		t.Fatalf("readErrorLogs() error = %v", err)
+	}
+	if logs != 2 {
+		t.Errorf("readErrorLogs() read %d logs, want 2", logs)
+	}
+
+	program := findings[`src\App\Program.cs`]
+	if len(program) != 2 {
+		t.Fatalf("readErrorLogs() for Program.cs = %+v, want CS1002 and CS0168 once each", program)
+	}
+	want := models.LineComment{Line: 4, Column: 18, EndLine: 4, EndColumn: 18, RuleID: "CS1002", Severity: models.SeverityError, Tool: "roslyn", Comment: "; expected"}
+	if program[0] != want {
+		t.Errorf("finding = %+v, want %+v", program[0], want)
+	}
+	if program[1].RuleID != "CS0168" || program[1].Severity != models.SeverityWarning {
+		t.Errorf("finding = %+v, want CS0168 as warning", program[1])
+	}
+
+	myService := findings[`src\App\Services\My Service.cs`]
+	if len(myService) != 1 || myService[0].RuleID != "CA1822" || myService[0].Severity != models.SeverityInfo || myService[0].Comment != "Member 'Run' does not access instance data and can be marked as static" {
+		t.Errorf("readErrorLogs() for My Service.cs = %+v, want CA1822 as info", myService)
+	}
+	if len(findings) != 2 {
+		t.Errorf("readErrorLogs() returned findings for %d files, want 2", len(findings))
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "Broken-net8.0.sarif"), []byte("{"), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	if _, _, err := readErrorLogs(ws, dir); err == nil {
+		t.Error("readErrorLogs() with an invalid log succeeded, want an error")
+	}
+}
+
+func TestReadErrorLogs_NoLogs(t *testing.T) {
+	ws, err := newWorkspace([]models.FileInput{{Path: "Program.cs"}})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer ws.Close()
+
+	findings, logs, err := readErrorLogs(ws, t.TempDir())
+	if err != nil || logs != 0 || len(findings) != 0 {
+		t.Errorf("readErrorLogs() of an empty directory = %v, %d, %v, want no findings and no logs", findings, logs, err)
+	}
+}
+
+func TestDotnetSeverity(t *testing.T) {
+	tests := []struct {
+		level string
+		want  string
+	}{
+		{level: "error", want: models.SeverityError},
+		{level: "warning", want: models.SeverityWarning},
+		{level: "", want: models.SeverityWarning},
+		{level: "note", want: models.SeverityInfo},
+		{level: "none", want: models.SeverityInfo},
+	}
+
+	for _, tt := range tests {
+		if got := dotnetSeverity(tt.level); got != tt.want {
+			t.Errorf("dotnetSeverity(%q) = %q, want %q", tt.level, got, tt.want)
+		}
+	}
+}
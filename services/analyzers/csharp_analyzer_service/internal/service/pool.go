@@ -0,0 +1,28 @@
+package service
+
+import "context"
+
+// processLimiter caps the number of tool processes running at the same time
+// across all requests handled by the service.
+type processLimiter chan struct{}
+
+func newProcessLimiter(limit int) processLimiter {
+	if limit < 1 {
+		limit = 1
+	}
+	return make(processLimiter, limit)
+}
+
+// acquire blocks until a process slot is free or ctx is done.
+func (l processLimiter) acquire(ctx context.Context) error {
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l processLimiter) release() {
+	<-l
+}
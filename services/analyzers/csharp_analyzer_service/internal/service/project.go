@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"csharp_analyzer_service/internal/models"
+)
+
+// analyzerTargets is imported into every project of the build through
+// CustomBeforeMicrosoftCommonTargets. It enables the .NET analyzers and the
+// code style rules unless the project configures them itself, suppresses the
+// rules the request disables and has the compiler write the diagnostics of
+// each project to a SARIF log in the given directory.
+const analyzerTargets = `<Project>
+  <PropertyGroup>
+    <EnableNETAnalyzers Condition="'$(EnableNETAnalyzers)' == ''">true</EnableNETAnalyzers>
+    <AnalysisLevel Condition="'$(AnalysisLevel)' == ''">latest-recommended</AnalysisLevel>
+    <EnforceCodeStyleInBuild Condition="'$(EnforceCodeStyleInBuild)' == ''">true</EnforceCodeStyleInBuild>
+    <NoWarn>$(NoWarn)%s</NoWarn>
+    <ErrorLog>%s/$(MSBuildProjectName)-$(TargetFramework).sarif,version=2.1</ErrorLog>
+  </PropertyGroup>
+</Project>
+`
+
+// generatedProject is the project built around the uploaded files when the
+// request has no project or solution at its root. It compiles every C# file
+// of the tree and imports the project's Directory.Build.props and
+// Directory.Build.targets, which MSBuild would not find from outside it.
+const generatedProject = `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>%[1]s</TargetFramework>
+    <OutputType>Library</OutputType>
+    <LangVersion>latest</LangVersion>
+    <Nullable>enable</Nullable>
+    <ImplicitUsings>enable</ImplicitUsings>
+    <EnableDefaultCompileItems>false</EnableDefaultCompileItems>
+  </PropertyGroup>
+  <Import Project="%[2]s/Directory.Build.props" Condition="Exists('%[2]s/Directory.Build.props')" />
+  <ItemGroup>
+    <Compile Include="%[3]s/**/*.cs" Exclude="%[3]s/**/bin/**;%[3]s/**/obj/**" />
+  </ItemGroup>
+  <Import Project="%[2]s/Directory.Build.targets" Condition="Exists('%[2]s/Directory.Build.targets')" />
+</Project>
+`
+
+// rootProject returns the solution or, failing that, the project file at
+// the project root that "dotnet build" should build, or "" when there is no
+// single one.
+func rootProject(ws *workspace) string {
+	entries, err := os.ReadDir(ws.root)
+	if err != nil {
+		return ""
+	}
+	byExt := make(map[string][]string)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			byExt[ext] = append(byExt[ext], entry.Name())
+		}
+	}
+	for _, ext := range []string{".sln", ".slnx", ".csproj"} {
+		if len(byExt[ext]) == 1 {
+			return byExt[ext][0]
+		}
+	}
+	return ""
+}
+
+// analyzeProject builds the uploaded solution or project, or a generated
+// project around the uploaded files, once for the whole request and returns
+// one result per path, in the same order. The build restores offline from
+// the configured local package source only.
+func (s *CsharpAnalyzerService) analyzeProject(ctx context.Context, ws *workspace, opts *models.AnalyzeOptions, paths []string) []models.FileResult {
+	// Waiting for a free process slot does not count against the deadline.
+	if err := s.processes.acquire(ctx); err != nil {
+		return timeoutResults(paths)
+	}
+	defer s.processes.release()
+
+	ctx, cancel := context.WithTimeout(ctx, s.fileTimeout*time.Duration(len(paths)))
+	defer cancel()
+
+	args, logDir, err := s.buildArgs(ws, opts)
+	if err != nil {
+		return toolErrorResults(paths, "dotnet build", err, nil)
+	}
+	stdout, stderr, runErr := ws.run(ctx, s.dotnetPath, args...)
+
+	if ctx.Err() != nil {
+		return timeoutResults(paths)
+	}
+
+	// Compile errors fail the build but are diagnostics like any other. The
+	// build itself failed when the compiler wrote no log, e.g. because the
+	// restore or the project evaluation failed; MSBuild reports why on stdout.
+	findings, logs, err := readErrorLogs(ws, logDir)
+	if err != nil || logs == 0 {
+		if err == nil {
+			err = runErr
+		}
+		if err == nil {
+			err = errors.New("no diagnostics were written")
+		}
+		return toolErrorResults(paths, "dotnet build", err, append(stdout, stderr...))
+	}
+
+	return fileResults(paths, findings)
+}
+
+// buildArgs writes the files the build needs next to the project tree and
+// returns the "dotnet build" arguments and the directory the SARIF logs are
+// written to.
+func (s *CsharpAnalyzerService) buildArgs(ws *workspace, opts *models.AnalyzeOptions) ([]string, string, error) {
+	logDir := filepath.Join(ws.dir, "sarif")
+	source := filepath.Join(ws.dir, "packages")
+	for _, dir := range []string{logDir, source} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, "", err
+		}
+	}
+	if s.packageSource != "" {
+		source = s.packageSource
+	}
+
+	targets, err := ws.writeGenerated("analyzers.targets", []byte(fmt.Sprintf(analyzerTargets, noWarn(opts), logDir)))
+	if err != nil {
+		return nil, "", err
+	}
+
+	project := rootProject(ws)
+	if project == "" {
+		root, _ := filepath.Rel(ws.dir, ws.root)
+		tree, _ := filepath.Rel(ws.dir, ws.tree)
+		content := fmt.Sprintf(generatedProject, s.targetFramework, filepath.ToSlash(root), filepath.ToSlash(tree))
+		if project, err = ws.writeGenerated("Analyze.csproj", []byte(content)); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return []string{
+		"build", project,
+		"--source", source,
+		"-nologo",
+		"-verbosity:quiet",
+		"-clp:NoSummary",
+		"-maxCpuCount:" + strconv.Itoa(s.maxWorkers),
+		"-nodeReuse:false",
+		"-p:UseSharedCompilation=false",
+		"-p:CustomBeforeMicrosoftCommonTargets=" + targets,
+	}, logDir, nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"csharp_analyzer_service/internal/models"
+)
+
+func TestRootProject(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{name: "sources only", files: []string{"Program.cs"}, want: ""},
+		{name: "project", files: []string{"App.csproj", "Program.cs"}, want: "App.csproj"},
+		{name: "solution over projects", files: []string{"App.sln", "App.csproj", "App.Tests.csproj", "Program.cs"}, want: "App.sln"},
+		{name: "several projects", files: []string{"App.csproj", "App.Tests.csproj", "Program.cs"}, want: ""},
+		{name: "nested project", files: []string{"src/App/App.csproj", "src/App/Program.cs", "README.md"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var files []models.FileInput
+			for _, path := range tt.files {
+				files = append(files, models.FileInput{Path: path})
+			}
+			ws, err := newWorkspace(files)
+			if err != nil {
+				t.Fatalf("newWorkspace() error = %v", err)
+			}
+			defer ws.Close()
+
+			if got := rootProject(ws); got != tt.want {
+				t.Errorf("rootProject() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCsharpAnalyzerService_BuildArgsGeneratedProject(t *testing.T) {
+	service := NewCsharpAnalyzerService()
+	service.targetFramework = "net9.0"
+	service.packageSource = "/opt/nuget"
+	service.maxWorkers = 3
+
+	ws, err := newWorkspace([]models.FileInput{
+		{Path: `app\Program.cs`, Content: "class Program {}\n"},
+		{Path: `app\Directory.Build.props`, Content: "<Project />\n"},
+	})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer ws.Close()
+
+	args, logDir, err := service.buildArgs(ws, &models.AnalyzeOptions{DisableRules: []string{"CA1822", "IDE*", "IDE0005"}})
+	if err != nil {
+		t.Fatalf("buildArgs() error = %v", err)
+	}
+	if args[0] != "build" || args[1] != filepath.Join(ws.dir, "Analyze.csproj") || args[2] != "--source" || args[3] != "/opt/nuget" {
+		t.Errorf("buildArgs() = %v, want to build the generated project from the configured source", args)
+	}
+	if !slices.Contains(args, "-maxCpuCount:3") {
+		t.Errorf("buildArgs() = %v, want MSBuild limited to MAX_WORKERS nodes", args)
+	}
+	if logDir != filepath.Join(ws.dir, "sarif") {
+		t.Errorf("buildArgs() log directory = %q, want %q", logDir, filepath.Join(ws.dir, "sarif"))
+	}
+
+	data, err := os.ReadFile(args[1])
+	if err != nil {
+		t.Fatalf("failed to read generated project: %v", err)
+	}
+	project := string(data)
+	// The project is written next to the tree, so it refers to the project
+	// root and the tree by relative paths.
+	for _, want := range []string{
+		`<Project Sdk="Microsoft.NET.Sdk">`,
+		`<TargetFramework>net9.0</TargetFramework>`,
+		`<EnableDefaultCompileItems>false</EnableDefaultCompileItems>`,
+		`<Import Project="project/app/Directory.Build.props" Condition="Exists('project/app/Directory.Build.props')" />`,
+		`<Compile Include="project/**/*.cs" Exclude="project/**/bin/**;project/**/obj/**" />`,
+		`<Import Project="project/app/Directory.Build.targets" Condition="Exists('project/app/Directory.Build.targets')" />`,
+	} {
+		if !strings.Contains(project, want) {
+			t.Errorf("generated project does not contain %s:\n%s", want, project)
+		}
+	}
+
+	targetsArg := args[len(args)-1]
+	targetsPath, ok := strings.CutPrefix(targetsArg, "-p:CustomBeforeMicrosoftCommonTargets=")
+	if !ok {
+		t.Fatalf("last argument = %q, want the analyzer targets", targetsArg)
+	}
+	data, err = os.ReadFile(targetsPath)
+	if err != nil {
+		t.Fatalf("failed to read analyzer targets: %v", err)
+	}
+	targets := string(data)
+	for _, want := range []string{
+		`<NoWarn>$(NoWarn);CA1822;IDE0005</NoWarn>`,
+		`<ErrorLog>` + logDir + `/$(MSBuildProjectName)-$(TargetFramework).sarif,version=2.1</ErrorLog>`,
+	} {
+		if !strings.Contains(targets, want) {
+			t.Errorf("analyzer targets do not contain %s:\n%s", want, targets)
+		}
+	}
+}
+
+func TestCsharpAnalyzerService_BuildArgsRootProject(t *testing.T) {
+	service := NewCsharpAnalyzerService()
+	service.packageSource = ""
+
+	ws, err := newWorkspace([]models.FileInput{
+		{Path: "App.csproj", Content: "<Project Sdk=\"Microsoft.NET.Sdk\" />\n"},
+		{Path: "Program.cs", Content: "class Program {}\n"},
+	})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer ws.Close()
+
+	args, _, err := service.buildArgs(ws, nil)
+	if err != nil {
+		t.Fatalf("buildArgs() error = %v", err)
+	}
+	// Without a configured source, restore uses an empty directory, so
+	// nothing is downloaded.
+	if args[1] != "App.csproj" || args[3] != filepath.Join(ws.dir, "packages") {
+		t.Errorf("buildArgs() = %v, want to build App.csproj from the empty package source", args)
+	}
+	if _, err := os.Stat(filepath.Join(ws.dir, "Analyze.csproj")); !os.IsNotExist(err) {
+		t.Errorf("buildArgs() generated a project for a request that has one (stat error = %v)", err)
+	}
+}
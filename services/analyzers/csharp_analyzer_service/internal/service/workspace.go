@@ -0,0 +1,214 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"csharp_analyzer_service/internal/models"
+)
+
+// workspace materializes the files of one request as their original relative
+// path tree inside an isolated temporary directory, so tools can resolve
+// includes, imports and package layout the way they would in the project.
+//
+// The tree is written below dir/project; dir itself holds files generated by
+// the service (e.g. converted tool configs) so they never mix with the
+// project. root is the project root the tools run from: the tree itself, or
+// the single top-level directory all files share, as in uploaded archives.
+type workspace struct {
+	dir       string
+	tree      string
+	root      string
+	originals map[string]string // tree-relative slash path -> FileInput.Path
+}
+
+func newWorkspace(files []models.FileInput) (*workspace, error) {
+	dir, err := os.MkdirTemp("", "analyze_*")
+	if err != nil {
+		return nil, err
+	}
+	// Tools report resolved paths, so resolve symlinked temp dirs up front.
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+
+	ws := &workspace{
+		dir:       dir,
+		tree:      filepath.Join(dir, "project"),
+		originals: make(map[string]string, len(files)),
+	}
+	for _, file := range files {
+		rel := relativePath(file.Path)
+		if rel == "" {
+			continue
+		}
+		if _, exists := ws.originals[rel]; exists {
+			continue
+		}
+
+		target := filepath.Join(ws.tree, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			ws.Close()
+			return nil, err
+		}
+		if err := os.WriteFile(target, []byte(file.Content), 0644); err != nil {
+			ws.Close()
+			return nil, err
+		}
+		ws.originals[rel] = file.Path
+	}
+
+	ws.root = filepath.Join(ws.tree, filepath.FromSlash(ws.commonTopDir()))
+	if err := os.MkdirAll(ws.root, 0755); err != nil {
+		ws.Close()
+		return nil, err
+	}
+
+	return ws, nil
+}
+
+// commonTopDir returns the top-level directory shared by every file, or ""
+// when files live at the top level or in different directories.
+func (w *workspace) commonTopDir() string {
+	top := ""
+	for rel := range w.originals {
+		first, _, nested := strings.Cut(rel, "/")
+		if !nested || (top != "" && first != top) {
+			return ""
+		}
+		top = first
+	}
+	return top
+}
+
+// relativePath turns a user-supplied path into a clean relative slash path
+// that cannot escape the workspace directory.
+func relativePath(p string) string {
+	p = strings.ReplaceAll(p, "\\", "/")
+	if len(p) >= 2 && p[1] == ':' {
+		p = p[2:]
+	}
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if p == "." {
+		return ""
+	}
+	return p
+}
+
+func (w *workspace) Close() error {
+	return os.RemoveAll(w.dir)
+}
+
+// relPath returns the path of an original FileInput.Path relative to the
+// project root, as it is passed to the tools.
+func (w *workspace) relPath(original string) string {
+	target := filepath.Join(w.tree, filepath.FromSlash(relativePath(original)))
+	rel, err := filepath.Rel(w.root, target)
+	if err != nil {
+		return target
+	}
+	return rel
+}
+
+// originalPath maps a path reported by a tool, either absolute or relative to
+// the project root, back to the FileInput.Path it was created from.
+func (w *workspace) originalPath(toolPath string) (string, bool) {
+	p := filepath.Clean(toolPath)
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(w.root, p)
+	}
+
+	rel, err := filepath.Rel(w.tree, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	original, ok := w.originals[filepath.ToSlash(rel)]
+	return original, ok
+}
+
+// writeGenerated stores a file generated by the service next to, but outside
+// of, the project tree and returns its absolute path.
+func (w *workspace) writeGenerated(name string, data []byte) (string, error) {
+	target := filepath.Join(w.dir, name)
+	if err := os.WriteFile(target, data, 0644); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// run executes a tool with the project root as working directory and returns
+// its stdout and stderr separately.
+func (w *workspace) run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = w.root
+	cmd.WaitDelay = waitDelay
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+// fileResults builds one result per path, in order, from findings keyed by
+// original path.
+func fileResults(paths []string, findings map[string][]models.LineComment) []models.FileResult {
+	results := make([]models.FileResult, len(paths))
+	for i, p := range paths {
+		lineComments := findings[p]
+		comment := "OK"
+		if len(lineComments) > 0 {
+			comment = "Issues found"
+		} else {
+			lineComments = []models.LineComment{}
+		}
+		results[i] = models.FileResult{
+			Path:         p,
+			Status:       resultStatus(lineComments),
+			Comment:      comment,
+			LineComments: lineComments,
+		}
+	}
+	return results
+}
+
+// resultStatus is the status of a completed analysis with the given findings.
+func resultStatus(lineComments []models.LineComment) string {
+	if len(lineComments) > 0 {
+		return models.StatusIssues
+	}
+	return models.StatusOK
+}
+
+// maxStderr caps the tool output attached to failed results. The end is
+// kept, since that is where tools report what went wrong.
+const maxStderr = 4096
+
+// toolErrorResults builds one result per path for a tool run that failed,
+// with the tool's diagnostic output attached.
+func toolErrorResults(paths []string, tool string, err error, stderr []byte) []models.FileResult {
+	output := strings.TrimSpace(string(stderr))
+	if len(output) > maxStderr {
+		output = "..." + output[len(output)-maxStderr:]
+	}
+
+	results := make([]models.FileResult, len(paths))
+	for i, path := range paths {
+		results[i] = models.FileResult{
+			Path:         path,
+			Status:       models.StatusToolError,
+			Comment:      fmt.Sprintf("Error: %s failed: %v", tool, err),
+			Stderr:       output,
+			LineComments: []models.LineComment{},
+		}
+	}
+	return results
+}
@@ -0,0 +1,5 @@
+module go_analyzer_service
+
+go 1.21
+
+require github.com/gorilla/mux v1.8.1
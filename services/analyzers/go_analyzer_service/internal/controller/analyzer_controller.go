@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"go_analyzer_service/internal/models"
+	"go_analyzer_service/internal/sarif"
+	"go_analyzer_service/internal/service"
+)
+
+type AnalyzerController struct {
+	service *service.GoAnalyzerService
+}
+
+func NewAnalyzerController(service *service.GoAnalyzerService) *AnalyzerController {
+	return &AnalyzerController{service: service}
+}
+
+func (c *AnalyzerController) Analyze(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.AnalyzeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.Analyze(r.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidOptions) {
+			respondError(w, err.Error(), http.StatusBadRequest)
+		} else {
+			respondError(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if wantsSARIF(r) {
+		respondSARIF(w, sarif.FromResponse(resp, "go vet"), http.StatusOK)
+		return
+	}
+
+	respondJSON(w, resp, http.StatusOK)
+}
+
+// CacheStats reports the hit/miss counters and size of the result cache.
+func (c *AnalyzerController) CacheStats(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, c.service.CacheStats(), http.StatusOK)
+}
+
+// wantsSARIF reports whether the client asked for a SARIF log instead of the
+// unified analysis JSON.
+func wantsSARIF(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), sarif.MediaType)
+}
+
+func respondSARIF(w http.ResponseWriter, log *sarif.Log, statusCode int) {
+	w.Header().Set("Content-Type", sarif.MediaType)
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(log)
+}
+
+func respondJSON(w http.ResponseWriter, data interface{}, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go_analyzer_service/internal/models"
+)
+
+const (
+	defaultFileTimeout    = 30 * time.Second
+	defaultRequestTimeout = 2 * time.Minute
+
+	// defaultGoVersion is the language version of the go.mod generated for
+	// files that are not part of a module.
+	defaultGoVersion = "1.21"
+
+	// waitDelay bounds how long we wait for output pipes after the tool
+	// process has been killed on cancellation.
+	waitDelay = 2 * time.Second
+)
+
+// offlineEnv keeps the go command from downloading toolchains or modules and
+// from picking up a go.work outside the workspace. Dependencies resolve from
+// the project's vendor directory or the module cache of the image.
+var offlineEnv = []string{"GOTOOLCHAIN=local", "GOPROXY=off", "GOWORK=off"}
+
+type GoAnalyzerService struct {
+	goPath          string
+	gofmtPath       string
+	staticcheckPath string
+	goVersion       string
+	fileTimeout     time.Duration
+	requestTimeout  time.Duration
+	maxWorkers      int
+	processes       processLimiter
+	cache           *resultCache
+
+	versionOnce sync.Once
+	version     string
+	versionOK   bool
+}
+
+func NewGoAnalyzerService() *GoAnalyzerService {
+	goVersion := os.Getenv("GO_LANGUAGE_VERSION")
+	if goVersion == "" {
+		goVersion = defaultGoVersion
+	}
+
+	return &GoAnalyzerService{
+		goPath:          pathFromEnv("GO_PATH", "go"),
+		gofmtPath:       pathFromEnv("GOFMT_PATH", "gofmt"),
+		staticcheckPath: pathFromEnv("STATICCHECK_PATH", "staticcheck"),
+		goVersion:       goVersion,
+		fileTimeout:     durationFromEnv("ANALYZER_FILE_TIMEOUT", defaultFileTimeout),
+		requestTimeout:  durationFromEnv("ANALYZER_REQUEST_TIMEOUT", defaultRequestTimeout),
+		maxWorkers:      intFromEnv("MAX_WORKERS", runtime.NumCPU()),
+		processes:       newProcessLimiter(intFromEnv("MAX_PROCESSES", runtime.NumCPU())),
+		cache:           cacheFromEnv(),
+	}
+}
+
+// pathFromEnv returns the executable configured in the environment, or the
+// default when the variable is unset.
+func pathFromEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// durationFromEnv parses a Go duration (e.g. "30s") from the environment,
+// falling back to the default when the variable is unset or invalid.
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// intFromEnv parses a positive integer from the environment, falling back to
+// the default when the variable is unset or invalid.
+func intFromEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func (s *GoAnalyzerService) Analyze(ctx context.Context, req *models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
+	if err := validateOptions(req.Options); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	results := make([]models.FileResult, len(req.Files))
+	var pending []int
+
+	for i, file := range req.Files {
+		if strings.ToLower(filepath.Ext(file.Path)) != ".go" {
+			results[i] = models.FileResult{
+				Path:         file.Path,
+				Status:       models.StatusUnsupported,
+				Comment:      "Not a Go file",
+				LineComments: []models.LineComment{},
+			}
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	keys := s.cacheKeys(req, pending)
+	pending = s.cache.lookup(keys, req.Files, results, pending)
+	if len(pending) == 0 {
+		return &models.AnalyzeResponse{Files: results}, nil
+	}
+
+	// Every file of the request is written to the workspace, so packages build with all their files, go.mod and go.sum.
+	ws, err := newWorkspace(req.Files)
+	var modules []goModule
+	if err == nil {
+		ws.env = offlineEnv
+		modules, err = goModules(ws, req.Files, pending, s.goVersion)
+	}
+	if err != nil {
+		for _, i := range pending {
+			results[i] = models.FileResult{
+				Path:         req.Files[i].Path,
+				Status:       models.StatusToolError,
+				Comment:      fmt.Sprintf("Error: failed to create workspace: %v", err),
+				LineComments: []models.LineComment{},
+			}
+		}
+		if ws != nil {
+			ws.Close()
+		}
+		return &models.AnalyzeResponse{Files: results}, nil
+	}
+	defer ws.Close()
+
+	// Every tool runs once per module; the runs are independent.
+	runs := make([][]toolRun, len(modules))
+	for m := range runs {
+		runs[m] = make([]toolRun, len(goTools))
+	}
+	forEach(len(modules)*len(goTools), s.maxWorkers, func(r int) {
+		m, t := r/len(goTools), r%len(goTools)
+		runs[m][t] = s.runTool(ctx, ws, modules[m], goTools[t])
+	})
+
+	for m, module := range modules {
+		for j, result := range mergeRuns(module.paths, runs[m]) {
+			results[module.indices[j]] = result
+		}
+	}
+
+	for i := range results {
+		results[i] = applyOptions(results[i], req.Options)
+	}
+	s.cache.store(keys, results, pending)
+
+	return &models.AnalyzeResponse{Files: results}, nil
+}
+
+func timeoutResults(paths []string) []models.FileResult {
+	results := make([]models.FileResult, len(paths))
+	for i, path := range paths {
+		results[i] = models.FileResult{
+			Path:         path,
+			Status:       models.StatusTimeout,
+			Comment:      "Analysis timed out",
+			LineComments: []models.LineComment{},
+		}
+	}
+	return results
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"go_analyzer_service/internal/models"
+)
+
+const vetOutput = `{
+	"analyze": {
+		"printf": [
+			{
+				"posn": "%[1]s/main.go:6:2",
+				"end": "%[1]s/main.go:6:25",
+				"message": "fmt.Printf format %%d has arg \"x\" of wrong type string"
+			}
+		],
+		"unusedresult": {"error": "analysis skipped"}
+	}
+}
+{
+	"analyze/util": {
+		"copylocks": [
+			{
+				"posn": "%[1]s/util/util.go:4:14",
+				"message": "Lock passes lock by value: sync.Mutex"
+			}
+		]
+	}
+}
+`
+
+func TestParseVetOutput(t *testing.T) {
+	ws, err := newWorkspace([]models.FileInput{
+		{Path: "main.go", Content: ""},
+		{Path: "util/util.go", Content: ""},
+	})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer ws.Close()
+	m := goModule{dir: ws.root}
+
+	findings, err := parseVetOutput(ws, m, []byte(fmt.Sprintf(vetOutput, ws.root)))
+	if err != nil {
+		t.Fatalf("parseVetOutput() error = %v", err)
+	}
+	want := models.LineComment{Line: 6, Column: 2, EndLine: 6, EndColumn: 25, RuleID: "printf", Severity: models.SeverityWarning, Tool: "go vet", Comment: `fmt.Printf format %d has arg "x" of wrong type string`}
+	if got := findings["main.go"]; len(got) != 1 || got[0] != want {
+		t.Errorf("parseVetOutput() for main.go = %+v, want %+v", got, want)
+	}
+	if got := findings["util/util.go"]; len(got) != 1 || got[0].RuleID != "copylocks" || got[0].Line != 4 || got[0].EndLine != 0 {
+		t.Errorf("parseVetOutput() for util/util.go = %+v, want the copylocks finding on line 4", got)
+	}
+
+	compileFindings, other := compileErrors(ws, m, []byte("# analyze/util\nvet: util/util.go:7:9: undefined: y\n"), "go vet")
+	if other {
+		t.Error("compileErrors() reported other output for a package header and a positioned error")
+	}
+	if got := compileFindings["util/util.go"]; len(got) != 1 || got[0].RuleID != "compile" || got[0].Line != 7 || got[0].Column != 9 || got[0].Comment != "undefined: y" {
+		t.Errorf("compileErrors() = %+v, want the undefined error at 7:9", compileFindings)
+	}
+}
+
+func TestParseStaticcheckOutput(t *testing.T) {
+	ws, err := newWorkspace([]models.FileInput{{Path: "cmd/main.go", Content: ""}})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer ws.Close()
+	// The project root is cmd, the directory the file is in; the module is
+	// the whole tree.
+	m := goModule{dir: ws.tree}
+
+	output := fmt.Sprintf(`{"code":"SA4006","severity":"error","location":{"file":"%[1]s/cmd/main.go","line":5,"column":2},"end":{"file":"%[1]s/cmd/main.go","line":5,"column":3},"message":"this value of x is never used"}
+{"code":"ST1003","severity":"warning","location":{"file":"%[1]s/cmd/main.go","line":3,"column":6},"end":{"file":"","line":0,"column":0},"message":"should not use underscores in Go names"}
+{"code":"compile","severity":"error","location":{"file":"","line":0,"column":0},"end":{"file":"","line":0,"column":0},"message":"# analyze/cmd\ncmd/main.go:8:1: syntax error: unexpected }"}
+`, ws.tree)
+	findings, err := parseStaticcheckOutput(ws, m, []byte(output))
+	if err != nil {
+		t.Fatalf("parseStaticcheckOutput() error = %v", err)
+	}
+	got := findings["cmd/main.go"]
+	if len(got) != 2 {
+		t.Fatalf("parseStaticcheckOutput() = %+v, want 2 findings, compile errors are left to go vet", got)
+	}
+	want := models.LineComment{Line: 5, Column: 2, EndLine: 5, EndColumn: 3, RuleID: "SA4006", Severity: models.SeverityError, Tool: "staticcheck", Comment: "this value of x is never used"}
+	if got[0] != want {
+		t.Errorf("finding = %+v, want %+v", got[0], want)
+	}
+	if got[1].Severity != models.SeverityWarning || got[1].EndLine != 0 {
+		t.Errorf("finding = %+v, want a warning without an end position", got[1])
+	}
+
+	unpositioned := `{"code":"compile","severity":"error","location":{"file":"","line":0,"column":0},"end":{"file":"","line":0,"column":0},"message":"could not import fmt (unsupported version: 2)"}`
+	if _, err := parseStaticcheckOutput(ws, m, []byte(unpositioned)); err == nil {
+		t.Error("parseStaticcheckOutput() of a compile error without position succeeded, want an error")
+	}
+}
+
+// requireGo skips tests that run the go command when it is not installed.
+func requireGo(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"go", "gofmt"} {
+		if _, err := exec.LookPath(name); err != nil {
+			t.Skipf("%s not found: %v", name, err)
+		}
+	}
+}
+
+// writeStaticcheck writes a fake staticcheck script and returns its path.
+func writeStaticcheck(t *testing.T, content string) string {
+	t.Helper()
+	script := filepath.Join(t.TempDir(), "staticcheck")
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("failed to write fake staticcheck: %v", err)
+	}
+	return script
+}
+
+func TestGoAnalyzerService_Analyze(t *testing.T) {
+	requireGo(t)
+	service := NewGoAnalyzerService()
+	service.staticcheckPath = writeStaticcheck(t, `#!/bin/sh
+if [ "$1" = "-version" ]; then echo "staticcheck 2023.1.7 (v0.4.7)"; exit 0; fi
+echo '{"code":"U1000","severity":"warning","location":{"file":"'"$PWD"'/main.go","line":9,"column":6},"end":{"file":"","line":0,"column":0},"message":"func unused is unused"}'
+exit 1
+`)
+
+	resp, err := service.Analyze(context.Background(), &models.AnalyzeRequest{
+		Files: []models.FileInput{
+			{Path: "main.go", Content: "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Printf(\"%d\\n\", \"x\")\n}\n\nfunc unused() {}\n"},
+			{Path: "util/util.go", Content: "package util\n\nfunc Add(a int, b int) int {\n  return a + y\n}\n"},
+			{Path: "README.md", Content: "# App\n"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	got := resp.Files[0]
+	if got.Status != models.StatusIssues || len(got.LineComments) != 2 {
+		t.Fatalf("Analyze() result for main.go = %+v, want the printf and U1000 findings", got)
+	}
+	if got.LineComments[0].Tool != "go vet" || got.LineComments[0].RuleID != "printf" || got.LineComments[0].Line != 6 {
+		t.Errorf("first finding = %+v, want go vet's printf finding on line 6", got.LineComments[0])
+	}
+	if got.LineComments[1].Tool != "staticcheck" || got.LineComments[1].RuleID != "U1000" {
+		t.Errorf("second finding = %+v, want staticcheck's U1000", got.LineComments[1])
+	}
+
+	var rules []string
+	for _, lineComment := range resp.Files[1].LineComments {
+		rules = append(rules, lineComment.RuleID)
+	}
+	if len(rules) != 2 || rules[0] != "gofmt" || rules[1] != "compile" {
+		t.Errorf("Analyze() rules for util/util.go = %v, want [gofmt compile]", rules)
+	}
+	if resp.Files[2].Status != models.StatusUnsupported {
+		t.Errorf("Analyze() status of README.md = %q, want %q", resp.Files[2].Status, models.StatusUnsupported)
+	}
+}
+
+func TestGoAnalyzerService_AnalyzeToolError(t *testing.T) {
+	requireGo(t)
+	service := NewGoAnalyzerService()
+	service.staticcheckPath = writeStaticcheck(t, "#!/bin/sh\necho 'invalid check: XX1000' >&2\nexit 2\n")
+
+	resp, err := service.Analyze(context.Background(), &models.AnalyzeRequest{
+		Files: []models.FileInput{{Path: "main.go", Content: "package main\n\nfunc main() {}\n"}},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if got := resp.Files[0]; got.Status != models.StatusToolError || got.Stderr == "" {
+		t.Errorf("Analyze() result = %+v, want a tool error with stderr", got)
+	}
+}
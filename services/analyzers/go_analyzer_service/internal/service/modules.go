@@ -0,0 +1,125 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"go_analyzer_service/internal/models"
+)
+
+// generatedModulePath is the module path of the go.mod written for files
+// that do not belong to a module of the project.
+const generatedModulePath = "analyze"
+
+// goModule is a module of the workspace and the request files that belong
+// to it. The tools run once per module, from its directory.
+type goModule struct {
+	dir     string   // module root, an absolute workspace path
+	indices []int    // indices of the request files in the module
+	paths   []string // original paths of those files, in the same order
+}
+
+// goModules groups the files by the module they belong to: the nearest
+// directory up to the project root that has a go.mod. When some files have
+// none, a go.mod for the given Go language version is written at the project
+// root, so they are analyzed as one module like "go mod init" would set it
+// up.
+func goModules(ws *workspace, files []models.FileInput, pending []int, goVersion string) ([]goModule, error) {
+	byDir := make(map[string]*goModule)
+	var dirs []string
+	generate := false
+
+	for _, i := range pending {
+		dir := moduleDir(ws, filepath.Dir(filepath.Join(ws.root, ws.relPath(files[i].Path))))
+		if dir == "" {
+			dir = ws.root
+			generate = true
+		}
+		m, ok := byDir[dir]
+		if !ok {
+			m = &goModule{dir: dir}
+			byDir[dir] = m
+			dirs = append(dirs, dir)
+		}
+		m.indices = append(m.indices, i)
+		m.paths = append(m.paths, files[i].Path)
+	}
+
+	if generate {
+		content := fmt.Sprintf("module %s\n\ngo %s\n", generatedModulePath, goVersion)
+		if err := os.WriteFile(filepath.Join(ws.root, "go.mod"), []byte(content), 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(dirs)
+	modules := make([]goModule, len(dirs))
+	for i, dir := range dirs {
+		modules[i] = *byDir[dir]
+	}
+	return modules, nil
+}
+
+// moduleDir returns the nearest directory from dir up to the project root
+// that has a go.mod, or "" when there is none. Every file of the workspace
+// is below the project root.
+func moduleDir(ws *workspace, dir string) string {
+	for {
+		if info, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil && !info.IsDir() {
+			return dir
+		}
+		if dir == ws.root {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// files returns the paths of the module's files relative to its root, as
+// they are passed to gofmt.
+func (m goModule) files(ws *workspace) []string {
+	files := make([]string, len(m.paths))
+	for i, p := range m.paths {
+		rel, err := filepath.Rel(m.dir, filepath.Join(ws.root, ws.relPath(p)))
+		if err != nil {
+			rel = p
+		}
+		files[i] = rel
+	}
+	return files
+}
+
+// packages returns the package patterns of the directories holding the
+// module's files, e.g. "." and "./internal/service", as they are passed to
+// go vet and staticcheck.
+func (m goModule) packages(ws *workspace) []string {
+	seen := make(map[string]bool)
+	var packages []string
+	for _, file := range m.files(ws) {
+		pkg := "./" + filepath.ToSlash(filepath.Dir(file))
+		if pkg == "./." {
+			pkg = "."
+		}
+		if !seen[pkg] {
+			seen[pkg] = true
+			packages = append(packages, pkg)
+		}
+	}
+	sort.Strings(packages)
+	return packages
+}
+
+// originalPath maps a path reported by a tool, either absolute or relative
+// to the module root, back to the FileInput.Path it was created from.
+func (m goModule) originalPath(ws *workspace, toolPath string) (string, bool) {
+	if !filepath.IsAbs(toolPath) {
+		toolPath = filepath.Join(m.dir, toolPath)
+	}
+	return ws.originalPath(toolPath)
+}
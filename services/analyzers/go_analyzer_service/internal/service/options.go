@@ -0,0 +1,93 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go_analyzer_service/internal/models"
+)
+
+// ErrInvalidOptions is returned by Analyze when the request options cannot
+// be applied; the controller reports it as a bad request.
+var ErrInvalidOptions = errors.New("invalid options")
+
+// severityRank orders severities for the min_severity option.
+var severityRank = map[string]int{
+	models.SeverityInfo:    0,
+	models.SeverityWarning: 1,
+	models.SeverityError:   2,
+}
+
+func validateOptions(opts *models.AnalyzeOptions) error {
+	if opts == nil {
+		return nil
+	}
+	if _, ok := severityRank[opts.MinSeverity]; opts.MinSeverity != "" && !ok {
+		return fmt.Errorf("%w: min_severity must be one of error, warning, info", ErrInvalidOptions)
+	}
+	if opts.MaxFindings < 0 {
+		return fmt.Errorf("%w: max_findings must not be negative", ErrInvalidOptions)
+	}
+	for _, rules := range [][]string{opts.EnableRules, opts.DisableRules} {
+		for _, rule := range rules {
+			if strings.TrimSpace(rule) == "" {
+				return fmt.Errorf("%w: rule IDs must not be empty", ErrInvalidOptions)
+			}
+		}
+	}
+	return nil
+}
+
+// applyOptions filters the findings of a result by the request options:
+// the enable_rules allowlist, disable_rules, min_severity and max_findings.
+// Rules are go vet analyzer names (e.g. printf), staticcheck check IDs (e.g.
+// SA4006), gofmt and compile.
+func applyOptions(result models.FileResult, opts *models.AnalyzeOptions) models.FileResult {
+	if opts == nil || len(result.LineComments) == 0 {
+		return result
+	}
+
+	lineComments := make([]models.LineComment, 0, len(result.LineComments))
+	for _, lineComment := range result.LineComments {
+		if opts.MaxFindings > 0 && len(lineComments) == opts.MaxFindings {
+			break
+		}
+		if keepFinding(lineComment, opts) {
+			lineComments = append(lineComments, lineComment)
+		}
+	}
+
+	result.LineComments = lineComments
+	if len(lineComments) == 0 {
+		result.Status = models.StatusOK
+		result.Comment = "OK"
+	}
+	return result
+}
+
+func keepFinding(lineComment models.LineComment, opts *models.AnalyzeOptions) bool {
+	if len(opts.EnableRules) > 0 && !matchesAnyRule(lineComment.RuleID, opts.EnableRules) {
+		return false
+	}
+	if matchesAnyRule(lineComment.RuleID, opts.DisableRules) {
+		return false
+	}
+	if opts.MinSeverity != "" && severityRank[lineComment.Severity] < severityRank[opts.MinSeverity] {
+		return false
+	}
+	return true
+}
+
+func matchesAnyRule(ruleID string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matchesRule(ruleID, strings.TrimSpace(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesRule(ruleID, pattern string) bool {
+	return ruleID == pattern
+}
@@ -0,0 +1,359 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_analyzer_service/internal/models"
+)
+
+// goTools are the tools run over every module, in the order their findings
+// are listed on the same position.
+var goTools = []string{"gofmt", "go vet", "staticcheck"}
+
+// toolRun is the outcome of one tool over one module: findings by original
+// path, or the results that replace those of the files when the tool timed
+// out or failed.
+type toolRun struct {
+	findings map[string][]models.LineComment
+	failed   []models.FileResult
+}
+
+// runTool runs one of goTools over the files of a module.
+func (s *GoAnalyzerService) runTool(ctx context.Context, ws *workspace, m goModule, tool string) toolRun {
+	// Waiting for a free process slot does not count against the deadline.
+	if err := s.processes.acquire(ctx); err != nil {
+		return toolRun{failed: timeoutResults(m.paths)}
+	}
+	defer s.processes.release()
+
+	ctx, cancel := context.WithTimeout(ctx, s.fileTimeout*time.Duration(len(m.paths)))
+	defer cancel()
+
+	var findings map[string][]models.LineComment
+	var stderr []byte
+	var err error
+	switch tool {
+	case "gofmt":
+		findings, stderr, err = s.runGofmt(ctx, ws, m)
+	case "go vet":
+		findings, stderr, err = s.runVet(ctx, ws, m)
+	case "staticcheck":
+		findings, stderr, err = s.runStaticcheck(ctx, ws, m)
+	}
+
+	if ctx.Err() != nil {
+		return toolRun{failed: timeoutResults(m.paths)}
+	}
+	if err != nil {
+		return toolRun{failed: toolErrorResults(m.paths, tool, err, stderr)}
+	}
+	return toolRun{findings: findings}
+}
+
+// diagnosticLine matches a positioned error as printed by gofmt, the
+// compiler and go list, e.g. "vet: internal/a.go:4:9: undefined: y".
+var diagnosticLine = regexp.MustCompile(`^(?:vet: )?(.+?\.go):(\d+)(?::(\d+))?: (.*)$`)
+
+// compileErrors returns the positioned errors in the output of a tool that
+// belong to files of the module, as "compile" findings, and whether the
+// output holds anything else.
+func compileErrors(ws *workspace, m goModule, output []byte, tool string) (map[string][]models.LineComment, bool) {
+	findings := make(map[string][]models.LineComment)
+	other := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		// Package headers and continuation lines (e.g. "\tgo get ...").
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, "\t") {
+			continue
+		}
+		match := diagnosticLine.FindStringSubmatch(text)
+		if match == nil {
+			other = true
+			continue
+		}
+		path, ok := m.originalPath(ws, match[1])
+		if !ok {
+			other = true
+			continue
+		}
+		line, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+		findings[path] = append(findings[path], models.LineComment{
+			Line:     line,
+			Column:   column,
+			RuleID:   "compile",
+			Severity: models.SeverityError,
+			Tool:     tool,
+			Comment:  match[4],
+		})
+	}
+	return findings, other
+}
+
+// runGofmt lists the files gofmt would change. Files that do not parse are
+// left to go vet, which reports the same syntax errors.
+func (s *GoAnalyzerService) runGofmt(ctx context.Context, ws *workspace, m goModule) (map[string][]models.LineComment, []byte, error) {
+	args := append([]string{"-l"}, m.files(ws)...)
+	output, stderr, runErr := ws.runIn(ctx, m.dir, s.gofmtPath, args...)
+
+	// gofmt exits with 2 when a file does not parse.
+	if err := exitError(runErr, 2); err != nil {
+		return nil, stderr, err
+	}
+	if syntaxErrors, other := compileErrors(ws, m, stderr, "gofmt"); runErr != nil && (other || len(syntaxErrors) == 0) {
+		return nil, stderr, runErr
+	}
+
+	findings := make(map[string][]models.LineComment)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		path, ok := m.originalPath(ws, strings.TrimSpace(scanner.Text()))
+		if !ok {
+			continue
+		}
+		findings[path] = append(findings[path], models.LineComment{
+			Line:     1,
+			RuleID:   "gofmt",
+			Severity: models.SeverityWarning,
+			Tool:     "gofmt",
+			Comment:  "File is not formatted with gofmt",
+		})
+	}
+	return findings, stderr, nil
+}
+
+// vetDiagnostic is a diagnostic of go vet -json, which prints one object
+// per package mapping analyzer names to their diagnostics.
+type vetDiagnostic struct {
+	Posn    string `json:"posn"`
+	End     string `json:"end"`
+	Message string `json:"message"`
+}
+
+// runVet runs go vet over the packages of the module's files. Packages that
+// do not build are reported by go vet as positioned errors, which become
+// "compile" findings; the run only failed when it exits non-zero without
+// any of them, e.g. when a dependency is not available offline.
+func (s *GoAnalyzerService) runVet(ctx context.Context, ws *workspace, m goModule) (map[string][]models.LineComment, []byte, error) {
+	args := append([]string{"vet", "-json"}, m.packages(ws)...)
+	output, stderr, runErr := ws.runIn(ctx, m.dir, s.goPath, args...)
+
+	findings, err := parseVetOutput(ws, m, output)
+	if err != nil {
+		return nil, stderr, err
+	}
+	compileFindings, _ := compileErrors(ws, m, stderr, "go vet")
+	if runErr != nil && len(compileFindings) == 0 {
+		return nil, stderr, runErr
+	}
+	for path, lineComments := range compileFindings {
+		findings[path] = append(findings[path], lineComments...)
+	}
+	return findings, stderr, nil
+}
+
+// parseVetOutput groups the diagnostics of go vet -json by original path,
+// with the analyzer that reported them as rule.
+func parseVetOutput(ws *workspace, m goModule, output []byte) (map[string][]models.LineComment, error) {
+	findings := make(map[string][]models.LineComment)
+
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	for {
+		var packages map[string]map[string]json.RawMessage
+		err := decoder.Decode(&packages)
+		if errors.Is(err, io.EOF) {
+			return findings, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid go vet output: %w", err)
+		}
+
+		for _, analyzers := range packages {
+			for analyzer, raw := range analyzers {
+				// An analyzer that failed reports {"error": "..."} instead.
+				var diagnostics []vetDiagnostic
+				if json.Unmarshal(raw, &diagnostics) != nil {
+					continue
+				}
+				for _, diagnostic := range diagnostics {
+					file, line, column := splitPosition(diagnostic.Posn)
+					path, ok := m.originalPath(ws, file)
+					if !ok {
+						continue
+					}
+					lineComment := models.LineComment{
+						Line:     line,
+						Column:   column,
+						RuleID:   analyzer,
+						Severity: models.SeverityWarning,
+						Tool:     "go vet",
+						Comment:  diagnostic.Message,
+					}
+					if endFile, endLine, endColumn := splitPosition(diagnostic.End); endFile == file {
+						lineComment.EndLine, lineComment.EndColumn = endLine, endColumn
+					}
+					findings[path] = append(findings[path], lineComment)
+				}
+			}
+		}
+	}
+}
+
+// positionSuffix matches the ":line:column" or ":line" end of a position.
+var positionSuffix = regexp.MustCompile(`:(\d+)(?::(\d+))?$`)
+
+// splitPosition splits a go/token position such as "/x/a.go:7:14" into file,
+// line and column.
+func splitPosition(position string) (string, int, int) {
+	match := positionSuffix.FindStringSubmatchIndex(position)
+	if match == nil {
+		return position, 0, 0
+	}
+	line, _ := strconv.Atoi(position[match[2]:match[3]])
+	column := 0
+	if match[4] >= 0 {
+		column, _ = strconv.Atoi(position[match[4]:match[5]])
+	}
+	return position[:match[0]], line, column
+}
+
+// staticcheckProblem is a line of staticcheck -f json output.
+type staticcheckProblem struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Location struct {
+		File   string `json:"file"`
+		Line   int    `json:"line"`
+		Column int    `json:"column"`
+	} `json:"location"`
+	End struct {
+		File   string `json:"file"`
+		Line   int    `json:"line"`
+		Column int    `json:"column"`
+	} `json:"end"`
+	Message string `json:"message"`
+}
+
+// runStaticcheck runs staticcheck over the packages of the module's files,
+// with the project's staticcheck.conf files.
+func (s *GoAnalyzerService) runStaticcheck(ctx context.Context, ws *workspace, m goModule) (map[string][]models.LineComment, []byte, error) {
+	args := append([]string{"-f", "json"}, m.packages(ws)...)
+	output, stderr, runErr := ws.runIn(ctx, m.dir, s.staticcheckPath, args...)
+
+	// staticcheck exits with 1 when it reports problems.
+	if err := exitError(runErr, 1); err != nil {
+		return nil, stderr, err
+	}
+	findings, err := parseStaticcheckOutput(ws, m, output)
+	if err != nil {
+		return nil, append(stderr, output...), err
+	}
+	return findings, stderr, nil
+}
+
+// parseStaticcheckOutput groups the problems staticcheck reported by
+// original path, with the check ID as rule. Packages that do not build are
+// reported without a location; their errors are left to go vet, and
+// staticcheck failed when such a problem names no file of the module, e.g.
+// when it cannot read the toolchain's export data.
+func parseStaticcheckOutput(ws *workspace, m goModule, output []byte) (map[string][]models.LineComment, error) {
+	findings := make(map[string][]models.LineComment)
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var problem staticcheckProblem
+		if err := json.Unmarshal([]byte(text), &problem); err != nil {
+			return nil, fmt.Errorf("invalid staticcheck output: %w", err)
+		}
+
+		if problem.Code == "compile" && problem.Location.File == "" {
+			if compileFindings, _ := compileErrors(ws, m, []byte(problem.Message), "staticcheck"); len(compileFindings) == 0 {
+				return nil, errors.New(problem.Message)
+			}
+			continue
+		}
+
+		path, ok := m.originalPath(ws, problem.Location.File)
+		if !ok {
+			continue
+		}
+		lineComment := models.LineComment{
+			Line:     problem.Location.Line,
+			Column:   problem.Location.Column,
+			RuleID:   problem.Code,
+			Severity: staticcheckSeverity(problem.Severity),
+			Tool:     "staticcheck",
+			Comment:  problem.Message,
+		}
+		if problem.End.File == problem.Location.File && problem.End.Line > 0 {
+			lineComment.EndLine, lineComment.EndColumn = problem.End.Line, problem.End.Column
+		}
+		findings[path] = append(findings[path], lineComment)
+	}
+	return findings, scanner.Err()
+}
+
+// staticcheckSeverity maps staticcheck severities onto error/warning/info.
+func staticcheckSeverity(severity string) string {
+	switch severity {
+	case "error":
+		return models.SeverityError
+	case "warning":
+		return models.SeverityWarning
+	default:
+		return models.SeverityInfo
+	}
+}
+
+// mergeRuns builds one result per path from the runs of every tool over a
+// module. A file fails with the first tool that failed on it; otherwise it
+// gets the findings of all tools, ordered by position.
+func mergeRuns(paths []string, runs []toolRun) []models.FileResult {
+	failed := make(map[string]models.FileResult)
+	findings := make(map[string][]models.LineComment)
+	for _, run := range runs {
+		for _, result := range run.failed {
+			if _, ok := failed[result.Path]; !ok {
+				failed[result.Path] = result
+			}
+		}
+		for path, lineComments := range run.findings {
+			findings[path] = append(findings[path], lineComments...)
+		}
+	}
+
+	for _, lineComments := range findings {
+		sort.SliceStable(lineComments, func(a, b int) bool {
+			if lineComments[a].Line != lineComments[b].Line {
+				return lineComments[a].Line < lineComments[b].Line
+			}
+			return lineComments[a].Column < lineComments[b].Column
+		})
+	}
+
+	results := fileResults(paths, findings)
+	for i, path := range paths {
+		if result, ok := failed[path]; ok {
+			results[i] = result
+		}
+	}
+	return results
+}
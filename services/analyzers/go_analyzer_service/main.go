@@ -0,0 +1,26 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"go_analyzer_service/internal/controller"
+	"go_analyzer_service/internal/service"
+)
+
+func main() {
+	analyzerService := service.NewGoAnalyzerService()
+	analyzerController := controller.NewAnalyzerController(analyzerService)
+	router := controller.NewRouter(analyzerController)
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8089"
+	}
+
+	log.Printf("Starting go_analyzer_service on port %s", port)
+	if err := http.ListenAndServe(":"+port, router); err != nil {
+		log.Fatalf("Server failed: %v", err)
+	}
+}
@@ -2,9 +2,12 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
 
 	"java_analyzer_service/internal/models"
+	"java_analyzer_service/internal/sarif"
 	"java_analyzer_service/internal/service"
 )
 
@@ -28,15 +31,41 @@ func (c *AnalyzerController) Analyze(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := c.service.Analyze(&req)
+	resp, err := c.service.Analyze(r.Context(), &req)
 	if err != nil {
-		respondError(w, err.Error(), http.StatusInternalServerError)
+		if errors.Is(err, service.ErrInvalidOptions) {
+			respondError(w, err.Error(), http.StatusBadRequest)
+		} else {
+			respondError(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if wantsSARIF(r) {
+		respondSARIF(w, sarif.FromResponse(resp, "checkstyle"), http.StatusOK)
 		return
 	}
 
 	respondJSON(w, resp, http.StatusOK)
 }
 
+// CacheStats reports the hit/miss counters and size of the result cache.
+func (c *AnalyzerController) CacheStats(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, c.service.CacheStats(), http.StatusOK)
+}
+
+// wantsSARIF reports whether the client asked for a SARIF log instead of the
+// unified analysis JSON.
+func wantsSARIF(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), sarif.MediaType)
+}
+
+func respondSARIF(w http.ResponseWriter, log *sarif.Log, statusCode int) {
+	w.Header().Set("Content-Type", sarif.MediaType)
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(log)
+}
+
 func respondJSON(w http.ResponseWriter, data interface{}, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"java_analyzer_service/internal/models"
+)
+
+// checkstyleConfigFiles are the project checkstyle configurations we look
+// for, relative to the project root, in order of preference.
+var checkstyleConfigFiles = []string{
+	"checkstyle.xml",
+	".checkstyle.xml",
+	"config/checkstyle/checkstyle.xml",
+	"checkstyle/checkstyle.xml",
+}
+
+// bundledConfigs are the configurations bundled in the checkstyle jar, by
+// the name requests and CHECKSTYLE_CONFIG select them with.
+var bundledConfigs = map[string]string{
+	"sun":    "/sun_checks.xml",
+	"google": "/google_checks.xml",
+}
+
+// defaultBundledConfig is used when the project has no configuration of its
+// own and CHECKSTYLE_CONFIG does not choose another bundled one.
+const defaultBundledConfig = "sun"
+
+// bundledConfigFromEnv reads the name of a bundled configuration from the
+// environment, falling back to the default when it is unset or unknown.
+func bundledConfigFromEnv(key string) string {
+	if name := strings.ToLower(os.Getenv(key)); bundledConfigs[name] != "" {
+		return name
+	}
+	return defaultBundledConfig
+}
+
+// configArgs returns the checkstyle arguments selecting the configuration:
+// the bundled one the request asks for, else the project's own checkstyle
+// XML when there is one, else the service's bundled default.
+func (s *JavaAnalyzerService) configArgs(ws *workspace, opts *models.AnalyzeOptions) []string {
+	if opts != nil && opts.CheckstyleConfig != "" {
+		return []string{"-c", bundledConfigs[strings.ToLower(opts.CheckstyleConfig)]}
+	}
+	name := ws.findConfig(checkstyleConfigFiles...)
+	if name == "" {
+		return []string{"-c", bundledConfigs[s.bundledConfig]}
+	}
+
+	args := []string{"-c", name}
+
+	// Gradle and Maven define ${config_loc} as the directory of the config,
+	// and project configs use it to reference their suppression files.
+	configLoc := filepath.ToSlash(filepath.Join(ws.root, filepath.FromSlash(path.Dir(name))))
+	properties := fmt.Sprintf("config_loc=%s\n", configLoc)
+	if propertiesPath, err := ws.writeGenerated("checkstyle.properties", []byte(properties)); err == nil {
+		args = append(args, "-p", propertiesPath)
+	}
+
+	return args
+}
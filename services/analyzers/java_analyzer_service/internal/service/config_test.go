@@ -0,0 +1,62 @@
+package service
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"java_analyzer_service/internal/models"
+)
+
+func TestJavaAnalyzerService_ConfigArgs(t *testing.T) {
+	service := NewJavaAnalyzerService()
+	service.bundledConfig = "google"
+
+	plain, err := newWorkspace([]models.FileInput{{Path: "src/Main.java"}})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer plain.Close()
+
+	configured, err := newWorkspace([]models.FileInput{
+		{Path: "app/src/Main.java"},
+		{Path: "app/config/checkstyle/checkstyle.xml", Content: "<module name=\"Checker\"/>\n"},
+	})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer configured.Close()
+
+	tests := []struct {
+		name string
+		ws   *workspace
+		opts *models.AnalyzeOptions
+		want []string
+	}{
+		{name: "service default", ws: plain, want: []string{"-c", "/google_checks.xml"}},
+		{name: "request", ws: plain, opts: &models.AnalyzeOptions{CheckstyleConfig: "Sun"}, want: []string{"-c", "/sun_checks.xml"}},
+		{name: "request over project", ws: configured, opts: &models.AnalyzeOptions{CheckstyleConfig: "google"}, want: []string{"-c", "/google_checks.xml"}},
+		{name: "request without config", ws: plain, opts: &models.AnalyzeOptions{MinSeverity: models.SeverityError}, want: []string{"-c", "/google_checks.xml"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := service.configArgs(tt.ws, tt.opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("configArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// The project's own config is used with ${config_loc} set to its directory.
+	got := service.configArgs(configured, nil)
+	if len(got) != 4 || got[0] != "-c" || got[1] != "config/checkstyle/checkstyle.xml" || got[2] != "-p" {
+		t.Fatalf("configArgs() = %v, want the project config and a properties file", got)
+	}
+	properties, err := os.ReadFile(got[3])
+	if err != nil {
+		t.Fatalf("failed to read properties file: %v", err)
+	}
+	if want := "config_loc=" + configured.root + "/config/checkstyle\n"; string(properties) != want {
+		t.Errorf("properties = %q, want %q", properties, want)
+	}
+}
@@ -1,18 +1,44 @@
 package service
 
 import (
+	"bytes"
+	"context"
+	"encoding/xml"
 	"fmt"
 	"os"
-	"os/exec"
-	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"java_analyzer_service/internal/models"
 )
 
+const (
+	defaultFileTimeout    = 30 * time.Second
+	defaultRequestTimeout = 2 * time.Minute
+
+	// waitDelay bounds how long we wait for output pipes after the tool
+	// process has been killed on cancellation.
+	waitDelay = 2 * time.Second
+)
+
 type JavaAnalyzerService struct {
 	checkstylePath string
+	bundledConfig  string
+	pmdPath        string
+	pmdRulesets    string
+	pmd            bool
+	fileTimeout    time.Duration
+	requestTimeout time.Duration
+	maxWorkers     int
+	processes      processLimiter
+	cache          *resultCache
+
+	versionOnce sync.Once
+	version     string
+	versionOK   bool
 }
 
 func NewJavaAnalyzerService() *JavaAnalyzerService {
@@ -20,91 +46,244 @@ func NewJavaAnalyzerService() *JavaAnalyzerService {
 	if checkstylePath == "" {
 		checkstylePath = "checkstyle"
 	}
+	pmdPath := os.Getenv("PMD_PATH")
+	if pmdPath == "" {
+		pmdPath = "pmd"
+	}
 
 	return &JavaAnalyzerService{
 		checkstylePath: checkstylePath,
+		bundledConfig:  bundledConfigFromEnv("CHECKSTYLE_CONFIG"),
+		pmdPath:        pmdPath,
+		pmdRulesets:    pmdRulesetsFromEnv("PMD_RULESETS"),
+		pmd:            boolFromEnv("PMD_ENABLED", true),
+		fileTimeout:    durationFromEnv("ANALYZER_FILE_TIMEOUT", defaultFileTimeout),
+		requestTimeout: durationFromEnv("ANALYZER_REQUEST_TIMEOUT", defaultRequestTimeout),
+		maxWorkers:     intFromEnv("MAX_WORKERS", runtime.NumCPU()),
+		processes:      newProcessLimiter(intFromEnv("MAX_PROCESSES", runtime.NumCPU())),
+		cache:          cacheFromEnv(),
+	}
+}
+
+// durationFromEnv parses a Go duration (e.g. "30s") from the environment,
+// falling back to the default when the variable is unset or invalid.
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// intFromEnv parses a positive integer from the environment, falling back to
+// the default when the variable is unset or invalid.
+func intFromEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// boolFromEnv parses a boolean ("true", "1", ...) from the environment,
+// falling back to the default when the variable is unset or invalid.
+func boolFromEnv(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
 	}
+	return value
 }
 
-func (s *JavaAnalyzerService) Analyze(req *models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
-	var results []models.FileResult
+func (s *JavaAnalyzerService) Analyze(ctx context.Context, req *models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
+	if err := validateOptions(req.Options); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
 
-	for _, file := range req.Files {
+	results := make([]models.FileResult, len(req.Files))
+	var pending []int
+
+	for i, file := range req.Files {
 		if !strings.HasSuffix(strings.ToLower(file.Path), ".java") {
-			results = append(results, models.FileResult{
+			results[i] = models.FileResult{
 				Path:         file.Path,
+				Status:       models.StatusUnsupported,
 				Comment:      "Not a Java file",
 				LineComments: []models.LineComment{},
-			})
+			}
 			continue
 		}
+		pending = append(pending, i)
+	}
+
+	keys := s.cacheKeys(req, pending)
+	pending = s.cache.lookup(keys, req.Files, results, pending)
+	if len(pending) == 0 {
+		return &models.AnalyzeResponse{Files: results}, nil
+	}
 
-		result := s.analyzeFile(file.Path, file.Content)
-		results = append(results, result)
+	// Every file of the request is written to the workspace, including resources, so the source tree matches the project layout.
+	ws, err := newWorkspace(req.Files)
+	if err != nil {
+		for _, i := range pending {
+			results[i] = models.FileResult{
+				Path:         req.Files[i].Path,
+				Status:       models.StatusToolError,
+				Comment:      fmt.Sprintf("Error: failed to create workspace: %v", err),
+				LineComments: []models.LineComment{},
+			}
+		}
+		return &models.AnalyzeResponse{Files: results}, nil
 	}
+	defer ws.Close()
+
+	// PMD runs once over all files while checkstyle works through its batches.
+	pmdDone := make(chan pmdResult, 1)
+	go func() {
+		pmdDone <- s.pmdFiles(ctx, ws, req.Files, pending)
+	}()
+
+	config := s.configArgs(ws, req.Options)
+	groups := batches(pending, s.maxWorkers)
+	forEach(len(groups), s.maxWorkers, func(g int) {
+		paths := make([]string, len(groups[g]))
+		for j, i := range groups[g] {
+			paths[j] = req.Files[i].Path
+		}
+		for j, result := range s.analyzeBatch(ctx, ws, config, paths) {
+			results[groups[g][j]] = result
+		}
+	})
+	(<-pmdDone).apply(results, pending)
+
+	for i := range results {
+		results[i] = applyOptions(results[i], req.Options)
+	}
+	s.cache.store(keys, results, pending)
 
 	return &models.AnalyzeResponse{Files: results}, nil
 }
 
-func (s *JavaAnalyzerService) analyzeFile(path, content string) models.FileResult {
-	tmpDir := os.TempDir()
-	tmpFile := filepath.Join(tmpDir, fmt.Sprintf("analyze_%d_%s", time.Now().UnixNano(), filepath.Base(path)))
-	defer os.Remove(tmpFile)
+// analyzeBatch runs checkstyle once over the given files inside the workspace,
+// with the project configuration arguments, and returns one result per path,
+// in the same order.
+func (s *JavaAnalyzerService) analyzeBatch(ctx context.Context, ws *workspace, config []string, paths []string) []models.FileResult {
+	// Waiting for a free process slot does not count against the deadline.
+	if err := s.processes.acquire(ctx); err != nil {
+		return timeoutResults(paths)
+	}
+	defer s.processes.release()
 
-	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
-		return models.FileResult{
-			Path:         path,
-			Comment:      fmt.Sprintf("Error: failed to create temp file: %v", err),
-			LineComments: []models.LineComment{},
-		}
+	ctx, cancel := context.WithTimeout(ctx, s.fileTimeout*time.Duration(len(paths)))
+	defer cancel()
+
+	args := append([]string{"-f", "xml"}, config...)
+	for _, path := range paths {
+		args = append(args, ws.relPath(path))
 	}
+	output, stderr, err := ws.run(ctx, s.checkstylePath, args...)
 
-	cmd := exec.Command(s.checkstylePath, "-f", "plain", tmpFile)
-	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return timeoutResults(paths)
+	}
 
-	if err != nil && len(output) == 0 {
-		return models.FileResult{
-			Path:         path,
-			Comment:      "OK",
-			LineComments: []models.LineComment{},
+	// checkstyle exits with the number of errors it found, so the exit code
+	// does not tell a failure apart; a completed audit always writes the
+	// whole report.
+	findings, parseErr := s.parseOutput(ws, output)
+	if parseErr != nil {
+		if err == nil {
+			err = parseErr
 		}
+		return toolErrorResults(paths, "checkstyle", err, stderr)
 	}
 
-	return s.parseOutput(path, string(output))
+	return fileResults(paths, findings)
 }
 
-func (s *JavaAnalyzerService) parseOutput(path, output string) models.FileResult {
-	lines := strings.Split(strings.TrimSpace(output), "\n")
-	var lineComments []models.LineComment
-	comment := "OK"
+// checkstyleReport is the report of "checkstyle -f xml".
+type checkstyleReport struct {
+	Files []struct {
+		Name   string `xml:"name,attr"`
+		Errors []struct {
+			Line     int    `xml:"line,attr"`
+			Column   int    `xml:"column,attr"`
+			Severity string `xml:"severity,attr"`
+			Message  string `xml:"message,attr"`
+			Source   string `xml:"source,attr"`
+		} `xml:"error"`
+	} `xml:"file"`
+}
+
+// parseOutput groups the findings of checkstyle's XML report by the original
+// path of the file they were reported for. It fails when the report is not
+// complete XML, as when checkstyle aborts the audit.
+func (s *JavaAnalyzerService) parseOutput(ws *workspace, output []byte) (map[string][]models.LineComment, error) {
+	var report checkstyleReport
+	// Decode reads the <checkstyle> element only, so anything checkstyle
+	// prints after the report does not matter.
+	if err := xml.NewDecoder(bytes.NewReader(output)).Decode(&report); err != nil {
+		return nil, fmt.Errorf("invalid checkstyle report: %w", err)
+	}
 
-	for _, line := range lines {
-		if line == "" {
+	findings := make(map[string][]models.LineComment)
+	for _, file := range report.Files {
+		path, ok := ws.originalPath(file.Name)
+		if !ok {
 			continue
 		}
+		for _, e := range file.Errors {
+			findings[path] = append(findings[path], models.LineComment{
+				Line:     e.Line,
+				Column:   e.Column,
+				RuleID:   checkName(e.Source),
+				Severity: checkstyleSeverity(e.Severity),
+				Tool:     "checkstyle",
+				Comment:  e.Message,
+			})
+		}
+	}
 
-		// Parse checkstyle output format
-		parts := strings.Split(line, ":")
-		if len(parts) >= 3 {
-			var lineNum int
-			fmt.Sscanf(parts[1], "%d", &lineNum)
-			msg := strings.Join(parts[2:], ":")
+	return findings, nil
+}
 
-			lineComments = append(lineComments, models.LineComment{
-				Line:    lineNum,
-				Comment: strings.TrimSpace(msg),
-			})
+// checkName returns the name a check is configured and suppressed by, as
+// the plain format prints it: the module id when the configuration gives
+// one, otherwise the check class without package and "Check" suffix, e.g.
+// "LineLength" for com.puppycrawl.tools.checkstyle.checks.sizes.LineLengthCheck.
+func checkName(source string) string {
+	name := source[strings.LastIndex(source, ".")+1:]
+	if trimmed := strings.TrimSuffix(name, "Check"); trimmed != "" {
+		return trimmed
+	}
+	return name
+}
 
-			if comment == "OK" {
-				comment = "Issues found"
-			}
-		}
+// checkstyleSeverity maps the severities of checkstyle's XML report.
+func checkstyleSeverity(level string) string {
+	switch level {
+	case "error":
+		return models.SeverityError
+	case "warning":
+		return models.SeverityWarning
+	default:
+		return models.SeverityInfo
 	}
+}
 
-	return models.FileResult{
-		Path:         path,
-		Comment:      comment,
-		LineComments: lineComments,
+func timeoutResults(paths []string) []models.FileResult {
+	results := make([]models.FileResult, len(paths))
+	for i, path := range paths {
+		results[i] = models.FileResult{
+			Path:         path,
+			Status:       models.StatusTimeout,
+			Comment:      "Analysis timed out",
+			LineComments: []models.LineComment{},
+		}
 	}
+	return results
 }
 
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"java_analyzer_service/internal/models"
+)
+
+// checkstyle runs from the project root, src, the directory the files share,
+// and reports absolute paths.
+const checkstyleOutput = `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="10.12.5">
+<file name="%[1]s/main/java/com/example/Main.java">
+<error line="3" column="1" severity="warning" message="Missing a Javadoc comment." source="com.puppycrawl.tools.checkstyle.checks.javadoc.MissingJavadocTypeCheck"/>
+<error line="9" severity="error" message="Line is longer than 80 characters (found 95)." source="com.puppycrawl.tools.checkstyle.checks.sizes.LineLengthCheck"/>
+</file>
+<file name="%[1]s/main/java/com/example/Util.java">
+<error line="5" column="12" severity="info" message="&apos;42&apos; is a magic number." source="magicNumberCustomId"/>
+</file>
+<file name="%[1]s/main/java/com/example/Clean.java">
+</file>
+<file name="/elsewhere/Other.java">
+<error line="1" severity="error" message="x" source="com.puppycrawl.tools.checkstyle.checks.whitespace.FileTabCharacterCheck"/>
+</file>
+</checkstyle>
+Checkstyle ends with 1 errors.
+`
+
+func TestJavaAnalyzerService_ParseOutput(t *testing.T) {
+	service := NewJavaAnalyzerService()
+
+	ws, err := newWorkspace([]models.FileInput{
+		{Path: "src/main/java/com/example/Main.java", Content: ""},
+		{Path: "src/main/java/com/example/Util.java", Content: ""},
+		{Path: "src/main/java/com/example/Clean.java", Content: ""},
+	})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer ws.Close()
+
+	findings, err := service.parseOutput(ws, []byte(fmt.Sprintf(checkstyleOutput, ws.root)))
+	if err != nil {
+		t.Fatalf("parseOutput() error = %v", err)
+	}
+
+	main := findings["src/main/java/com/example/Main.java"]
+	if len(main) != 2 {
+		t.Fatalf("parseOutput() for Main.java = %+v, want 2 findings", main)
+	}
+	want := models.LineComment{Line: 3, Column: 1, RuleID: "MissingJavadocType", Severity: models.SeverityWarning, Tool: "checkstyle", Comment: "Missing a Javadoc comment."}
+	if main[0] != want {
+		t.Errorf("finding = %+v, want %+v", main[0], want)
+	}
+	if main[1].RuleID != "LineLength" || main[1].Severity != models.SeverityError || main[1].Column != 0 {
+		t.Errorf("finding = %+v, want LineLength as error without column", main[1])
+	}
+
+	util := findings["src/main/java/com/example/Util.java"]
+	if len(util) != 1 || util[0].RuleID != "magicNumberCustomId" || util[0].Severity != models.SeverityInfo || util[0].Comment != "'42' is a magic number." {
+		t.Errorf("parseOutput() for Util.java = %+v, want the magic number finding under its module id", util)
+	}
+	if len(findings) != 2 {
+		t.Errorf("parseOutput() returned findings for %d files, want 2", len(findings))
+	}
+
+	// An aborted audit leaves the report unfinished.
+	truncated := fmt.Sprintf("<?xml version=\"1.0\"?>\n<checkstyle version=\"10.12.5\">\n<file name=\"%s/main/java/com/example/Main.java\">\n", ws.root)
+	if _, err := service.parseOutput(ws, []byte(truncated)); err == nil {
+		t.Error("parseOutput() of a truncated report succeeded, want an error")
+	}
+	if _, err := service.parseOutput(ws, []byte("Checkstyle ends with 1 errors.")); err == nil {
+		t.Error("parseOutput() of output without a report succeeded, want an error")
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{source: "com.puppycrawl.tools.checkstyle.checks.sizes.LineLengthCheck", want: "LineLength"},
+		{source: "com.puppycrawl.tools.checkstyle.checks.javadoc.JavadocMethodCheck", want: "JavadocMethod"},
+		{source: "com.puppycrawl.tools.checkstyle.checks.whitespace.FileTabCharacterCheck", want: "FileTabCharacter"},
+		{source: "com.example.checks.Check", want: "Check"},
+		{source: "magicNumberCustomId", want: "magicNumberCustomId"},
+		{source: "LineLength", want: "LineLength"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			if got := checkName(tt.source); got != tt.want {
+				t.Errorf("checkName(%q) = %q, want %q", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckstyleSeverity(t *testing.T) {
+	tests := []struct {
+		level string
+		want  string
+	}{
+		{level: "error", want: models.SeverityError},
+		{level: "warning", want: models.SeverityWarning},
+		{level: "info", want: models.SeverityInfo},
+		{level: "ignore", want: models.SeverityInfo},
+		{level: "", want: models.SeverityInfo},
+	}
+
+	for _, tt := range tests {
+		if got := checkstyleSeverity(tt.level); got != tt.want {
+			t.Errorf("checkstyleSeverity(%q) = %q, want %q", tt.level, got, tt.want)
+		}
+	}
+}
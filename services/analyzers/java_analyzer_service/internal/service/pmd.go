@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"java_analyzer_service/internal/models"
+)
+
+// defaultPMDRulesets is the general purpose ruleset bundled with PMD, used
+// when neither the project nor PMD_RULESETS chooses rulesets.
+const defaultPMDRulesets = "rulesets/java/quickstart.xml"
+
+// pmdRulesetFiles are the project PMD rulesets we look for, relative to the
+// project root, in order of preference.
+var pmdRulesetFiles = []string{
+	"pmd.xml",
+	"pmd-ruleset.xml",
+	"ruleset.xml",
+	"config/pmd/pmd.xml",
+	"config/pmd/ruleset.xml",
+}
+
+// pmdResult holds the violations PMD reported by original path, or, when
+// PMD timed out or failed, the results that replace those of the files it
+// should have analyzed.
+type pmdResult struct {
+	findings map[string][]models.LineComment
+	failed   []models.FileResult
+}
+
+// pmdFiles runs "pmd check" once over the given files when PMD is enabled,
+// with the project's ruleset when it has one and the configured rulesets
+// otherwise.
+func (s *JavaAnalyzerService) pmdFiles(ctx context.Context, ws *workspace, files []models.FileInput, pending []int) pmdResult {
+	if !s.pmd || len(pending) == 0 {
+		return pmdResult{}
+	}
+	paths := make([]string, len(pending))
+	for j, i := range pending {
+		paths[j] = files[i].Path
+	}
+
+	// Waiting for a free process slot does not count against the deadline.
+	if err := s.processes.acquire(ctx); err != nil {
+		return pmdResult{failed: timeoutResults(paths)}
+	}
+	defer s.processes.release()
+
+	ctx, cancel := context.WithTimeout(ctx, s.fileTimeout*time.Duration(len(paths)))
+	defer cancel()
+
+	// The file list keeps paths with commas or spaces intact.
+	var list strings.Builder
+	for _, p := range paths {
+		list.WriteString(filepath.Join(ws.root, ws.relPath(p)) + "\n")
+	}
+	fileList, err := ws.writeGenerated("pmd-files.txt", []byte(list.String()))
+	if err != nil {
+		return pmdResult{failed: toolErrorResults(paths, "pmd", err, nil)}
+	}
+
+	rulesets := s.pmdRulesets
+	if name := ws.findConfig(pmdRulesetFiles...); name != "" {
+		rulesets = name
+	}
+	args := []string{"check", "--no-cache", "--no-progress", "--format", "json", "--rulesets", rulesets, "--file-list", fileList}
+	output, stderr, runErr := ws.run(ctx, s.pmdPath, args...)
+
+	if ctx.Err() != nil {
+		return pmdResult{failed: timeoutResults(paths)}
+	}
+
+	// PMD exits with 4 when it reports violations and with 5 when it could
+	// not process some of the files, which the report lists.
+	if err := exitError(runErr, 4, 5); err != nil {
+		return pmdResult{failed: toolErrorResults(paths, "pmd", err, stderr)}
+	}
+	result, err := parsePMDOutput(ws, output)
+	if err != nil {
+		return pmdResult{failed: toolErrorResults(paths, "pmd", err, stderr)}
+	}
+	return result
+}
+
+type pmdReport struct {
+	Files []struct {
+		Filename   string `json:"filename"`
+		Violations []struct {
+			BeginLine   int    `json:"beginline"`
+			BeginColumn int    `json:"begincolumn"`
+			EndLine     int    `json:"endline"`
+			EndColumn   int    `json:"endcolumn"`
+			Description string `json:"description"`
+			Rule        string `json:"rule"`
+			Ruleset     string `json:"ruleset"`
+			Priority    int    `json:"priority"`
+		} `json:"violations"`
+	} `json:"files"`
+	ProcessingErrors []struct {
+		Filename string `json:"filename"`
+		Message  string `json:"message"`
+		Detail   string `json:"detail"`
+	} `json:"processingErrors"`
+}
+
+// parsePMDOutput groups the violations of PMD's JSON report by original
+// path. Files PMD could not process, e.g. because they do not parse, are
+// returned as failed with PMD's message.
+func parsePMDOutput(ws *workspace, output []byte) (pmdResult, error) {
+	var report pmdReport
+	if err := json.Unmarshal(output, &report); err != nil {
+		return pmdResult{}, fmt.Errorf("invalid pmd report: %w", err)
+	}
+
+	result := pmdResult{findings: make(map[string][]models.LineComment)}
+	for _, file := range report.Files {
+		path, ok := ws.originalPath(file.Filename)
+		if !ok {
+			continue
+		}
+		for _, v := range file.Violations {
+			result.findings[path] = append(result.findings[path], models.LineComment{
+				Line:      v.BeginLine,
+				Column:    v.BeginColumn,
+				EndLine:   v.EndLine,
+				EndColumn: v.EndColumn,
+				RuleID:    v.Rule,
+				Severity:  pmdSeverity(v.Priority),
+				Tool:      "pmd",
+				Comment:   fmt.Sprintf("%s (%s)", strings.TrimSpace(v.Description), v.Ruleset),
+			})
+		}
+	}
+	for _, processingError := range report.ProcessingErrors {
+		path, ok := ws.originalPath(processingError.Filename)
+		if !ok {
+			continue
+		}
+		result.failed = append(result.failed, toolErrorResults([]string{path}, "pmd", errors.New(processingError.Message), []byte(processingError.Detail))...)
+	}
+	return result, nil
+}
+
+// pmdSeverity maps PMD rule priorities, from 1 (high) to 5 (low).
+func pmdSeverity(priority int) string {
+	switch {
+	case priority <= 2:
+		return models.SeverityError
+	case priority == 3:
+		return models.SeverityWarning
+	default:
+		return models.SeverityInfo
+	}
+}
+
+// apply merges the PMD violations into the checkstyle results of the given
+// indices. Results that already timed out or failed are left alone.
+func (r pmdResult) apply(results []models.FileResult, indices []int) {
+	failed := make(map[string]models.FileResult, len(r.failed))
+	for _, result := range r.failed {
+		failed[result.Path] = result
+	}
+
+	for _, i := range indices {
+		if results[i].Status != models.StatusOK && results[i].Status != models.StatusIssues {
+			continue
+		}
+		if result, ok := failed[results[i].Path]; ok {
+			results[i] = result
+			continue
+		}
+
+		extra := r.findings[results[i].Path]
+		if len(extra) == 0 {
+			continue
+		}
+		lineComments := append(results[i].LineComments, extra...)
+		sort.SliceStable(lineComments, func(a, b int) bool {
+			if lineComments[a].Line != lineComments[b].Line {
+				return lineComments[a].Line < lineComments[b].Line
+			}
+			return lineComments[a].Column < lineComments[b].Column
+		})
+		results[i].LineComments = lineComments
+		results[i].Status = models.StatusIssues
+		results[i].Comment = "Issues found"
+	}
+}
+
+// pmdRulesetsFromEnv reads comma-separated rulesets from the environment,
+// falling back to the default when the variable is unset.
+func pmdRulesetsFromEnv(key string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return defaultPMDRulesets
+}
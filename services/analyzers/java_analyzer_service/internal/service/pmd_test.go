@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"java_analyzer_service/internal/models"
+)
+
+func TestParsePMDOutput(t *testing.T) {
+	ws, err := newWorkspace([]models.FileInput{
+		{Path: "src/Main.java", Content: ""},
+		{Path: "src/Broken.java", Content: ""},
+	})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer ws.Close()
+
+	output := fmt.Sprintf(`{
+  "formatVersion": 0,
+  "files": [
+    {"filename": "%[1]s/Main.java", "violations": [
+      {"beginline": 3, "begincolumn": 5, "endline": 3, "endcolumn": 20, "description": "Avoid unused private fields such as 'x'.\n", "rule": "UnusedPrivateField", "ruleset": "Best Practices", "priority": 3},
+      {"beginline": 7, "begincolumn": 9, "endline": 9, "endcolumn": 10, "description": "Avoid empty catch blocks", "rule": "EmptyCatchBlock", "ruleset": "Error Prone", "priority": 1}
+    ]},
+    {"filename": "/elsewhere/Other.java", "violations": [
+      {"beginline": 1, "begincolumn": 1, "endline": 1, "endcolumn": 2, "description": "x", "rule": "X", "ruleset": "Y", "priority": 5}
+    ]}
+  ],
+  "processingErrors": [
+    {"filename": "%[1]s/Broken.java", "message": "ParseException: Encountered \"}\" at line 4", "detail": "net.sourceforge.pmd.lang.ast.ParseException"}
+  ]
+}`, ws.root)
+
+	result, err := parsePMDOutput(ws, []byte(output))
+	if err != nil {
+		t.Fatalf("parsePMDOutput() error = %v", err)
+	}
+
+	got := result.findings["src/Main.java"]
+	if len(got) != 2 {
+		t.Fatalf("parsePMDOutput() findings for src/Main.java = %+v, want 2", got)
+	}
+	want := models.LineComment{
+		Line: 3, Column: 5, EndLine: 3, EndColumn: 20,
+		RuleID: "UnusedPrivateField", Severity: models.SeverityWarning, Tool: "pmd",
+		Comment: "Avoid unused private fields such as 'x'. (Best Practices)",
+	}
+	if got[0] != want {
+		t.Errorf("finding = %+v, want %+v", got[0], want)
+	}
+	if got[1].Severity != models.SeverityError {
+		t.Errorf("severity of a priority 1 violation = %q, want %q", got[1].Severity, models.SeverityError)
+	}
+	if len(result.findings) != 1 {
+		t.Errorf("parsePMDOutput() returned findings for %d files, want 1", len(result.findings))
+	}
+
+	if len(result.failed) != 1 || result.failed[0].Path != "src/Broken.java" || result.failed[0].Status != models.StatusToolError || result.failed[0].Stderr == "" {
+		t.Errorf("parsePMDOutput() failed = %+v, want a tool error for src/Broken.java", result.failed)
+	}
+
+	if _, err := parsePMDOutput(ws, []byte("Error: no rulesets")); err == nil {
+		t.Error("parsePMDOutput() of output that is not a report succeeded, want an error")
+	}
+}
+
+func TestPMDSeverity(t *testing.T) {
+	tests := []struct {
+		priority int
+		want     string
+	}{
+		{priority: 1, want: models.SeverityError},
+		{priority: 2, want: models.SeverityError},
+		{priority: 3, want: models.SeverityWarning},
+		{priority: 4, want: models.SeverityInfo},
+		{priority: 5, want: models.SeverityInfo},
+	}
+
+	for _, tt := range tests {
+		if got := pmdSeverity(tt.priority); got != tt.want {
+			t.Errorf("pmdSeverity(%d) = %q, want %q", tt.priority, got, tt.want)
+		}
+	}
+}
+
+func TestJavaAnalyzerService_PMDExitCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		code   int
+		report string
+		want   string
+	}{
+		{name: "no violations", code: 0, report: `{"files":[]}`, want: models.StatusOK},
+		{name: "violations", code: 4, report: `{"files":[{"filename":"'"$file"'","violations":[{"beginline":2,"begincolumn":1,"endline":2,"endcolumn":5,"description":"Avoid empty catch blocks","rule":"EmptyCatchBlock","ruleset":"Error Prone","priority":3}]}]}`, want: models.StatusIssues},
+		{name: "processing errors in other files", code: 5, report: `{"files":[{"filename":"'"$file"'","violations":[{"beginline":2,"begincolumn":1,"endline":2,"endcolumn":5,"description":"Avoid empty catch blocks","rule":"EmptyCatchBlock","ruleset":"Error Prone","priority":3}]}],"processingErrors":[{"filename":"/elsewhere/Gen.java","message":"ParseException","detail":"at line 2"}]}`, want: models.StatusIssues},
+		{name: "processing errors", code: 5, report: `{"files":[],"processingErrors":[{"filename":"'"$file"'","message":"ParseException","detail":"at line 2"}]}`, want: models.StatusToolError},
+		{name: "failure", code: 1, report: ``, want: models.StatusToolError},
+		{name: "invalid report", code: 4, report: `not json`, want: models.StatusToolError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The fake PMD reports the first file of its --file-list.
+			script := filepath.Join(t.TempDir(), "pmd")
+			content := fmt.Sprintf("#!/bin/sh\nfor last; do :; done\nfile=$(head -n 1 \"$last\")\necho '%s'\nexit %d\n", tt.report, tt.code)
+			if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+				t.Fatalf("failed to write fake pmd: %v", err)
+			}
+			service := NewJavaAnalyzerService()
+			service.pmd = true
+			service.pmdPath = script
+
+			files := []models.FileInput{{Path: "Main.java", Content: "class Main {\n}\n"}}
+			ws, err := newWorkspace(files)
+			if err != nil {
+				t.Fatalf("newWorkspace() error = %v", err)
+			}
+			defer ws.Close()
+
+			results := fileResults([]string{"Main.java"}, nil)
+			service.pmdFiles(context.Background(), ws, files, []int{0}).apply(results, []int{0})
+			if results[0].Status != tt.want {
+				t.Errorf("status after pmd exited with %d = %q (%s), want %q", tt.code, results[0].Status, results[0].Comment, tt.want)
+			}
+		})
+	}
+}
+
+func TestPMDResult_Apply(t *testing.T) {
+	results := []models.FileResult{
+		{Path: "A.java", Status: models.StatusIssues, Comment: "Issues found", LineComments: []models.LineComment{
+			{Line: 2, Column: 5, RuleID: "MagicNumber", Tool: "checkstyle"},
+			{Line: 9, Column: 1, RuleID: "LineLength", Tool: "checkstyle"},
+		}},
+		{Path: "B.java", Status: models.StatusOK, Comment: "OK", LineComments: []models.LineComment{}},
+		{Path: "C.java", Status: models.StatusTimeout, Comment: "Analysis timed out", LineComments: []models.LineComment{}},
+		{Path: "D.java", Status: models.StatusOK, Comment: "OK", LineComments: []models.LineComment{}},
+		{Path: "E.java", Status: models.StatusOK, Comment: "OK", LineComments: []models.LineComment{}},
+	}
+	r := pmdResult{
+		findings: map[string][]models.LineComment{
+			"A.java": {
+				{Line: 2, Column: 1, RuleID: "UnusedLocalVariable", Tool: "pmd"},
+				{Line: 5, Column: 3, RuleID: "EmptyCatchBlock", Tool: "pmd"},
+			},
+			"B.java": {{Line: 1, Column: 1, RuleID: "UseUtilityClass", Tool: "pmd"}},
+			"C.java": {{Line: 1, Column: 1, RuleID: "UseUtilityClass", Tool: "pmd"}},
+		},
+		failed: toolErrorResults([]string{"D.java"}, "pmd", fmt.Errorf("ParseException"), nil),
+	}
+
+	r.apply(results, []int{0, 1, 2, 3, 4})
+
+	var got []string
+	for _, lineComment := range results[0].LineComments {
+		got = append(got, fmt.Sprintf("%d:%d %s %s", lineComment.Line, lineComment.Column, lineComment.RuleID, lineComment.Tool))
+	}
+	want := []string{
+		"2:1 UnusedLocalVariable pmd",
+		"2:5 MagicNumber checkstyle",
+		"5:3 EmptyCatchBlock pmd",
+		"9:1 LineLength checkstyle",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("apply() findings = %v, want %v", got, want)
+	}
+	if results[1].Status != models.StatusIssues || results[1].Comment != "Issues found" || len(results[1].LineComments) != 1 {
+		t.Errorf("apply() result for B.java = %+v, want the PMD finding as issues", results[1])
+	}
+	if results[2].Status != models.StatusTimeout || len(results[2].LineComments) != 0 {
+		t.Errorf("apply() result for C.java = %+v, want the timeout kept", results[2])
+	}
+	if results[3].Status != models.StatusToolError {
+		t.Errorf("apply() status of D.java = %q, want %q", results[3].Status, models.StatusToolError)
+	}
+	if results[4].Status != models.StatusOK {
+		t.Errorf("apply() status of E.java = %q, want %q", results[4].Status, models.StatusOK)
+	}
+}
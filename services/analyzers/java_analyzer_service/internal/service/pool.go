@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+// forEach calls fn for every index in [0, n) using at most workers
+// goroutines. Callers write results by index, which keeps them in input order.
+func forEach(n, workers int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// processLimiter caps the number of tool processes running at the same time
+// across all requests handled by the service.
+type processLimiter chan struct{}
+
+func newProcessLimiter(limit int) processLimiter {
+	if limit < 1 {
+		limit = 1
+	}
+	return make(processLimiter, limit)
+}
+
+// acquire blocks until a process slot is free or ctx is done.
+func (l processLimiter) acquire(ctx context.Context) error {
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l processLimiter) release() {
+	<-l
+}
+
+// batches splits items into at most n contiguous, similarly sized groups.
+func batches(items []int, n int) [][]int {
+	if n < 1 {
+		n = 1
+	}
+	if n > len(items) {
+		n = len(items)
+	}
+
+	groups := make([][]int, 0, n)
+	for g := 0; g < n; g++ {
+		start := g * len(items) / n
+		end := (g + 1) * len(items) / n
+		groups = append(groups, items[start:end])
+	}
+	return groups
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// eslintFlatConfigFiles are the flat config files eslint picks up by itself
+// from the project root.
+var eslintFlatConfigFiles = []string{
+	"eslint.config.js",
+	"eslint.config.mjs",
+	"eslint.config.cjs",
+	"eslint.config.ts",
+	"eslint.config.mts",
+	"eslint.config.cts",
+}
+
+// eslintLegacyConfigFiles are the eslintrc files that need eslint's legacy
+// configuration mode.
+var eslintLegacyConfigFiles = []string{
+	".eslintrc.js",
+	".eslintrc.cjs",
+	".eslintrc.yaml",
+	".eslintrc.yml",
+	".eslintrc.json",
+	".eslintrc",
+}
+
+// defaultESLintConfig is used when the project has no ESLint configuration.
+// It only enables core rules, because shareable configs and plugins cannot be
+// resolved from the workspace. The parsers for TypeScript, Vue and Svelte are
+// loaded from the parsers directory, which configArgs fills in; file types
+// whose parser is not installed fall back to the default parser.
+const defaultESLintConfig = `import { createRequire } from "node:module";
+
+const require = createRequire(%q);
+
+function parser(name) {
+	try {
+		return require(name);
+	} catch {
+		return undefined;
+	}
+}
+
+const typescript = parser("@typescript-eslint/parser");
+const vue = parser("vue-eslint-parser");
+const svelte = parser("svelte-eslint-parser");
+
+export default [
+	{
+		files: ["**/*.{js,jsx,mjs,cjs,ts,tsx,mts,cts,vue,svelte}"],
+		languageOptions: {
+			ecmaVersion: "latest",
+			sourceType: "module",
+			parserOptions: { ecmaFeatures: { jsx: true } },
+		},
+		rules: {
+			"no-constant-condition": "warn",
+			"no-debugger": "warn",
+			"no-dupe-keys": "error",
+			"no-duplicate-case": "error",
+			"no-empty": "warn",
+			"no-func-assign": "error",
+			"no-self-assign": "error",
+			"no-unreachable": "error",
+			"no-unused-vars": "warn",
+			"use-isnan": "error",
+			"valid-typeof": "error",
+		},
+	},
+	{
+		files: ["**/*.{cjs,cts}"],
+		languageOptions: { sourceType: "commonjs" },
+	},
+	...(typescript ? [{
+		files: ["**/*.{ts,tsx,mts,cts}"],
+		languageOptions: { parser: typescript },
+	}] : []),
+	...(vue ? [{
+		files: ["**/*.vue"],
+		languageOptions: { parser: vue, parserOptions: { parser: typescript } },
+	}] : []),
+	...(svelte ? [{
+		files: ["**/*.svelte"],
+		languageOptions: { parser: svelte, parserOptions: { parser: typescript } },
+	}] : []),
+];
+`
+
+// eslintInvocation holds the extra arguments and environment that make
+// eslint use the configuration of the analyzed project.
+type eslintInvocation struct {
+	args []string
+	env  []string
+}
+
+// configArgs selects how eslint is configured: a flat config at the project
+// root is found by eslint itself, eslintrc files (or an "eslintConfig" key in
+// package.json) switch eslint to its legacy mode, and projects without any
+// configuration are linted with the bundled default.
+func (s *JavaScriptAnalyzerService) configArgs(ws *workspace) eslintInvocation {
+	if ws.findConfig(eslintFlatConfigFiles...) != "" {
+		return eslintInvocation{}
+	}
+
+	if ws.findConfig(eslintLegacyConfigFiles...) != "" || packageJSONHasESLintConfig(ws) {
+		return eslintInvocation{env: []string{"ESLINT_USE_FLAT_CONFIG=false"}}
+	}
+
+	config := fmt.Sprintf(defaultESLintConfig, s.parsersDir+"/")
+	path, err := ws.writeGenerated("eslint.config.mjs", []byte(config))
+	if err != nil {
+		return eslintInvocation{}
+	}
+	return eslintInvocation{args: []string{"--config", path}}
+}
+
+func packageJSONHasESLintConfig(ws *workspace) bool {
+	data, err := ws.readConfig("package.json")
+	if err != nil {
+		return false
+	}
+
+	var pkg struct {
+		ESLintConfig json.RawMessage `json:"eslintConfig"`
+	}
+	return json.Unmarshal(data, &pkg) == nil && len(pkg.ESLintConfig) > 0
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"javascript_analyzer_service/internal/models"
+)
+
+func TestJavaScriptAnalyzerService_ConfigArgs(t *testing.T) {
+	service := NewJavaScriptAnalyzerService()
+	service.parsersDir = "/opt/parsers"
+
+	tests := []struct {
+		name    string
+		files   []models.FileInput
+		wantEnv []string
+	}{
+		{name: "flat config", files: []models.FileInput{{Path: "eslint.config.mjs", Content: "export default [];\n"}}},
+		{name: "eslintrc", files: []models.FileInput{{Path: ".eslintrc.json", Content: "{}\n"}}, wantEnv: []string{"ESLINT_USE_FLAT_CONFIG=false"}},
+		{name: "package.json", files: []models.FileInput{{Path: "package.json", Content: `{"eslintConfig": {"root": true}}`}}, wantEnv: []string{"ESLINT_USE_FLAT_CONFIG=false"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws, err := newWorkspace(append(tt.files, models.FileInput{Path: "index.js"}))
+			if err != nil {
+				t.Fatalf("newWorkspace() error = %v", err)
+			}
+			defer ws.Close()
+
+			got := service.configArgs(ws)
+			if len(got.args) != 0 || !reflect.DeepEqual(got.env, tt.wantEnv) {
+				t.Errorf("configArgs() = %+v, want no arguments and env %v", got, tt.wantEnv)
+			}
+		})
+	}
+}
+
+func TestJavaScriptAnalyzerService_DefaultConfig(t *testing.T) {
+	service := NewJavaScriptAnalyzerService()
+	service.parsersDir = "/opt/parsers"
+
+	ws, err := newWorkspace([]models.FileInput{
+		{Path: "src/App.vue"},
+		{Path: "package.json", Content: `{"name": "app"}`},
+	})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer ws.Close()
+
+	got := service.configArgs(ws)
+	if len(got.args) != 2 || got.args[0] != "--config" || len(got.env) != 0 {
+		t.Fatalf("configArgs() = %+v, want --config with the generated file", got)
+	}
+	data, err := os.ReadFile(got.args[1])
+	if err != nil {
+		t.Fatalf("failed to read generated config: %v", err)
+	}
+	config := string(data)
+
+	for _, want := range []string{
+		`createRequire("/opt/parsers/")`,
+		`parser("@typescript-eslint/parser")`,
+		`parser("vue-eslint-parser")`,
+		`parser("svelte-eslint-parser")`,
+		`files: ["**/*.{js,jsx,mjs,cjs,ts,tsx,mts,cts,vue,svelte}"]`,
+		`files: ["**/*.{cjs,cts}"]`,
+		`files: ["**/*.{ts,tsx,mts,cts}"]`,
+		`files: ["**/*.vue"]`,
+		`files: ["**/*.svelte"]`,
+	} {
+		if !strings.Contains(config, want) {
+			t.Errorf("generated config does not contain %s:\n%s", want, config)
+		}
+	}
+}
@@ -1,19 +1,65 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
-	"path/filepath"
+	"path"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"javascript_analyzer_service/internal/models"
 )
 
+const (
+	defaultFileTimeout    = 30 * time.Second
+	defaultRequestTimeout = 2 * time.Minute
+
+	// waitDelay bounds how long we wait for output pipes after the tool
+	// process has been killed on cancellation.
+	waitDelay = 2 * time.Second
+
+	// defaultParsersDir is where npm installs global packages in the
+	// service image.
+	defaultParsersDir = "/usr/local/lib/node_modules"
+)
+
+// javaScriptExtensions are the extensions of the files eslint is run on.
+var javaScriptExtensions = map[string]bool{
+	".js":     true,
+	".jsx":    true,
+	".mjs":    true,
+	".cjs":    true,
+	".ts":     true,
+	".tsx":    true,
+	".mts":    true,
+	".cts":    true,
+	".vue":    true,
+	".svelte": true,
+}
+
+func isJavaScriptFile(filePath string) bool {
+	return javaScriptExtensions[strings.ToLower(path.Ext(filePath))]
+}
+
 type JavaScriptAnalyzerService struct {
-	eslintPath string
+	eslintPath     string
+	parsersDir     string
+	tscPath        string
+	typecheck      bool
+	fileTimeout    time.Duration
+	requestTimeout time.Duration
+	maxWorkers     int
+	processes      processLimiter
+	cache          *resultCache
+
+	versionOnce sync.Once
+	version     string
+	versionOK   bool
 }
 
 func NewJavaScriptAnalyzerService() *JavaScriptAnalyzerService {
@@ -21,90 +67,235 @@ func NewJavaScriptAnalyzerService() *JavaScriptAnalyzerService {
 	if eslintPath == "" {
 		eslintPath = "eslint"
 	}
+	parsersDir := os.Getenv("ESLINT_PARSERS_DIR")
+	if parsersDir == "" {
+		parsersDir = defaultParsersDir
+	}
+	tscPath := os.Getenv("TSC_PATH")
+	if tscPath == "" {
+		tscPath = "tsc"
+	}
 
 	return &JavaScriptAnalyzerService{
-		eslintPath: eslintPath,
+		eslintPath:     eslintPath,
+		parsersDir:     parsersDir,
+		tscPath:        tscPath,
+		typecheck:      boolFromEnv("TYPECHECK", false),
+		fileTimeout:    durationFromEnv("ANALYZER_FILE_TIMEOUT", defaultFileTimeout),
+		requestTimeout: durationFromEnv("ANALYZER_REQUEST_TIMEOUT", defaultRequestTimeout),
+		maxWorkers:     intFromEnv("MAX_WORKERS", runtime.NumCPU()),
+		processes:      newProcessLimiter(intFromEnv("MAX_PROCESSES", runtime.NumCPU())),
+		cache:          cacheFromEnv(),
+	}
+}
+
+// durationFromEnv parses a Go duration (e.g. "30s") from the environment,
+// falling back to the default when the variable is unset or invalid.
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// intFromEnv parses a positive integer from the environment, falling back to
+// the default when the variable is unset or invalid.
+func intFromEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
 	}
+	return value
 }
 
-func (s *JavaScriptAnalyzerService) Analyze(req *models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
-	var results []models.FileResult
+// boolFromEnv parses a boolean ("true", "1", ...) from the environment,
+// falling back to the default when the variable is unset or invalid.
+func boolFromEnv(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func (s *JavaScriptAnalyzerService) Analyze(ctx context.Context, req *models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
+	if err := validateOptions(req.Options); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
 
-	for _, file := range req.Files {
-		if !strings.HasSuffix(strings.ToLower(file.Path), ".js") && !strings.HasSuffix(strings.ToLower(file.Path), ".jsx") {
-			results = append(results, models.FileResult{
+	results := make([]models.FileResult, len(req.Files))
+	var pending []int
+
+	for i, file := range req.Files {
+		if !isJavaScriptFile(file.Path) {
+			results[i] = models.FileResult{
 				Path:         file.Path,
+				Status:       models.StatusUnsupported,
 				Comment:      "Not a JavaScript file",
 				LineComments: []models.LineComment{},
-			})
+			}
 			continue
 		}
+		pending = append(pending, i)
+	}
 
-		result := s.analyzeFile(file.Path, file.Content)
-		results = append(results, result)
+	keys := s.cacheKeys(req, pending)
+	pending = s.cache.lookup(keys, req.Files, results, pending)
+	if len(pending) == 0 {
+		return &models.AnalyzeResponse{Files: results}, nil
 	}
 
+	// Every file of the request is written to the workspace, so imports between modules resolve like in the project.
+	ws, err := newWorkspace(req.Files)
+	if err != nil {
+		for _, i := range pending {
+			results[i] = models.FileResult{
+				Path:         req.Files[i].Path,
+				Status:       models.StatusToolError,
+				Comment:      fmt.Sprintf("Error: failed to create workspace: %v", err),
+				LineComments: []models.LineComment{},
+			}
+		}
+		return &models.AnalyzeResponse{Files: results}, nil
+	}
+	defer ws.Close()
+
+	// The type check covers the whole program, so it runs once per request,
+	// alongside the eslint batches.
+	typecheckDone := make(chan typecheckResult, 1)
+	go func() {
+		typecheckDone <- s.typecheckFiles(ctx, ws, req.Files, pending)
+	}()
+
+	config := s.configArgs(ws)
+	config.args = append(config.args, optionArgs(req.Options)...)
+	groups := batches(pending, s.maxWorkers)
+	forEach(len(groups), s.maxWorkers, func(g int) {
+		paths := make([]string, len(groups[g]))
+		for j, i := range groups[g] {
+			paths[j] = req.Files[i].Path
+		}
+		for j, result := range s.analyzeBatch(ctx, ws, config, paths) {
+			results[groups[g][j]] = result
+		}
+	})
+
+	(<-typecheckDone).apply(results, pending)
+
+	for i := range results {
+		results[i] = applyOptions(results[i], req.Options)
+	}
+	s.cache.store(keys, results, pending)
+
 	return &models.AnalyzeResponse{Files: results}, nil
 }
 
-func (s *JavaScriptAnalyzerService) analyzeFile(path, content string) models.FileResult {
-	tmpDir := os.TempDir()
-	tmpFile := filepath.Join(tmpDir, fmt.Sprintf("analyze_%d_%s", time.Now().UnixNano(), filepath.Base(path)))
-	defer os.Remove(tmpFile)
+// analyzeBatch runs eslint once over the given files inside the workspace,
+// configured for the project, and returns one result per path, in the same
+// order.
+func (s *JavaScriptAnalyzerService) analyzeBatch(ctx context.Context, ws *workspace, config eslintInvocation, paths []string) []models.FileResult {
+	// Waiting for a free process slot does not count against the deadline.
+	if err := s.processes.acquire(ctx); err != nil {
+		return timeoutResults(paths)
+	}
+	defer s.processes.release()
 
-	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
-		return models.FileResult{
-			Path:         path,
-			Comment:      fmt.Sprintf("Error: failed to create temp file: %v", err),
-			LineComments: []models.LineComment{},
-		}
+	ctx, cancel := context.WithTimeout(ctx, s.fileTimeout*time.Duration(len(paths)))
+	defer cancel()
+
+	args := append([]string{"--format", "json"}, config.args...)
+	for _, path := range paths {
+		args = append(args, ws.relPath(path))
 	}
+	// Only stdout carries the JSON report; warnings go to stderr.
+	output, stderr, err := ws.runEnv(ctx, config.env, s.eslintPath, args...)
 
-	cmd := exec.Command(s.eslintPath, "--format", "json", tmpFile)
-	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return timeoutResults(paths)
+	}
+	// eslint exits with 1 when it reports errors and with 2 when it fails,
+	// e.g. on an invalid configuration.
+	if err := exitError(err, 1); err != nil {
+		return toolErrorResults(paths, "eslint", err, stderr)
+	}
 
-	if err != nil && len(output) == 0 {
-		return models.FileResult{
-			Path:         path,
-			Comment:      "OK",
-			LineComments: []models.LineComment{},
-		}
+	findings, err := s.parseOutput(ws, output)
+	if err != nil {
+		return toolErrorResults(paths, "eslint", err, stderr)
 	}
+	return fileResults(paths, findings)
+}
+
+// parseOutput groups eslint findings by the original path of the file they
+// were reported for. Output that is not a JSON report means eslint failed.
+func (s *JavaScriptAnalyzerService) parseOutput(ws *workspace, output []byte) (map[string][]models.LineComment, error) {
+	findings := make(map[string][]models.LineComment)
 
 	var eslintResults []map[string]interface{}
 	if err := json.Unmarshal(output, &eslintResults); err != nil {
-		return models.FileResult{
-			Path:         path,
-			Comment:      "OK",
-			LineComments: []models.LineComment{},
-		}
+		return nil, fmt.Errorf("invalid JSON report: %w", err)
 	}
 
-	var lineComments []models.LineComment
-	comment := "OK"
-
 	for _, fileResult := range eslintResults {
+		filePath, _ := fileResult["filePath"].(string)
+		path, ok := ws.originalPath(filePath)
+		if !ok {
+			continue
+		}
+
 		messages, _ := fileResult["messages"].([]interface{})
 		for _, msg := range messages {
 			msgMap, _ := msg.(map[string]interface{})
 			line, _ := msgMap["line"].(float64)
+			column, _ := msgMap["column"].(float64)
+			endLine, _ := msgMap["endLine"].(float64)
+			endColumn, _ := msgMap["endColumn"].(float64)
+			ruleID, _ := msgMap["ruleId"].(string)
+			severity, _ := msgMap["severity"].(float64)
 			message, _ := msgMap["message"].(string)
 
-			lineComments = append(lineComments, models.LineComment{
-				Line:    int(line),
-				Comment: message,
+			findings[path] = append(findings[path], models.LineComment{
+				Line:      int(line),
+				Column:    int(column),
+				EndLine:   int(endLine),
+				EndColumn: int(endColumn),
+				RuleID:    ruleID,
+				Severity:  eslintSeverity(int(severity)),
+				Tool:      "eslint",
+				Comment:   message,
 			})
-
-			if comment == "OK" {
-				comment = "Issues found"
-			}
 		}
 	}
 
-	return models.FileResult{
-		Path:         path,
-		Comment:      comment,
-		LineComments: lineComments,
+	return findings, nil
+}
+
+// eslintSeverity maps eslint's numeric severity (2 = error, 1 = warning).
+func eslintSeverity(severity int) string {
+	switch severity {
+	case 2:
+		return models.SeverityError
+	case 1:
+		return models.SeverityWarning
+	default:
+		return models.SeverityInfo
+	}
+}
+
+func timeoutResults(paths []string) []models.FileResult {
+	results := make([]models.FileResult, len(paths))
+	for i, path := range paths {
+		results[i] = models.FileResult{
+			Path:         path,
+			Status:       models.StatusTimeout,
+			Comment:      "Analysis timed out",
+			LineComments: []models.LineComment{},
+		}
 	}
+	return results
 }
 
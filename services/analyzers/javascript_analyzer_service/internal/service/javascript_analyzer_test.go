@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"javascript_analyzer_service/internal/models"
+)
+
+func TestIsJavaScriptFile(t *testing.T) {
+	tests := []struct {
+		path       string
+		eslint     bool
+		typescript bool
+	}{
+		{path: "src/index.js", eslint: true},
+		{path: "src/App.jsx", eslint: true},
+		{path: "scripts/build.mjs", eslint: true},
+		{path: "scripts/config.cjs", eslint: true},
+		{path: "src/app.ts", eslint: true, typescript: true},
+		{path: "src/App.TSX", eslint: true, typescript: true},
+		{path: "src/worker.mts", eslint: true, typescript: true},
+		{path: "src/legacy.cts", eslint: true, typescript: true},
+		{path: "src/App.vue", eslint: true},
+		{path: "src/Button.svelte", eslint: true},
+		{path: "src/styles.css"},
+		{path: "package.json"},
+		{path: "README.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := isJavaScriptFile(tt.path); got != tt.eslint {
+				t.Errorf("isJavaScriptFile(%q) = %v, want %v", tt.path, got, tt.eslint)
+			}
+			if got := isTypeScriptFile(tt.path); got != tt.typescript {
+				t.Errorf("isTypeScriptFile(%q) = %v, want %v", tt.path, got, tt.typescript)
+			}
+		})
+	}
+}
+
+// writeTool writes a fake tool that records its file arguments, one per
+// line, and prints the given output.
+func writeTool(t *testing.T, name, output string) (script, calls string) {
+	t.Helper()
+	dir := t.TempDir()
+	script = filepath.Join(dir, name)
+	calls = filepath.Join(dir, "calls")
+	content := fmt.Sprintf(`#!/bin/sh
+if [ "$1" = "--version" ]; then echo "v0.0.0"; exit 0; fi
+for arg; do
+	case "$arg" in
+	*.*) echo "$arg" >> %s ;;
+	esac
+done
+echo '%s'
+`, calls, output)
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("failed to write fake %s: %v", name, err)
+	}
+	return script, calls
+}
+
+func readCalls(t *testing.T, calls string) []string {
+	t.Helper()
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatalf("failed to read recorded arguments: %v", err)
+	}
+	var files []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		// Generated files live outside the project tree.
+		if !filepath.IsAbs(line) {
+			files = append(files, line)
+		}
+	}
+	return files
+}
+
+func TestJavaScriptAnalyzerService_AnalyzeRouting(t *testing.T) {
+	eslint, eslintCalls := writeTool(t, "eslint", "[]")
+	tsc, tscCalls := writeTool(t, "tsc", "")
+
+	service := NewJavaScriptAnalyzerService()
+	service.eslintPath = eslint
+	service.tscPath = tsc
+	service.typecheck = true
+	service.maxWorkers = 1
+	service.cache = newResultCache(0, "")
+
+	files := []models.FileInput{
+		{Path: "src/app.ts", Content: "export const x: number = 1;\n"},
+		{Path: "src/View.tsx", Content: "export const View = () => null;\n"},
+		{Path: "src/build.mjs", Content: "export default {};\n"},
+		{Path: "src/config.cjs", Content: "module.exports = {};\n"},
+		{Path: "src/App.vue", Content: "<template><div /></template>\n"},
+		{Path: "src/Button.svelte", Content: "<button>OK</button>\n"},
+		{Path: "src/styles.css", Content: "body {}\n"},
+	}
+	resp, err := service.Analyze(context.Background(), &models.AnalyzeRequest{Files: files})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	for i, result := range resp.Files {
+		want := models.StatusOK
+		if i == len(files)-1 {
+			want = models.StatusUnsupported
+		}
+		if result.Status != want {
+			t.Errorf("Analyze() status of %s = %q (%s), want %q", result.Path, result.Status, result.Comment, want)
+		}
+	}
+
+	wantESLint := []string{"app.ts", "View.tsx", "build.mjs", "config.cjs", "App.vue", "Button.svelte"}
+	if got := readCalls(t, eslintCalls); !reflect.DeepEqual(got, wantESLint) {
+		t.Errorf("eslint ran on %v, want %v", got, wantESLint)
+	}
+	wantTSC := []string{"app.ts", "View.tsx"}
+	if got := readCalls(t, tscCalls); !reflect.DeepEqual(got, wantTSC) {
+		t.Errorf("tsc ran on %v, want %v", got, wantTSC)
+	}
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"javascript_analyzer_service/internal/models"
+)
+
+// typeScriptExtensions are the extensions of the files tsc checks. Vue and
+// Svelte components need their own compilers, so only eslint sees them.
+var typeScriptExtensions = map[string]bool{
+	".ts":  true,
+	".tsx": true,
+	".mts": true,
+	".cts": true,
+}
+
+func isTypeScriptFile(filePath string) bool {
+	return typeScriptExtensions[strings.ToLower(path.Ext(filePath))]
+}
+
+// defaultTSCArgs configure tsc for projects without a tsconfig.json.
+var defaultTSCArgs = []string{
+	"--strict",
+	"--skipLibCheck",
+	"--target", "es2022",
+	"--module", "esnext",
+	"--moduleResolution", "bundler",
+	"--jsx", "preserve",
+}
+
+// tscDiagnostic matches a diagnostic of "tsc --pretty false", e.g.
+// "src/app.ts(3,7): error TS2322: Type 'string' is not assignable to type 'number'.".
+var tscDiagnostic = regexp.MustCompile(`^(.+)\((\d+),(\d+)\): (error|warning|message) (TS\d+): (.*)$`)
+
+// unresolvedPackageCodes are the tsc codes for imports that cannot be
+// resolved. Dependencies are never installed in the workspace, so they are
+// only reported for relative imports.
+var unresolvedPackageCodes = map[string]bool{
+	"TS2307": true, // Cannot find module 'x' or its corresponding type declarations.
+	"TS2792": true, // Cannot find module 'x'. Did you mean to set the 'moduleResolution' option ...?
+	"TS7016": true, // Could not find a declaration file for module 'x'.
+	"TS2875": true, // This JSX tag requires the module path 'x/jsx-runtime' to exist ...
+}
+
+// missingTypesCodes are the tsc codes that follow from missing dependency
+// types rather than from the code, e.g. for every JSX element when the
+// framework's types are not installed. They are never reported.
+var missingTypesCodes = map[string]bool{
+	"TS7026": true, // JSX element implicitly has type 'any' because no interface 'JSX.IntrinsicElements' exists.
+}
+
+// typecheckResult holds the diagnostics of a type check by original path,
+// or, when tsc timed out or failed, the results that replace those of the
+// files it should have checked.
+type typecheckResult struct {
+	findings map[string][]models.LineComment
+	failed   []models.FileResult
+}
+
+// typecheckFiles runs "tsc --noEmit" over the project when type checking is
+// enabled and the request has TypeScript files to analyze. Projects with a
+// tsconfig.json at the root are checked with it, others with the given files
+// and defaultTSCArgs.
+func (s *JavaScriptAnalyzerService) typecheckFiles(ctx context.Context, ws *workspace, files []models.FileInput, pending []int) typecheckResult {
+	if !s.typecheck {
+		return typecheckResult{}
+	}
+	var paths []string
+	for _, i := range pending {
+		if isTypeScriptFile(files[i].Path) {
+			paths = append(paths, files[i].Path)
+		}
+	}
+	if len(paths) == 0 {
+		return typecheckResult{}
+	}
+
+	// Waiting for a free process slot does not count against the deadline.
+	if err := s.processes.acquire(ctx); err != nil {
+		return typecheckResult{failed: timeoutResults(paths)}
+	}
+	defer s.processes.release()
+
+	ctx, cancel := context.WithTimeout(ctx, s.fileTimeout*time.Duration(len(paths)))
+	defer cancel()
+
+	args := []string{"--noEmit", "--pretty", "false"}
+	if ws.findConfig("tsconfig.json") != "" {
+		args = append(args, "--project", "tsconfig.json")
+	} else {
+		args = append(args, defaultTSCArgs...)
+		for _, p := range paths {
+			args = append(args, ws.relPath(p))
+		}
+	}
+	// tsc writes its diagnostics to stdout.
+	output, _, runErr := ws.runEnv(ctx, nil, s.tscPath, args...)
+
+	if ctx.Err() != nil {
+		return typecheckResult{failed: timeoutResults(paths)}
+	}
+
+	// tsc exits with 1 or 2 when it reports diagnostics. Errors that stop
+	// it altogether, such as an unreadable tsconfig.json, have no location.
+	findings, located := parseTSCOutput(ws, output)
+	if err := exitError(runErr, 1, 2); err != nil || (runErr != nil && located == 0) {
+		return typecheckResult{failed: toolErrorResults(paths, "tsc", runErr, output)}
+	}
+	return typecheckResult{findings: findings}
+}
+
+// apply merges the type check into the eslint results of the given indices.
+// Results that already timed out or failed are left alone.
+func (r typecheckResult) apply(results []models.FileResult, indices []int) {
+	failed := make(map[string]models.FileResult, len(r.failed))
+	for _, result := range r.failed {
+		failed[result.Path] = result
+	}
+
+	for _, i := range indices {
+		if results[i].Status != models.StatusOK && results[i].Status != models.StatusIssues {
+			continue
+		}
+		if result, ok := failed[results[i].Path]; ok {
+			results[i] = result
+			continue
+		}
+
+		extra := r.findings[results[i].Path]
+		if len(extra) == 0 {
+			continue
+		}
+		lineComments := append(results[i].LineComments, extra...)
+		sort.SliceStable(lineComments, func(a, b int) bool {
+			if lineComments[a].Line != lineComments[b].Line {
+				return lineComments[a].Line < lineComments[b].Line
+			}
+			return lineComments[a].Column < lineComments[b].Column
+		})
+		results[i].LineComments = lineComments
+		results[i].Status = models.StatusIssues
+		results[i].Comment = "Issues found"
+	}
+}
+
+// parseTSCOutput groups tsc diagnostics by original path and returns them
+// with the number of located diagnostics in the output, reported or not.
+// Follow-up lines of a diagnostic (indented message chains) are appended to
+// its message.
+func parseTSCOutput(ws *workspace, output []byte) (findings map[string][]models.LineComment, located int) {
+	findings = make(map[string][]models.LineComment)
+
+	var last *models.LineComment
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if last != nil && strings.HasPrefix(line, " ") {
+			last.Comment += " " + strings.TrimSpace(line)
+			continue
+		}
+		last = nil
+
+		match := tscDiagnostic.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		located++
+		path, ok := ws.originalPath(match[1])
+		if !ok || missingTypesCodes[match[5]] || unresolvedPackage(match[5], match[6]) {
+			continue
+		}
+		lineNumber, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+
+		findings[path] = append(findings[path], models.LineComment{
+			Line:     lineNumber,
+			Column:   column,
+			RuleID:   match[5],
+			Severity: tscSeverity(match[4]),
+			Tool:     "tsc",
+			Comment:  match[6],
+		})
+		last = &findings[path][len(findings[path])-1]
+	}
+
+	return findings, located
+}
+
+// unresolvedPackage reports whether a diagnostic is about a package import,
+// as opposed to a relative one, that cannot be resolved.
+func unresolvedPackage(code, message string) bool {
+	if !unresolvedPackageCodes[code] {
+		return false
+	}
+	_, rest, found := strings.Cut(message, "'")
+	if !found {
+		return false
+	}
+	return !strings.HasPrefix(rest, ".") && !strings.HasPrefix(rest, "/")
+}
+
+func tscSeverity(category string) string {
+	switch category {
+	case "error":
+		return models.SeverityError
+	case "warning":
+		return models.SeverityWarning
+	default:
+		return models.SeverityInfo
+	}
+}
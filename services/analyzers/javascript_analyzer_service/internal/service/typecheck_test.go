@@ -0,0 +1,89 @@
+package service
+
+import (
+	"testing"
+
+	"javascript_analyzer_service/internal/models"
+)
+
+// tsc runs from the project root, the directory the files share, and reports
+// paths relative to it.
+const tscOutput = `app.ts(3,7): error TS2322: Type 'string' is not assignable to type 'number'.
+components/View.tsx(12,5): error TS2345: Argument of type '{ id: string; }' is not assignable to parameter of type 'Props'.
+  Property 'name' is missing in type '{ id: string; }' but required in type 'Props'.
+app.ts(1,23): error TS2307: Cannot find module 'react' or its corresponding type declarations.
+app.ts(2,20): error TS2307: Cannot find module './missing' or its corresponding type declarations.
+components/View.tsx(14,3): error TS7026: JSX element implicitly has type 'any' because no interface 'JSX.IntrinsicElements' exists.
+../shared/types.d.ts(1,1): warning TS6133: 'x' is declared but its value is never read.
+error TS5083: Cannot read file 'tsconfig.base.json'.
+`
+
+func TestParseTSCOutput(t *testing.T) {
+	ws, err := newWorkspace([]models.FileInput{
+		{Path: "web/app.ts", Content: ""},
+		{Path: "web/components/View.tsx", Content: ""},
+	})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer ws.Close()
+
+	findings, located := parseTSCOutput(ws, []byte(tscOutput))
+	if located != 6 {
+		t.Errorf("parseTSCOutput() located = %d, want 6", located)
+	}
+
+	app := findings["web/app.ts"]
+	if len(app) != 2 {
+		t.Fatalf("parseTSCOutput() for web/app.ts = %+v, want the type error and the relative import", app)
+	}
+	want := models.LineComment{
+		Line: 3, Column: 7, RuleID: "TS2322", Severity: models.SeverityError, Tool: "tsc",
+		Comment: "Type 'string' is not assignable to type 'number'.",
+	}
+	if app[0] != want {
+		t.Errorf("finding = %+v, want %+v", app[0], want)
+	}
+	if app[1].RuleID != "TS2307" || app[1].Line != 2 {
+		t.Errorf("finding = %+v, want TS2307 for './missing' on line 2", app[1])
+	}
+
+	view := findings["web/components/View.tsx"]
+	if len(view) != 1 {
+		t.Fatalf("parseTSCOutput() for web/components/View.tsx = %+v, want 1 finding", view)
+	}
+	wantComment := "Argument of type '{ id: string; }' is not assignable to parameter of type 'Props'. Property 'name' is missing in type '{ id: string; }' but required in type 'Props'."
+	if view[0].Comment != wantComment {
+		t.Errorf("comment = %q, want the message chain %q", view[0].Comment, wantComment)
+	}
+	if len(findings) != 2 {
+		t.Errorf("parseTSCOutput() returned findings for %d files, want 2", len(findings))
+	}
+}
+
+func TestUnresolvedPackage(t *testing.T) {
+	tests := []struct {
+		code    string
+		message string
+		want    bool
+	}{
+		{code: "TS2307", message: "Cannot find module 'react' or its corresponding type declarations.", want: true},
+		{code: "TS2307", message: "Cannot find module '@scope/pkg/sub' or its corresponding type declarations.", want: true},
+		{code: "TS2307", message: "Cannot find module './util' or its corresponding type declarations.", want: false},
+		{code: "TS2307", message: "Cannot find module '../lib/util' or its corresponding type declarations.", want: false},
+		{code: "TS2307", message: "Cannot find module '/abs/util' or its corresponding type declarations.", want: false},
+		{code: "TS2792", message: "Cannot find module 'vue'. Did you mean to set the 'moduleResolution' option to 'nodenext'?", want: true},
+		{code: "TS7016", message: "Could not find a declaration file for module 'lodash'.", want: true},
+		{code: "TS2875", message: "This JSX tag requires the module path 'react/jsx-runtime' to exist, but none could be found.", want: true},
+		{code: "TS2322", message: "Type 'string' is not assignable to type 'number'.", want: false},
+		{code: "TS2307", message: "Cannot find module", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code+" "+tt.message, func(t *testing.T) {
+			if got := unresolvedPackage(tt.code, tt.message); got != tt.want {
+				t.Errorf("unresolvedPackage(%q, %q) = %v, want %v", tt.code, tt.message, got, tt.want)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 func NewRouter(analyzerController *AnalyzerController) *mux.Router {
 	router := mux.NewRouter()
 	router.HandleFunc("/api/analyzer/json", analyzerController.Analyze).Methods("POST")
+	router.HandleFunc("/api/analyzer/json/cache", analyzerController.CacheStats).Methods("GET")
 	return router
 }
 
@@ -1,7 +1,59 @@
 package models
 
+import "encoding/json"
+
+// Statuses of a file result: the tool ran and found nothing (ok) or
+// findings (issues), it failed (tool_error), the file is not one the
+// analyzer handles (unsupported), or the analysis did not finish in time.
+const (
+	StatusOK          = "ok"
+	StatusIssues      = "issues"
+	StatusToolError   = "tool_error"
+	StatusUnsupported = "unsupported"
+	StatusTimeout     = "timeout"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
 type AnalyzeRequest struct {
-	Files []FileInput `json:"files"`
+	Files   []FileInput     `json:"files"`
+	Options *AnalyzeOptions `json:"options,omitempty"`
+	Schemas []SchemaMapping `json:"schemas,omitempty"`
+	Lint    *LintOptions    `json:"lint,omitempty"`
+}
+
+// LintOptions toggles the lint checks run on syntactically valid documents.
+// Rules maps rule IDs (duplicate-key, max-depth, number-precision,
+// trailing-whitespace, mixed-indentation, final-newline) to whether they run,
+// overriding the defaults; MaxDepth sets the limit of the max-depth check.
+type LintOptions struct {
+	Rules    map[string]bool `json:"rules,omitempty"`
+	MaxDepth int             `json:"max_depth,omitempty"`
+}
+
+// SchemaMapping validates the files matching one of the Files patterns
+// (e.g. "config/*.json"; a pattern without a slash matches the base name,
+// no patterns match every file) against a JSON Schema, given either inline
+// in Schema or as Ref, the path of another file of the request. The first
+// matching mapping wins over the document's own $schema key.
+type SchemaMapping struct {
+	Files  []string        `json:"files,omitempty"`
+	Schema json.RawMessage `json:"schema,omitempty"`
+	Ref    string          `json:"ref,omitempty"`
+}
+
+// AnalyzeOptions tunes which findings are reported. EnableRules restricts the
+// report to the listed rules, DisableRules drops rules, MinSeverity drops
+// findings below the given severity and MaxFindings caps findings per file.
+type AnalyzeOptions struct {
+	EnableRules  []string `json:"enable_rules,omitempty"`
+	DisableRules []string `json:"disable_rules,omitempty"`
+	MinSeverity  string   `json:"min_severity,omitempty"`
+	MaxFindings  int      `json:"max_findings,omitempty"`
 }
 
 type FileInput struct {
@@ -14,13 +66,34 @@ type AnalyzeResponse struct {
 }
 
 type FileResult struct {
-	Path         string       `json:"path"`
-	Comment      string       `json:"comment"`
+	Path         string        `json:"path"`
+	Status       string        `json:"status,omitempty"`
+	Comment      string        `json:"comment"`
+	Stderr       string        `json:"stderr,omitempty"`
 	LineComments []LineComment `json:"line_comments"`
 }
 
 type LineComment struct {
-	Line    int    `json:"line"`
-	Comment string `json:"comment"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+	EndColumn int    `json:"end_column,omitempty"`
+	RuleID    string `json:"rule_id,omitempty"`
+	Severity  string `json:"severity,omitempty"`
+	Tool      string `json:"tool,omitempty"`
+	Comment   string `json:"comment"`
+	// Snippet shows the source line of the finding with a caret under the
+	// reported column.
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// CacheStats describes the result cache of an analyzer service.
+type CacheStats struct {
+	Enabled    bool   `json:"enabled"`
+	Persistent bool   `json:"persistent"`
+	Entries    int    `json:"entries"`
+	Capacity   int    `json:"capacity"`
+	Hits       uint64 `json:"hits"`
+	Misses     uint64 `json:"misses"`
 }
 
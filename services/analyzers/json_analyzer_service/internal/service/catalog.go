@@ -0,0 +1,155 @@
+package service
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"strings"
+)
+
+// embeddedCatalog holds the schemas of well-known configuration files. They
+// are compiled into the binary because the analyzers run without network
+// access.
+//
+//go:embed catalog/*.json
+var embeddedCatalog embed.FS
+
+// catalogIndex is the catalog.json index of a catalog directory. It follows
+// the shape of the SchemaStore catalog, except that schema names a file of
+// the directory instead of a URL.
+type catalogIndex struct {
+	Schemas []catalogEntry `json:"schemas"`
+}
+
+type catalogEntry struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	FileMatch   []string `json:"fileMatch"`
+	URL         []string `json:"url,omitempty"`
+	Schema      string   `json:"schema"`
+
+	schema *documentSchema
+}
+
+// schemaCatalog picks a schema for documents by file name, or by the URL in
+// their $schema key. Its schemas are compiled once when it is loaded and are
+// shared by all requests.
+type schemaCatalog struct {
+	entries []catalogEntry
+}
+
+// catalogFromEnv loads the embedded catalog. Entries of the catalog mounted
+// at SCHEMA_CATALOG_DIR (a directory with its own catalog.json) come first,
+// so they can replace built-in schemas for the same files.
+func catalogFromEnv() *schemaCatalog {
+	catalog := &schemaCatalog{}
+	if dir := os.Getenv("SCHEMA_CATALOG_DIR"); dir != "" {
+		entries, err := loadCatalog(os.DirFS(dir), ".")
+		if err != nil {
+			log.Printf("Ignoring schema catalog in %s: %v", dir, err)
+		}
+		catalog.entries = append(catalog.entries, entries...)
+	}
+
+	entries, err := loadCatalog(embeddedCatalog, "catalog")
+	if err != nil {
+		log.Printf("Failed to load the embedded schema catalog: %v", err)
+	}
+	catalog.entries = append(catalog.entries, entries...)
+	return catalog
+}
+
+// loadCatalog reads the catalog.json index in dir and compiles the schemas it
+// lists. Entries whose schema cannot be read or compiled are skipped.
+func loadCatalog(fsys fs.FS, dir string) ([]catalogEntry, error) {
+	data, err := fs.ReadFile(fsys, path.Join(dir, "catalog.json"))
+	if err != nil {
+		return nil, err
+	}
+	var index catalogIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("invalid catalog.json: %w", err)
+	}
+
+	// Schemas may refer to each other by URL, so all of them are read before
+	// any is compiled.
+	read := &schemaCatalog{}
+	for _, entry := range index.Schemas {
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Schema))
+		if err != nil {
+			log.Printf("Skipping schema %q: %v", entry.Name, err)
+			continue
+		}
+		id := fileSchemaID(entry.Schema)
+		if len(entry.URL) > 0 {
+			id = entry.URL[0]
+		}
+		entry.schema = &documentSchema{source: "catalog " + entry.Name, id: id, data: data}
+		read.entries = append(read.entries, entry)
+	}
+
+	var entries []catalogEntry
+	for _, entry := range read.entries {
+		schema := entry.schema
+		schema.resolveRefs(schemaSources{catalog: read})
+		schema.compile()
+		if schema.problem != "" {
+			log.Printf("Skipping schema %q: %s", entry.Name, schema.problem)
+			continue
+		}
+		if schema.compileErr != nil {
+			log.Printf("Skipping schema %q: %v", entry.Name, schema.compileErr)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// forPath returns the schema of the first entry whose file patterns match
+// filePath, or nil. Patterns without a slash match the base name.
+func (c *schemaCatalog) forPath(filePath string) *documentSchema {
+	if c == nil {
+		return nil
+	}
+	filePath = cleanPath(filePath)
+	for _, entry := range c.entries {
+		for _, pattern := range entry.FileMatch {
+			name := filePath
+			if !strings.Contains(pattern, "/") {
+				name = path.Base(filePath)
+			}
+			if matched, _ := path.Match(pattern, name); matched {
+				return entry.schema
+			}
+		}
+	}
+	return nil
+}
+
+// forURL returns the schema published at url, or nil. http and https URLs
+// are treated alike, as are URLs with and without an empty fragment.
+func (c *schemaCatalog) forURL(url string) *documentSchema {
+	if c == nil {
+		return nil
+	}
+	url = normalizeSchemaURL(url)
+	for _, entry := range c.entries {
+		for _, known := range entry.URL {
+			if normalizeSchemaURL(known) == url {
+				return entry.schema
+			}
+		}
+	}
+	return nil
+}
+
+func normalizeSchemaURL(url string) string {
+	url = strings.TrimSuffix(url, "#")
+	url = strings.TrimPrefix(url, "https://")
+	return strings.TrimPrefix(url, "http://")
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"json_analyzer_service/internal/models"
+)
+
+func TestEmbeddedCatalog(t *testing.T) {
+	index, err := loadCatalog(embeddedCatalog, "catalog")
+	if err != nil {
+		t.Fatalf("loadCatalog() error = %v", err)
+	}
+	if len(index) != 5 {
+		t.Errorf("loadCatalog() loaded %d schemas, want all 5 embedded ones", len(index))
+	}
+
+	catalog := &schemaCatalog{entries: index}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "package.json", want: "catalog package.json"},
+		{path: "web/package.json", want: "catalog package.json"},
+		{path: "tsconfig.build.json", want: "catalog tsconfig.json"},
+		{path: ".eslintrc.json", want: "catalog .eslintrc.json"},
+		{path: "composer.json", want: "catalog composer.json"},
+		{path: "src/Api/appsettings.Development.json", want: "catalog appsettings.json"},
+		{path: "data.json", want: ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if schema := catalog.forPath(tt.path); schema != nil {
+			got = schema.source
+		}
+		if got != tt.want {
+			t.Errorf("forPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	if schema := catalog.forURL("http://json.schemastore.org/package.json#"); schema == nil || schema.source != "catalog package.json" {
+		t.Errorf("forURL() = %v, want the package.json schema", schema)
+	}
+}
+
+func TestJSONAnalyzerService_CatalogSchemas(t *testing.T) {
+	service := NewJSONAnalyzerService()
+
+	resp, err := service.Analyze(context.Background(), &models.AnalyzeRequest{
+		Files: []models.FileInput{
+			{Path: "package.json", Content: "{\n  \"name\": \"My Package\",\n  \"private\": \"yes\"\n}\n"},
+			{Path: "appsettings.json", Content: `{"Logging": {"LogLevel": {"Default": "Information"}}}`},
+			{Path: "config/app.json", Content: `{"$schema": "https://json.schemastore.org/package.json", "version": 1}`},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	pkg := resp.Files[0].LineComments
+	if len(pkg) != 2 || pkg[0].Line != 2 || pkg[1].Line != 3 {
+		t.Errorf("package.json findings = %+v, want the name and private members", pkg)
+	}
+	if resp.Files[1].Comment != "OK" {
+		t.Errorf("appsettings.json = %+v, want OK", resp.Files[1])
+	}
+	if got := resp.Files[2].LineComments; len(got) != 1 || got[0].RuleID != "invalid_type" {
+		t.Errorf("config/app.json findings = %+v, want the version type error from the $schema URL", got)
+	}
+}
+
+func TestCatalogFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"catalog.json": `{"schemas": [
+			{"name": "custom package", "fileMatch": ["package.json"], "schema": "custom.json"},
+			{"name": "broken", "fileMatch": ["broken.json"], "schema": "missing.json"},
+			{"name": "remote", "fileMatch": ["remote.json"], "schema": "remote.json"}
+		]}`,
+		"custom.json": `{"required": ["owner"]}`,
+		"remote.json": `{"$ref": "file:///etc/passwd"}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("SCHEMA_CATALOG_DIR", dir)
+
+	catalog := catalogFromEnv()
+	if schema := catalog.forPath("package.json"); schema == nil || schema.source != "catalog custom package" {
+		t.Errorf("forPath(package.json) = %v, want the mounted schema to take precedence", schema)
+	}
+	if schema := catalog.forPath("broken.json"); schema != nil {
+		t.Errorf("forPath(broken.json) = %v, want entries with missing schemas skipped", schema)
+	}
+	if schema := catalog.forPath("remote.json"); schema != nil {
+		t.Errorf("forPath(remote.json) = %v, want entries with external references skipped", schema)
+	}
+	if schema := catalog.forPath("tsconfig.json"); schema == nil {
+		t.Error("forPath(tsconfig.json) = nil, want the embedded schemas to remain available")
+	}
+}
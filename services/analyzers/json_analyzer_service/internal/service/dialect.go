@@ -0,0 +1,410 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// dialect is the JSON flavour a document is parsed with.
+type dialect int
+
+const (
+	// dialectJSON is strict RFC 8259 JSON.
+	dialectJSON dialect = iota
+	// dialectJSONC adds // and /* */ comments and trailing commas, as read
+	// by VS Code and the TypeScript compiler.
+	dialectJSONC
+	// dialectJSON5 adds to JSONC single-quoted strings, unquoted keys and the
+	// number forms of ECMAScript 5 (hex, leading or trailing decimal point,
+	// explicit plus sign, Infinity and NaN).
+	dialectJSON5
+)
+
+func (d dialect) String() string {
+	switch d {
+	case dialectJSONC:
+		return "JSONC"
+	case dialectJSON5:
+		return "JSON5"
+	default:
+		return "JSON"
+	}
+}
+
+func (d dialect) comments() bool {
+	return d != dialectJSON
+}
+
+func (d dialect) trailingCommas() bool {
+	return d != dialectJSON
+}
+
+// commentTolerantFiles lists .json files that their tools read as JSONC or
+// JSON5 rather than strict JSON. Patterns without a slash match the base name.
+var commentTolerantFiles = []struct {
+	pattern string
+	dialect dialect
+}{
+	{"tsconfig.json", dialectJSONC},
+	{"tsconfig.*.json", dialectJSONC},
+	{"jsconfig.json", dialectJSONC},
+	{"jsconfig.*.json", dialectJSONC},
+	{".eslintrc.json", dialectJSONC},
+	{"devcontainer.json", dialectJSONC},
+	{".devcontainer.json", dialectJSONC},
+	{"deno.json", dialectJSONC},
+	{"api-extractor.json", dialectJSONC},
+	{"typedoc.json", dialectJSONC},
+	{".vscode/*.json", dialectJSONC},
+	{"babel.config.json", dialectJSON5},
+	{".babelrc.json", dialectJSON5},
+}
+
+// isJSONFile reports whether the analyzer handles the file.
+func isJSONFile(filePath string) bool {
+	switch strings.ToLower(path.Ext(filePath)) {
+	case ".json", ".jsonc", ".json5":
+		return true
+	}
+	return false
+}
+
+// dialectFor picks the dialect of a file from its extension or, for .json
+// files, from the well-known names of comment-tolerant configuration files.
+func dialectFor(filePath string) dialect {
+	filePath = cleanPath(filePath)
+	switch strings.ToLower(path.Ext(filePath)) {
+	case ".jsonc":
+		return dialectJSONC
+	case ".json5":
+		return dialectJSON5
+	}
+
+	for _, known := range commentTolerantFiles {
+		if strings.Contains(known.pattern, "/") {
+			// Directory patterns match at any depth, e.g. web/.vscode/settings.json.
+			dir, base := path.Split(known.pattern)
+			if strings.HasSuffix("/"+path.Dir(filePath)+"/", "/"+dir) {
+				if matched, _ := path.Match(base, path.Base(filePath)); matched {
+					return known.dialect
+				}
+			}
+			continue
+		}
+		if matched, _ := path.Match(known.pattern, path.Base(filePath)); matched {
+			return known.dialect
+		}
+	}
+	return dialectJSON
+}
+
+// skipBOM steps over a byte order mark, which the JSONC and JSON5 readers
+// ignore.
+func (s *jsonScanner) skipBOM() {
+	if s.dialect != dialectJSON && bytes.HasPrefix(s.data, []byte("\ufeff")) {
+		s.pos = len("\ufeff")
+	}
+}
+
+// comment skips a comment starting at the current '/' and reports whether
+// there was one. An unterminated block comment runs to the end of input.
+func (s *jsonScanner) comment() bool {
+	if s.pos+1 >= len(s.data) {
+		return false
+	}
+	switch s.data[s.pos+1] {
+	case '/':
+		end := bytes.IndexByte(s.data[s.pos:], '\n')
+		if end < 0 {
+			s.pos = len(s.data)
+		} else {
+			s.advance(end)
+		}
+		return true
+	case '*':
+		end := bytes.Index(s.data[s.pos+2:], []byte("*/"))
+		if end < 0 {
+			s.fail(s.pos, "unterminated block comment")
+			s.pos = len(s.data)
+		} else {
+			s.advance(2 + end + 2)
+		}
+		return true
+	}
+	return false
+}
+
+// json5Space returns the length of the JSON5 white space at the start of
+// data besides the JSON one, or 0.
+func json5Space(data []byte) int {
+	if len(data) > 0 && (data[0] == '\v' || data[0] == '\f') {
+		return 1
+	}
+	r, size := utf8.DecodeRune(data)
+	switch {
+	case r == '\u00a0', r == '\ufeff', r == '\u1680', r == '\u2028', r == '\u2029',
+		r == '\u202f', r == '\u205f', r == '\u3000', r >= '\u2000' && r <= '\u200a':
+		return size
+	}
+	return 0
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= utf8.RuneSelf
+}
+
+func isIdentifierPart(c byte) bool {
+	return isIdentifierStart(c) || isDigit(c)
+}
+
+// identifier reads an unquoted JSON5 object key.
+func (s *jsonScanner) identifier() {
+	for !s.eof() && isIdentifierPart(s.data[s.pos]) {
+		if s.data[s.pos] >= utf8.RuneSelf {
+			if n := json5Space(s.data[s.pos:]); n > 0 {
+				return
+			}
+		}
+		s.advance(1)
+	}
+}
+
+// number5 reads a JSON5 number.
+func (s *jsonScanner) number5() bool {
+	if c := s.data[s.pos]; c == '+' || c == '-' {
+		s.advance(1)
+	}
+	if s.eof() {
+		s.failEOF()
+		return false
+	}
+
+	for _, word := range []string{"Infinity", "NaN"} {
+		if s.data[s.pos] == word[0] {
+			return s.literal(word)
+		}
+	}
+
+	if s.data[s.pos] == '0' && s.pos+1 < len(s.data) && (s.data[s.pos+1] == 'x' || s.data[s.pos+1] == 'X') {
+		s.advance(2)
+		start := s.pos
+		for !s.eof() && isHex(s.data[s.pos]) {
+			s.advance(1)
+		}
+		if s.pos == start {
+			if s.eof() {
+				s.failEOF()
+			} else {
+				s.fail(s.pos, "invalid character %s in hexadecimal numeric literal", quoteChar(s.data[s.pos]))
+			}
+			return false
+		}
+		return true
+	}
+
+	start := s.pos
+	s.digits()
+	intDigits := s.pos - start
+	if !s.eof() && s.data[s.pos] == '.' {
+		s.advance(1)
+		fracStart := s.pos
+		s.digits()
+		if intDigits == 0 && s.pos == fracStart {
+			if s.eof() {
+				s.failEOF()
+			} else {
+				s.fail(s.pos, "invalid character %s after decimal point in numeric literal", quoteChar(s.data[s.pos]))
+			}
+			return false
+		}
+	} else if intDigits == 0 {
+		if s.eof() {
+			s.failEOF()
+		} else {
+			s.fail(s.pos, "invalid character %s in numeric literal", quoteChar(s.data[s.pos]))
+		}
+		return false
+	}
+
+	if !s.eof() && (s.data[s.pos] == 'e' || s.data[s.pos] == 'E') {
+		s.advance(1)
+		if !s.eof() && (s.data[s.pos] == '+' || s.data[s.pos] == '-') {
+			s.advance(1)
+		}
+		if s.eof() {
+			s.failEOF()
+			return false
+		}
+		if !isDigit(s.data[s.pos]) {
+			s.fail(s.pos, "invalid character %s in exponent of numeric literal", quoteChar(s.data[s.pos]))
+			return false
+		}
+		s.digits()
+	}
+	return true
+}
+
+// decodeQuoted returns the value of a JSON or JSON5 string literal, or the
+// text itself for unquoted keys.
+func decodeQuoted(raw []byte) string {
+	if len(raw) < 2 || (raw[0] != '"' && raw[0] != '\'') {
+		return string(raw)
+	}
+	if raw[0] == '"' {
+		var value string
+		if json.Unmarshal(raw, &value) == nil {
+			return value
+		}
+	}
+
+	body := raw[1 : len(raw)-1]
+	var b strings.Builder
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if c != '\\' || i+1 == len(body) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch e := body[i]; e {
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'v':
+			b.WriteByte('\v')
+		case '0':
+			b.WriteByte(0)
+		case '\n':
+			// Line continuation.
+		case '\r':
+			if i+1 < len(body) && body[i+1] == '\n' {
+				i++
+			}
+		case 'x':
+			if i+3 <= len(body) {
+				if v, err := strconv.ParseUint(string(body[i+1:i+3]), 16, 8); err == nil {
+					b.WriteRune(rune(v))
+					i += 2
+				}
+			}
+		case 'u':
+			r, n := decodeUnicodeEscape(body[i-1:])
+			b.WriteRune(r)
+			i += n - 2
+		default:
+			b.WriteByte(e)
+		}
+	}
+	return b.String()
+}
+
+// decodeUnicodeEscape decodes a \uXXXX escape, joining surrogate pairs, and
+// returns the rune and the number of bytes consumed.
+func decodeUnicodeEscape(data []byte) (rune, int) {
+	hex := func(data []byte) (rune, bool) {
+		if len(data) < 6 || data[0] != '\\' || data[1] != 'u' {
+			return 0, false
+		}
+		v, err := strconv.ParseUint(string(data[2:6]), 16, 16)
+		return rune(v), err == nil
+	}
+
+	r, ok := hex(data)
+	if !ok {
+		return utf8.RuneError, 2
+	}
+	if utf16.IsSurrogate(r) {
+		if r2, ok := hex(data[6:]); ok {
+			if joined := utf16.DecodeRune(r, r2); joined != utf8.RuneError {
+				return joined, 12
+			}
+		}
+		return utf8.RuneError, 6
+	}
+	return r, 6
+}
+
+// nodeValue converts a parsed document into the values encoding/json would
+// produce for the equivalent strict JSON, so JSONC and JSON5 documents can be
+// validated against a schema. Numbers are kept as json.Number; NaN has no
+// JSON equivalent and becomes null.
+func nodeValue(data []byte, node *jsonNode) interface{} {
+	if node == nil {
+		return nil
+	}
+	switch node.kind {
+	case nodeObject:
+		object := make(map[string]interface{}, len(node.members))
+		for _, member := range node.members {
+			object[member.key] = nodeValue(data, member.value)
+		}
+		return object
+	case nodeArray:
+		array := make([]interface{}, 0, len(node.elements))
+		for _, element := range node.elements {
+			array = append(array, nodeValue(data, element))
+		}
+		return array
+	case nodeString:
+		return node.text
+	case nodeNumber:
+		if number := jsonNumber(string(data[node.start:node.end])); number != "" {
+			return number
+		}
+		return nil
+	default:
+		switch string(data[node.start:node.end]) {
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+		return nil
+	}
+}
+
+// jsonNumber rewrites a JSON5 number in strict JSON syntax. Infinity
+// becomes a number too large for a double; NaN yields "".
+func jsonNumber(text string) json.Number {
+	sign := ""
+	if strings.HasPrefix(text, "-") {
+		sign = "-"
+	}
+	text = strings.TrimLeft(text, "+-")
+
+	switch {
+	case text == "Infinity":
+		return json.Number(sign + "1e999")
+	case text == "NaN":
+		return ""
+	case strings.HasPrefix(text, "0x") || strings.HasPrefix(text, "0X"):
+		n, ok := new(big.Int).SetString(text[2:], 16)
+		if !ok {
+			return ""
+		}
+		return json.Number(sign + n.String())
+	}
+
+	if strings.HasPrefix(text, ".") {
+		text = "0" + text
+	}
+	text = strings.Replace(text, ".e", ".0e", 1)
+	text = strings.Replace(text, ".E", ".0E", 1)
+	if strings.HasSuffix(text, ".") {
+		text += "0"
+	}
+	return json.Number(sign + text)
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"json_analyzer_service/internal/models"
+)
+
+func TestDialectFor(t *testing.T) {
+	tests := []struct {
+		path string
+		want dialect
+	}{
+		{path: "data.json", want: dialectJSON},
+		{path: "settings.JSONC", want: dialectJSONC},
+		{path: "config/app.json5", want: dialectJSON5},
+		{path: "tsconfig.json", want: dialectJSONC},
+		{path: "web/tsconfig.app.json", want: dialectJSONC},
+		{path: ".vscode/settings.json", want: dialectJSONC},
+		{path: "web/.vscode/launch.json", want: dialectJSONC},
+		{path: "vscode/settings.json", want: dialectJSON},
+		{path: "babel.config.json", want: dialectJSON5},
+	}
+	for _, tt := range tests {
+		if got := dialectFor(tt.path); got != tt.want {
+			t.Errorf("dialectFor(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseDialect(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect dialect
+		input   string
+		want    string // the value as strict JSON
+	}{
+		{
+			name:    "jsonc comments and trailing commas",
+			dialect: dialectJSONC,
+			input:   "\ufeff// header\n{\n  /* block\n  comment */ \"a\": [1, 2,], // tail\n  \"b\": {\"c\": \"//\",},\n}\n",
+			want:    `{"a": [1, 2], "b": {"c": "//"}}`,
+		},
+		{
+			name:    "json5",
+			dialect: dialectJSON5,
+			input:   "{unquoted: 'single \\'q\\'', hex: 0x1F, lead: .5, trail: 5., plus: +1, nan: NaN, line: 'a\\\nb', esc: '\\x41\\u00e9',}",
+			want:    `{"unquoted": "single 'q'", "hex": 31, "lead": 0.5, "trail": 5.0, "plus": 1, "nan": null, "line": "ab", "esc": "A\u00e9"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := []byte(tt.input)
+			root, issues := parseDialect(data, tt.dialect)
+			if len(issues) != 0 {
+				t.Fatalf("parseDialect() issues = %v", issues)
+			}
+
+			got, err := json.Marshal(nodeValue(data, root))
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			var gotValue, wantValue interface{}
+			if err := json.Unmarshal(got, &gotValue); err != nil {
+				t.Fatalf("json.Unmarshal(%s) error = %v", got, err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &wantValue); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("nodeValue() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONNumber(t *testing.T) {
+	tests := map[string]json.Number{
+		"12":        "12",
+		"-0x1f":     "-31",
+		"+.5e3":     "0.5e3",
+		"5.":        "5.0",
+		"5.e1":      "5.0e1",
+		"-Infinity": "-1e999",
+		"NaN":       "",
+	}
+	for text, want := range tests {
+		if got := jsonNumber(text); got != want {
+			t.Errorf("jsonNumber(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestParseDialect_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect dialect
+		input   string
+		want    int // number of syntax errors
+	}{
+		{name: "comments in strict JSON", dialect: dialectJSON, input: `{"a": 1 /* no */}`, want: 1},
+		{name: "trailing comma in strict JSON", dialect: dialectJSON, input: `[1,]`, want: 1},
+		{name: "unterminated comment", dialect: dialectJSONC, input: `{"a": 1} /* open`, want: 1},
+		{name: "single quotes in JSONC", dialect: dialectJSONC, input: `{'a': 1}`, want: 1},
+		{name: "bad hex in JSON5", dialect: dialectJSON5, input: `[0xZ]`, want: 1},
+		{name: "raw newline in JSON5 string", dialect: dialectJSON5, input: "['a\nb']", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, issues := parseDialect([]byte(tt.input), tt.dialect); len(issues) != tt.want {
+				t.Errorf("parseDialect() issues = %v, want %d", issues, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONAnalyzerService_Dialects(t *testing.T) {
+	service := NewJSONAnalyzerService()
+
+	resp, err := service.Analyze(context.Background(), &models.AnalyzeRequest{
+		Files: []models.FileInput{
+			{Path: "tsconfig.json", Content: "{\n  // build settings\n  \"compilerOptions\": {\n    \"strict\": \"yes\", // wrong type\n  },\n}\n"},
+			{Path: ".vscode/settings.jsonc", Content: "{\"editor.tabSize\": 2, /* ok */}"},
+			{Path: "config.json5", Content: "{name: 'demo', }"},
+			{Path: "broken.jsonc", Content: "{\"a\": 1 \"b\": 2}"},
+			{Path: "strict.json", Content: "{\"a\": 1, // no\n}"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	tsconfig := resp.Files[0]
+	if len(tsconfig.LineComments) != 1 || tsconfig.LineComments[0].Line != 4 || tsconfig.LineComments[0].RuleID != "invalid_type" {
+		t.Errorf("tsconfig.json = %+v, want the catalog schema error on line 4", tsconfig)
+	}
+	for _, i := range []int{1, 2} {
+		if resp.Files[i].Comment != "OK" {
+			t.Errorf("%s = %+v, want OK", resp.Files[i].Path, resp.Files[i])
+		}
+	}
+	if got := resp.Files[3]; got.Comment != "Invalid JSONC syntax" || got.LineComments[0].Tool != "jsonc" {
+		t.Errorf("broken.jsonc = %+v, want a JSONC syntax error", got)
+	}
+	if got := resp.Files[4]; got.Comment != "Invalid JSON syntax" {
+		t.Errorf("strict.json = %+v, want comments rejected in strict JSON", got)
+	}
+}
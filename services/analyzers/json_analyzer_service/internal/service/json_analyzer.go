@@ -1,99 +1,223 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/xeipuuv/gojsonschema"
 	"json_analyzer_service/internal/models"
 )
 
-type JSONAnalyzerService struct{}
+const defaultRequestTimeout = 2 * time.Minute
+
+// JSONAnalyzerService validates documents in-process, so only a per-request
+// deadline applies; there is no external tool to bound per file.
+type JSONAnalyzerService struct {
+	requestTimeout time.Duration
+	maxWorkers     int
+	cache          *resultCache
+	catalog        *schemaCatalog
+}
 
 func NewJSONAnalyzerService() *JSONAnalyzerService {
-	return &JSONAnalyzerService{}
+	return &JSONAnalyzerService{
+		requestTimeout: durationFromEnv("ANALYZER_REQUEST_TIMEOUT", defaultRequestTimeout),
+		maxWorkers:     intFromEnv("MAX_WORKERS", runtime.NumCPU()),
+		cache:          cacheFromEnv(),
+		catalog:        catalogFromEnv(),
+	}
+}
+
+// durationFromEnv parses a Go duration (e.g. "30s") from the environment,
+// falling back to the default when the variable is unset or invalid.
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
 }
 
-func (s *JSONAnalyzerService) Analyze(req *models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
-	var results []models.FileResult
+// intFromEnv parses a positive integer from the environment, falling back to
+// the default when the variable is unset or invalid.
+func intFromEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func (s *JSONAnalyzerService) Analyze(ctx context.Context, req *models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
+	if err := validateOptions(req.Options); err != nil {
+		return nil, err
+	}
+	if err := validateLint(req.Lint); err != nil {
+		return nil, err
+	}
+	mapped, err := validateSchemas(req, s.catalog)
+	if err != nil {
+		return nil, err
+	}
+	lint := newLintConfig(req.Lint)
+
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	results := make([]models.FileResult, len(req.Files))
+	var pending []int
 
-	for _, file := range req.Files {
-		if !strings.HasSuffix(strings.ToLower(file.Path), ".json") {
-			results = append(results, models.FileResult{
+	for i, file := range req.Files {
+		if !isJSONFile(file.Path) {
+			results[i] = models.FileResult{
 				Path:         file.Path,
+				Status:       models.StatusUnsupported,
 				Comment:      "Not a JSON file",
 				LineComments: []models.LineComment{},
-			})
+			}
 			continue
 		}
+		pending = append(pending, i)
+	}
+
+	schemas := resolveSchemas(req, mapped, pending, s.catalog)
+	keys := s.cacheKeys(req, pending, schemas)
+	pending = s.cache.lookup(keys, req.Files, results, pending)
+	compileSchemas(schemas, pending)
 
-		result := s.analyzeFile(file.Path, file.Content)
-		results = append(results, result)
+	forEach(len(pending), s.maxWorkers, func(j int) {
+		i := pending[j]
+		file := req.Files[i]
+		if ctx.Err() != nil {
+			results[i] = models.FileResult{
+				Path:         file.Path,
+				Status:       models.StatusTimeout,
+				Comment:      "Analysis timed out",
+				LineComments: []models.LineComment{},
+			}
+			return
+		}
+
+		results[i] = s.analyzeFile(file.Path, file.Content, schemas[i], lint)
+	})
+
+	for i := range results {
+		results[i] = applyOptions(results[i], req.Options)
 	}
+	s.cache.store(keys, results, pending)
 
 	return &models.AnalyzeResponse{Files: results}, nil
 }
 
-func (s *JSONAnalyzerService) analyzeFile(path, content string) models.FileResult {
-	// First, validate JSON syntax
-	var jsonData interface{}
-	if err := json.Unmarshal([]byte(content), &jsonData); err != nil {
-		line := s.getErrorLine(content, err.Error())
-		return models.FileResult{
-			Path:    path,
-			Comment: "Invalid JSON syntax",
-			LineComments: []models.LineComment{
-				{
-					Line:    line,
-					Comment: err.Error(),
-				},
-			},
+// analyzeFile checks the syntax of a document in its dialect, then runs the
+// lint checks and validates it against its schema, if it has one.
+func (s *JSONAnalyzerService) analyzeFile(path, content string, schema *documentSchema, lint lintConfig) models.FileResult {
+	data := []byte(content)
+	dialect := dialectFor(path)
+
+	var root *jsonNode
+	var document gojsonschema.JSONLoader
+	if dialect == dialectJSON {
+		// First, validate JSON syntax. Numbers are not decoded, so one out of
+		// the range of a float64 is left to the number-precision check.
+		var raw json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return models.FileResult{
+				Path:         path,
+				Status:       models.StatusIssues,
+				Comment:      "Invalid JSON syntax",
+				LineComments: syntaxComments(data, err),
+			}
+		}
+		root, _ = parseJSON(data)
+		document = gojsonschema.NewBytesLoader(data)
+	} else {
+		var issues []syntaxIssue
+		root, issues = parseDialect(data, dialect)
+		if len(issues) > 0 {
+			lineComments := make([]models.LineComment, 0, len(issues))
+			for _, issue := range issues {
+				lineComments = append(lineComments, syntaxComment(data, issue.offset, issue.message, strings.ToLower(dialect.String())))
+			}
+			return models.FileResult{
+				Path:         path,
+				Status:       models.StatusIssues,
+				Comment:      fmt.Sprintf("Invalid %s syntax", dialect),
+				LineComments: lineComments,
+			}
 		}
+		// Validate the value the document stands for, as strict JSON.
+		document = gojsonschema.NewGoLoader(nodeValue(data, root))
 	}
 
-	// If schema validation is needed, it can be added here
-	// For now, we just validate syntax
-	schemaLoader := gojsonschema.NewStringLoader(`{}`)
-	documentLoader := gojsonschema.NewStringLoader(content)
+	lineComments := lintDocument(data, root, lint)
+	comment := "JSON lint issues found"
+	if schema != nil {
+		if schemaComments := validateSchema(data, root, document, schema); len(schemaComments) > 0 {
+			lineComments = append(lineComments, schemaComments...)
+			comment = "JSON validation issues found"
+		}
+	}
 
-	result, err := gojsonschema.Validate(schemaLoader, documentLoader)
-	if err != nil {
+	if len(lineComments) == 0 {
 		return models.FileResult{
 			Path:         path,
+			Status:       models.StatusOK,
 			Comment:      "OK",
 			LineComments: []models.LineComment{},
 		}
 	}
+	sortLineComments(lineComments)
+	return models.FileResult{
+		Path:         path,
+		Status:       models.StatusIssues,
+		Comment:      comment,
+		LineComments: lineComments,
+	}
+}
 
-	if !result.Valid() {
-		var lineComments []models.LineComment
-		for _, desc := range result.Errors() {
-			lineComments = append(lineComments, models.LineComment{
-				Line:    1, // gojsonschema doesn't provide line numbers directly
-				Comment: desc.String(),
-			})
-		}
-		return models.FileResult{
-			Path:         path,
-			Comment:      "JSON validation issues found",
-			LineComments: lineComments,
+// syntaxComments locates the encoding/json error and adds the further
+// syntax errors found by scanning past it.
+func syntaxComments(data []byte, err error) []models.LineComment {
+	var comments []models.LineComment
+	first := -1
+	if offset, ok := errorOffset(data, err); ok {
+		first = offset
+		comments = append(comments, syntaxComment(data, offset, err.Error(), "encoding/json"))
+	}
+	for _, issue := range scanSyntaxErrors(data) {
+		if issue.offset > first {
+			comments = append(comments, syntaxComment(data, issue.offset, issue.message, "encoding/json"))
 		}
 	}
-
-	return models.FileResult{
-		Path:         path,
-		Comment:      "OK",
-		LineComments: []models.LineComment{},
+	if len(comments) == 0 {
+		comments = append(comments, models.LineComment{
+			Line:     1,
+			RuleID:   "syntax-error",
+			Severity: models.SeverityError,
+			Tool:     "encoding/json",
+			Comment:  err.Error(),
+		})
 	}
+	return comments
 }
 
-func (s *JSONAnalyzerService) getErrorLine(content, errorMsg string) int {
-	// Simple heuristic to find line number from error
-	// JSON unmarshal errors typically include position info
-	lines := strings.Split(content, "\n")
-	if len(lines) > 0 {
-		return len(lines)
+func syntaxComment(data []byte, offset int, message, tool string) models.LineComment {
+	line, column := position(data, offset)
+	return models.LineComment{
+		Line:     line,
+		Column:   column,
+		RuleID:   "syntax-error",
+		Severity: models.SeverityError,
+		Tool:     tool,
+		Comment:  message,
+		Snippet:  snippet(data, offset),
 	}
-	return 1
 }
 
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"strings"
 	"testing"
 
 	"json_analyzer_service/internal/models"
@@ -41,15 +44,28 @@ func TestJSONAnalyzerService_Analyze(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "invalid options",
+			req: &models.AnalyzeRequest{
+				Files: []models.FileInput{
+					{Path: "test.json", Content: `{}`},
+				},
+				Options: &models.AnalyzeOptions{MinSeverity: "fatal"},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			resp, err := service.Analyze(tt.req)
+			resp, err := service.Analyze(context.Background(), tt.req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Analyze() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if tt.wantErr {
+				return
+			}
 			if resp == nil {
 				t.Error("Analyze() returned nil response")
 			}
@@ -57,3 +73,86 @@ func TestJSONAnalyzerService_Analyze(t *testing.T) {
 	}
 }
 
+func TestJSONAnalyzerService_SyntaxErrors(t *testing.T) {
+	service := NewJSONAnalyzerService()
+
+	content := "{\n  \"name\": \"demo\"\n  \"tags\": [1, 2,],\n  \"ok\": tru\n}\n"
+	result := service.analyzeFile("broken.json", content, nil, newLintConfig(nil))
+	if result.Comment != "Invalid JSON syntax" {
+		t.Fatalf("analyzeFile() comment = %q, want Invalid JSON syntax", result.Comment)
+	}
+
+	want := []struct{ line, column int }{{3, 3}, {3, 17}, {4, 12}}
+	if len(result.LineComments) != len(want) {
+		t.Fatalf("analyzeFile() returned %d line comments, want %d: %+v", len(result.LineComments), len(want), result.LineComments)
+	}
+	for i, w := range want {
+		got := result.LineComments[i]
+		if got.Line != w.line || got.Column != w.column {
+			t.Errorf("line comment %d at %d:%d, want %d:%d (%s)", i, got.Line, got.Column, w.line, w.column, got.Comment)
+		}
+		if got.RuleID != "syntax-error" || got.Snippet == "" {
+			t.Errorf("line comment %d = %+v, want a syntax-error with a snippet", i, got)
+		}
+	}
+	if want := "  \"tags\": [1, 2,],\n  ^"; result.LineComments[0].Snippet != want {
+		t.Errorf("snippet = %q, want %q", result.LineComments[0].Snippet, want)
+	}
+}
+
+func TestSyntaxErrorAtEndOfInput(t *testing.T) {
+	service := NewJSONAnalyzerService()
+
+	result := service.analyzeFile("truncated.json", "{\n  \"a\": [1,\n\n", nil, newLintConfig(nil))
+	if len(result.LineComments) != 1 {
+		t.Fatalf("analyzeFile() returned %d line comments, want 1: %+v", len(result.LineComments), result.LineComments)
+	}
+	if got := result.LineComments[0]; got.Line != 2 || got.Column != 11 {
+		t.Errorf("line comment at %d:%d, want 2:11 (just past the last value)", got.Line, got.Column)
+	}
+}
+
+func TestSyntaxErrorCap(t *testing.T) {
+	// Every control character is an error, so the cap is reached inside the
+	// string.
+	content := "[\"" + strings.Repeat("\x01", 28) + "\"]"
+
+	for _, d := range []dialect{dialectJSON, dialectJSONC, dialectJSON5} {
+		_, issues := parseDialect([]byte(content), d)
+		if d != dialectJSON5 && len(issues) != maxSyntaxErrors {
+			t.Errorf("parseDialect(%v) returned %d issues, want %d", d, len(issues), maxSyntaxErrors)
+		}
+	}
+
+	// With a schema mapping the file is parsed in a worker goroutine, where a
+	// panic would take the service down.
+	service := NewJSONAnalyzerService()
+	resp, err := service.Analyze(context.Background(), &models.AnalyzeRequest{
+		Files:   []models.FileInput{{Path: "controls.json", Content: content}},
+		Schemas: []models.SchemaMapping{{Schema: json.RawMessage(`{"type": "array"}`)}},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if got := resp.Files[0]; got.Comment != "Invalid JSON syntax" || len(got.LineComments) != maxSyntaxErrors {
+		t.Errorf("Analyze() result = %q with %d line comments, want Invalid JSON syntax with %d", got.Comment, len(got.LineComments), maxSyntaxErrors)
+	}
+}
+
+func TestPositionAndSnippet(t *testing.T) {
+	data := []byte("{\n\t\"é\": x\n}")
+	offset := 9 // the 'x'
+
+	if line, column := position(data, offset); line != 2 || column != 7 {
+		t.Errorf("position() = %d:%d, want 2:7", line, column)
+	}
+	if got, want := snippet(data, offset), "\t\"é\": x\n\t     ^"; got != want {
+		t.Errorf("snippet() = %q, want %q", got, want)
+	}
+
+	long := []byte(`{"a":"` + strings.Repeat("x", 100) + `" 1}`)
+	if got := snippet(long, 107); !strings.HasPrefix(got, "...") || strings.HasSuffix(got, "...") {
+		t.Errorf("snippet() of a long line = %q, want it cut on the left only", got)
+	}
+}
+
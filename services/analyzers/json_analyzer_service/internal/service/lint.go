@@ -0,0 +1,248 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"json_analyzer_service/internal/models"
+)
+
+const (
+	ruleDuplicateKey       = "duplicate-key"
+	ruleMaxDepth           = "max-depth"
+	ruleNumberPrecision    = "number-precision"
+	ruleTrailingWhitespace = "trailing-whitespace"
+	ruleMixedIndentation   = "mixed-indentation"
+	ruleFinalNewline       = "final-newline"
+)
+
+// lintRules lists the lint checks and whether they run by default. The
+// checks for likely bugs are on; the style checks have to be enabled.
+var lintRules = map[string]bool{
+	ruleDuplicateKey:       true,
+	ruleMaxDepth:           true,
+	ruleNumberPrecision:    true,
+	ruleTrailingWhitespace: false,
+	ruleMixedIndentation:   false,
+	ruleFinalNewline:       false,
+}
+
+const defaultMaxDepth = 32
+
+// maxSignificantDigits is the most significant digits a float64 keeps.
+const maxSignificantDigits = 17
+
+// lintConfig is the effective set of lint checks for a request.
+type lintConfig struct {
+	enabled  map[string]bool
+	maxDepth int
+}
+
+func validateLint(opts *models.LintOptions) error {
+	if opts == nil {
+		return nil
+	}
+	for rule := range opts.Rules {
+		if _, ok := lintRules[rule]; !ok {
+			return fmt.Errorf("%w: unknown lint rule %q", ErrInvalidOptions, rule)
+		}
+	}
+	if opts.MaxDepth < 0 {
+		return fmt.Errorf("%w: lint max_depth must not be negative", ErrInvalidOptions)
+	}
+	return nil
+}
+
+func newLintConfig(opts *models.LintOptions) lintConfig {
+	config := lintConfig{
+		enabled:  make(map[string]bool, len(lintRules)),
+		maxDepth: defaultMaxDepth,
+	}
+	for rule, enabled := range lintRules {
+		config.enabled[rule] = enabled
+	}
+	if opts == nil {
+		return config
+	}
+	for rule, enabled := range opts.Rules {
+		config.enabled[rule] = enabled
+	}
+	if opts.MaxDepth > 0 {
+		config.maxDepth = opts.MaxDepth
+	}
+	return config
+}
+
+// lintDocument runs the enabled lint checks on a syntactically valid
+// document.
+func lintDocument(data []byte, root *jsonNode, config lintConfig) []models.LineComment {
+	l := &linter{data: data, config: config}
+	if root != nil {
+		l.walk(root, 1)
+	}
+	if config.enabled[ruleTrailingWhitespace] || config.enabled[ruleMixedIndentation] {
+		l.lines()
+	}
+	if config.enabled[ruleFinalNewline] && len(data) > 0 && data[len(data)-1] != '\n' {
+		l.report(len(data), len(data), ruleFinalNewline, models.SeverityInfo, "File does not end with a newline")
+	}
+	return l.findings
+}
+
+type linter struct {
+	data     []byte
+	config   lintConfig
+	findings []models.LineComment
+}
+
+func (l *linter) report(start, end int, ruleID, severity, message string) {
+	line, column := position(l.data, start)
+	endLine, endColumn := position(l.data, end)
+	l.findings = append(l.findings, models.LineComment{
+		Line:      line,
+		Column:    column,
+		EndLine:   endLine,
+		EndColumn: endColumn,
+		RuleID:    ruleID,
+		Severity:  severity,
+		Tool:      "json-lint",
+		Comment:   message,
+	})
+}
+
+// walk checks node and its descendants; depth counts the containers from
+// the root. Containers nested too deeply are reported once, without
+// descending further.
+func (l *linter) walk(node *jsonNode, depth int) {
+	switch node.kind {
+	case nodeObject, nodeArray:
+		if l.config.enabled[ruleMaxDepth] && depth > l.config.maxDepth {
+			l.report(node.start, node.start+1, ruleMaxDepth, models.SeverityWarning,
+				fmt.Sprintf("Nesting depth %d exceeds the maximum of %d", depth, l.config.maxDepth))
+			return
+		}
+	case nodeNumber:
+		if l.config.enabled[ruleNumberPrecision] {
+			if message := numberPrecisionProblem(string(l.data[node.start:node.end])); message != "" {
+				l.report(node.start, node.end, ruleNumberPrecision, models.SeverityWarning, message)
+			}
+		}
+		return
+	default:
+		return
+	}
+
+	if node.kind == nodeObject && l.config.enabled[ruleDuplicateKey] {
+		seen := make(map[string]int, len(node.members))
+		for _, member := range node.members {
+			if first, ok := seen[member.key]; ok {
+				firstLine, _ := position(l.data, first)
+				l.report(member.keyStart, member.value.end, ruleDuplicateKey, models.SeverityWarning,
+					fmt.Sprintf("Duplicate key %q (first defined on line %d); only the last value is used", member.key, firstLine))
+				continue
+			}
+			seen[member.key] = member.keyStart
+		}
+	}
+
+	for _, member := range node.members {
+		l.walk(member.value, depth+1)
+	}
+	for _, element := range node.elements {
+		l.walk(element, depth+1)
+	}
+}
+
+// numberPrecisionProblem reports numbers that change when decoded into a
+// float64, as JSON parsers in JavaScript and Go do by default.
+func numberPrecisionProblem(text string) string {
+	r, ok := new(big.Rat).SetString(text)
+	if !ok {
+		// big.Rat rejects huge exponents such as 1e9999999; strconv still
+		// tells whether the number overflows.
+		if f, err := strconv.ParseFloat(text, 64); err != nil && math.IsInf(f, 0) {
+			return fmt.Sprintf("Number %s is out of the range of a double-precision float", text)
+		}
+		return ""
+	}
+	f, exact := r.Float64()
+	switch {
+	case math.IsInf(f, 0):
+		return fmt.Sprintf("Number %s is out of the range of a double-precision float", text)
+	case r.IsInt() && !exact:
+		return fmt.Sprintf("Integer %s cannot be represented exactly and becomes %s when parsed", text, new(big.Float).SetFloat64(f).Text('f', 0))
+	case !r.IsInt() && significantDigits(text) > maxSignificantDigits:
+		return fmt.Sprintf("Number %s has more significant digits than a double-precision float keeps", text)
+	}
+	return ""
+}
+
+// significantDigits counts the digits of a JSON number's mantissa, leaving
+// out leading zeros.
+func significantDigits(text string) int {
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		text = text[:i]
+	}
+	digits := strings.TrimLeft(strings.NewReplacer("-", "", ".", "").Replace(text), "0")
+	return len(digits)
+}
+
+// lines runs the line-based style checks. The document is valid, so line
+// breaks only occur inside strings as JSON5 line continuations.
+func (l *linter) lines() {
+	indentChar := byte(0)
+	indentLine := 0
+	lineNumber := 0
+
+	for start := 0; start < len(l.data); {
+		lineNumber++
+		end := start
+		for end < len(l.data) && l.data[end] != '\n' {
+			end++
+		}
+		line := strings.TrimSuffix(string(l.data[start:end]), "\r")
+
+		if l.config.enabled[ruleTrailingWhitespace] {
+			if trimmed := strings.TrimRight(line, " \t"); len(trimmed) < len(line) {
+				l.report(start+len(trimmed), start+len(line), ruleTrailingWhitespace, models.SeverityInfo, "Trailing whitespace")
+			}
+		}
+
+		if l.config.enabled[ruleMixedIndentation] {
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			switch {
+			case indent == "" || len(indent) == len(line):
+			case strings.Contains(indent, " ") && strings.Contains(indent, "\t"):
+				l.report(start, start+len(indent), ruleMixedIndentation, models.SeverityInfo, "Indentation mixes tabs and spaces")
+			case indentChar == 0:
+				indentChar, indentLine = indent[0], lineNumber
+			case indent[0] != indentChar:
+				l.report(start, start+len(indent), ruleMixedIndentation, models.SeverityInfo,
+					fmt.Sprintf("Indentation uses %s, but line %d is indented with %s", indentName(indent[0]), indentLine, indentName(indentChar)))
+			}
+		}
+
+		start = end + 1
+	}
+}
+
+func indentName(c byte) string {
+	if c == '\t' {
+		return "tabs"
+	}
+	return "spaces"
+}
+
+// sortLineComments orders findings of different checks by position.
+func sortLineComments(lineComments []models.LineComment) {
+	sort.SliceStable(lineComments, func(i, j int) bool {
+		if lineComments[i].Line != lineComments[j].Line {
+			return lineComments[i].Line < lineComments[j].Line
+		}
+		return lineComments[i].Column < lineComments[j].Column
+	})
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"json_analyzer_service/internal/models"
+)
+
+func lintRuleIDs(lineComments []models.LineComment) []string {
+	var ids []string
+	for _, lineComment := range lineComments {
+		ids = append(ids, lineComment.RuleID)
+	}
+	return ids
+}
+
+func TestLintDocument_Defaults(t *testing.T) {
+	data := []byte("{\n  \"id\": 9007199254740993,\n  \"ratio\": 0.1,\n  \"pi\": 3.14159265358979323846,\n  \"big\": 1e400,\n  \"id\": 1,  \n  \"ok\": 9007199254740992\n}")
+	root, issues := parseJSON(data)
+	if len(issues) != 0 {
+		t.Fatalf("parseJSON() issues = %v", issues)
+	}
+
+	got := lintDocument(data, root, newLintConfig(nil))
+	sortLineComments(got)
+	want := []string{ruleNumberPrecision, ruleNumberPrecision, ruleNumberPrecision, ruleDuplicateKey}
+	if strings.Join(lintRuleIDs(got), ",") != strings.Join(want, ",") {
+		t.Fatalf("lintDocument() = %+v, want %v", got, want)
+	}
+
+	if got[0].Line != 2 || got[0].Column != 9 || !strings.Contains(got[0].Comment, "9007199254740992") {
+		t.Errorf("precision finding = %+v, want line 2 column 9 mentioning the parsed value", got[0])
+	}
+	duplicate := got[3]
+	if duplicate.Line != 6 || duplicate.Column != 3 || !strings.Contains(duplicate.Comment, "line 2") {
+		t.Errorf("duplicate-key finding = %+v, want line 6 column 3 pointing back to line 2", duplicate)
+	}
+}
+
+func TestNumberPrecisionProblem(t *testing.T) {
+	tests := []struct {
+		text string
+		want string // substring of the message, "" for none
+	}{
+		{text: "42"},
+		{text: "0.1"},
+		{text: "1e-400"},
+		{text: "9007199254740993", want: "becomes 9007199254740992"},
+		{text: "1e400", want: "out of the range"},
+		{text: "-1e9999999", want: "out of the range"},
+		{text: "Infinity"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got := numberPrecisionProblem(tt.text)
+			if (got == "") != (tt.want == "") || !strings.Contains(got, tt.want) {
+				t.Errorf("numberPrecisionProblem(%q) = %q, want a message containing %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONAnalyzerService_NumberOutOfRange(t *testing.T) {
+	service := NewJSONAnalyzerService()
+
+	result := service.analyzeFile("big.json", "{\"big\": 1e400, \"huge\": 1e9999999}\n", nil, newLintConfig(nil))
+	if got := lintRuleIDs(result.LineComments); strings.Join(got, ",") != ruleNumberPrecision+","+ruleNumberPrecision {
+		t.Fatalf("analyzeFile() = %+v, want two number-precision findings, not a syntax error", result)
+	}
+	if result.LineComments[0].Column != 9 || result.LineComments[1].Column != 24 {
+		t.Errorf("findings at columns %d and %d, want 9 and 24", result.LineComments[0].Column, result.LineComments[1].Column)
+	}
+}
+
+func TestLintDocument_Depth(t *testing.T) {
+	data := []byte(`{"a": [[[{"b": [1]}]]], "c": [[[]]]}`)
+	root, _ := parseJSON(data)
+
+	got := lintDocument(data, root, newLintConfig(&models.LintOptions{MaxDepth: 3}))
+	if len(got) != 2 || got[0].RuleID != ruleMaxDepth || got[0].Column != 9 || got[1].Column != 32 {
+		t.Errorf("lintDocument() = %+v, want one max-depth finding per branch", got)
+	}
+
+	got = lintDocument(data, root, newLintConfig(&models.LintOptions{MaxDepth: 3, Rules: map[string]bool{ruleMaxDepth: false}}))
+	if len(got) != 0 {
+		t.Errorf("lintDocument() with max-depth disabled = %+v, want none", got)
+	}
+}
+
+func TestLintDocument_Style(t *testing.T) {
+	data := []byte("{\n  \"a\": 1, \n\t\"b\": 2,\n \t\"c\": 3\n}")
+	root, _ := parseJSON(data)
+
+	options := &models.LintOptions{Rules: map[string]bool{
+		ruleTrailingWhitespace: true,
+		ruleMixedIndentation:   true,
+		ruleFinalNewline:       true,
+	}}
+	got := lintDocument(data, root, newLintConfig(options))
+	sortLineComments(got)
+
+	want := []struct {
+		ruleID       string
+		line, column int
+	}{
+		{ruleTrailingWhitespace, 2, 10},
+		{ruleMixedIndentation, 3, 1},
+		{ruleMixedIndentation, 4, 1},
+		{ruleFinalNewline, 5, 2},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("lintDocument() = %+v, want %d findings", got, len(want))
+	}
+	for i, w := range want {
+		if got[i].RuleID != w.ruleID || got[i].Line != w.line || got[i].Column != w.column {
+			t.Errorf("finding %d = %s at %d:%d, want %s at %d:%d", i, got[i].RuleID, got[i].Line, got[i].Column, w.ruleID, w.line, w.column)
+		}
+	}
+
+	if got := lintDocument(data, root, newLintConfig(nil)); len(got) != 0 {
+		t.Errorf("lintDocument() with default rules = %+v, want no style findings", got)
+	}
+}
+
+func TestJSONAnalyzerService_LintOptions(t *testing.T) {
+	service := NewJSONAnalyzerService()
+
+	resp, err := service.Analyze(context.Background(), &models.AnalyzeRequest{
+		Files: []models.FileInput{{Path: "a.json", Content: `{"x": 1, "x": 2}`}},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if got := resp.Files[0]; got.Comment != "JSON lint issues found" || len(got.LineComments) != 1 {
+		t.Errorf("Analyze() = %+v, want a duplicate-key finding", got)
+	}
+
+	_, err = service.Analyze(context.Background(), &models.AnalyzeRequest{
+		Files: []models.FileInput{{Path: "a.json", Content: `{}`}},
+		Lint:  &models.LintOptions{Rules: map[string]bool{"no-such-rule": true}},
+	})
+	if !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("Analyze() with an unknown lint rule error = %v, want ErrInvalidOptions", err)
+	}
+}
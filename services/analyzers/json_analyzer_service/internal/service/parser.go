@@ -0,0 +1,639 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSyntaxErrors caps the syntax errors reported per file; past that point
+// the errors are mostly consequences of the earlier ones.
+const maxSyntaxErrors = 20
+
+// syntaxIssue is a syntax error at a byte offset of the document.
+type syntaxIssue struct {
+	offset  int
+	message string
+}
+
+type nodeKind int
+
+const (
+	nodeObject nodeKind = iota
+	nodeArray
+	nodeString
+	nodeNumber
+	nodeLiteral
+)
+
+// jsonNode is a parsed value with the byte range it spans in the document,
+// so findings about a value can be reported at its position.
+type jsonNode struct {
+	kind     nodeKind
+	start    int
+	end      int
+	members  []jsonMember // objects, in document order
+	elements []*jsonNode  // arrays
+	text     string       // decoded value of strings
+}
+
+type jsonMember struct {
+	key      string
+	keyStart int
+	value    *jsonNode
+}
+
+// jsonScanner is a tolerant JSON parser that keeps going after a syntax
+// error, so several problems can be reported in one pass. Its messages follow
+// encoding/json. After an error inside an object or array it skips to the next
+// ',' or closing bracket at the same nesting level and continues from there.
+// The dialect enables the JSONC and JSON5 extensions.
+type jsonScanner struct {
+	data    []byte
+	pos     int
+	issues  []syntaxIssue
+	dialect dialect
+
+	root     *jsonNode
+	parent   *jsonNode // container whose members or elements are being read
+	key      string    // key of the member whose value comes next
+	keyStart int
+}
+
+// parseJSON parses data into a tree of positioned values and returns the
+// syntax errors in document order. The tree is complete only when there are
+// no errors.
+func parseJSON(data []byte) (*jsonNode, []syntaxIssue) {
+	return parseDialect(data, dialectJSON)
+}
+
+// parseDialect is parseJSON for documents of the given dialect.
+func parseDialect(data []byte, d dialect) (*jsonNode, []syntaxIssue) {
+	s := &jsonScanner{data: data, dialect: d}
+	s.skipBOM()
+	if !s.value() {
+		return s.root, s.issues
+	}
+	s.skipSpace()
+	if !s.eof() {
+		s.fail(s.pos, "invalid character %s after top-level value", quoteChar(s.data[s.pos]))
+	}
+	return s.root, s.issues
+}
+
+// scanSyntaxErrors returns the syntax errors of data in document order.
+func scanSyntaxErrors(data []byte) []syntaxIssue {
+	_, issues := parseJSON(data)
+	return issues
+}
+
+// fail records a syntax error. The error that reaches maxSyntaxErrors stops
+// the scan: the position jumps to the end of the input, where every loop of
+// the scanner ends.
+func (s *jsonScanner) fail(offset int, format string, args ...interface{}) {
+	if len(s.issues) >= maxSyntaxErrors {
+		return
+	}
+	s.issues = append(s.issues, syntaxIssue{offset: offset, message: fmt.Sprintf(format, args...)})
+	if len(s.issues) == maxSyntaxErrors {
+		s.pos = len(s.data)
+	}
+}
+
+// advance moves the position n bytes forward, never past the end of the
+// input: callers step over what they have just read without checking
+// whether fail stopped the scan meanwhile, and values are sliced up to the
+// position.
+func (s *jsonScanner) advance(n int) {
+	s.pos += n
+	if s.pos > len(s.data) {
+		s.pos = len(s.data)
+	}
+}
+
+func (s *jsonScanner) failEOF() {
+	s.fail(endOffset(s.data), "unexpected end of JSON input")
+}
+
+// endOffset is where an unexpected end of input is reported: just past the
+// last significant character, rather than after trailing blank lines.
+func endOffset(data []byte) int {
+	return len(strings.TrimRight(string(data), " \t\r\n"))
+}
+
+func (s *jsonScanner) eof() bool {
+	return s.pos >= len(s.data)
+}
+
+func (s *jsonScanner) skipSpace() {
+	for !s.eof() {
+		switch c := s.data[s.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			s.advance(1)
+		case c == '/' && s.dialect.comments():
+			if !s.comment() {
+				return
+			}
+		case s.dialect == dialectJSON5:
+			if n := json5Space(s.data[s.pos:]); n > 0 {
+				s.advance(n)
+				continue
+			}
+			return
+		default:
+			return
+		}
+	}
+}
+
+// value parses one value and reports whether it was well-formed.
+func (s *jsonScanner) value() bool {
+	s.skipSpace()
+	if s.eof() {
+		s.failEOF()
+		return false
+	}
+
+	node := &jsonNode{start: s.pos}
+	var ok bool
+	switch c := s.data[s.pos]; {
+	case c == '{':
+		node.kind = nodeObject
+		s.attach(node)
+		parent := s.parent
+		s.parent = node
+		ok = s.object()
+		s.parent = parent
+	case c == '[':
+		node.kind = nodeArray
+		s.attach(node)
+		parent := s.parent
+		s.parent = node
+		ok = s.array()
+		s.parent = parent
+	case c == '"' || (c == '\'' && s.dialect == dialectJSON5):
+		node.kind = nodeString
+		s.attach(node)
+		ok = s.str()
+		node.text = decodeQuoted(s.data[node.start:s.pos])
+	case c == '-' || isDigit(c):
+		node.kind = nodeNumber
+		s.attach(node)
+		ok = s.number()
+	case s.dialect == dialectJSON5 && (c == '+' || c == '.' || c == 'I' || c == 'N'):
+		node.kind = nodeNumber
+		s.attach(node)
+		ok = s.number()
+	case c == 't' || c == 'f' || c == 'n':
+		node.kind = nodeLiteral
+		s.attach(node)
+		ok = s.literal(map[byte]string{'t': "true", 'f': "false", 'n': "null"}[c])
+	default:
+		s.fail(s.pos, "invalid character %s looking for beginning of value", quoteChar(c))
+		return false
+	}
+	node.end = s.pos
+	return ok
+}
+
+// attach adds node to the container being read, as the value of the pending
+// member of an object or as the next element of an array.
+func (s *jsonScanner) attach(node *jsonNode) {
+	switch {
+	case s.parent == nil:
+		s.root = node
+	case s.parent.kind == nodeObject:
+		s.parent.members = append(s.parent.members, jsonMember{key: s.key, keyStart: s.keyStart, value: node})
+	default:
+		s.parent.elements = append(s.parent.elements, node)
+	}
+}
+
+// object parses an object and reports whether it ended with its own '}'.
+func (s *jsonScanner) object() bool {
+	s.advance(1) // '{'
+	s.skipSpace()
+	if !s.eof() && s.data[s.pos] == '}' {
+		s.advance(1)
+		return true
+	}
+
+	for {
+		s.skipSpace()
+		if s.eof() {
+			s.failEOF()
+			return false
+		}
+		// Only reached after a comma: allow a trailing one where the dialect does.
+		if s.data[s.pos] == '}' && s.dialect.trailingCommas() {
+			s.advance(1)
+			return true
+		}
+
+		keyStart := s.pos
+		switch c := s.data[s.pos]; {
+		case c == '"' || (c == '\'' && s.dialect == dialectJSON5):
+			if !s.str() {
+				if more, ok := s.resume('}'); !more {
+					return ok
+				}
+				continue
+			}
+		case s.dialect == dialectJSON5 && isIdentifierStart(c):
+			s.identifier()
+		default:
+			s.fail(s.pos, "invalid character %s looking for beginning of object key string", quoteChar(c))
+			if more, ok := s.resume('}'); !more {
+				return ok
+			}
+			continue
+		}
+		key := decodeQuoted(s.data[keyStart:s.pos])
+
+		s.skipSpace()
+		if s.eof() {
+			s.failEOF()
+			return false
+		}
+		if s.data[s.pos] != ':' {
+			s.fail(s.pos, "invalid character %s after object key", quoteChar(s.data[s.pos]))
+			if more, ok := s.resume('}'); !more {
+				return ok
+			}
+			continue
+		}
+		s.advance(1)
+
+		s.key, s.keyStart = key, keyStart
+		if !s.value() {
+			if more, ok := s.resume('}'); !more {
+				return ok
+			}
+			continue
+		}
+
+		s.skipSpace()
+		if s.eof() {
+			s.failEOF()
+			return false
+		}
+		switch c := s.data[s.pos]; c {
+		case ',':
+			s.advance(1)
+		case '}':
+			s.advance(1)
+			return true
+		case '"', '\'':
+			// Most likely a missing comma: report it and read the next member.
+			s.fail(s.pos, "invalid character %s after object key:value pair", quoteChar(c))
+		default:
+			s.fail(s.pos, "invalid character %s after object key:value pair", quoteChar(c))
+			if more, ok := s.resume('}'); !more {
+				return ok
+			}
+		}
+	}
+}
+
+// array parses an array and reports whether it ended with its own ']'.
+func (s *jsonScanner) array() bool {
+	s.advance(1) // '['
+	s.skipSpace()
+	if !s.eof() && s.data[s.pos] == ']' {
+		s.advance(1)
+		return true
+	}
+
+	for {
+		// Only reached after a comma: allow a trailing one where the dialect does.
+		s.skipSpace()
+		if !s.eof() && s.data[s.pos] == ']' && s.dialect.trailingCommas() {
+			s.advance(1)
+			return true
+		}
+
+		if !s.value() {
+			if more, ok := s.resume(']'); !more {
+				return ok
+			}
+			continue
+		}
+
+		s.skipSpace()
+		if s.eof() {
+			s.failEOF()
+			return false
+		}
+		switch c := s.data[s.pos]; {
+		case c == ',':
+			s.advance(1)
+		case c == ']':
+			s.advance(1)
+			return true
+		case isValueStart(c, s.dialect):
+			// Most likely a missing comma: report it and read the next element.
+			s.fail(s.pos, "invalid character %s after array element", quoteChar(c))
+		default:
+			s.fail(s.pos, "invalid character %s after array element", quoteChar(c))
+			if more, ok := s.resume(']'); !more {
+				return ok
+			}
+		}
+	}
+}
+
+// resume skips the rest of a malformed member or element of the container
+// closed by closer. more reports that a ',' follows and the container goes
+// on; otherwise ok reports whether the container ended with its own closer
+// (a mismatched closer or the end of input is left to the enclosing value).
+func (s *jsonScanner) resume(closer byte) (more, ok bool) {
+	depth := 0
+	for !s.eof() {
+		switch c := s.data[s.pos]; c {
+		case '"', '\'':
+			if c == '"' || s.dialect == dialectJSON5 {
+				s.skipString()
+				continue
+			}
+		case '/':
+			if s.dialect.comments() && s.comment() {
+				continue
+			}
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth == 0 {
+				if c != closer {
+					return false, false
+				}
+				s.advance(1)
+				return false, true
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				s.advance(1)
+				return true, true
+			}
+		}
+		s.advance(1)
+	}
+	return false, false
+}
+
+// skipString steps over a string without checking it.
+func (s *jsonScanner) skipString() {
+	quote := s.data[s.pos]
+	s.advance(1)
+	for !s.eof() {
+		switch s.data[s.pos] {
+		case '\\':
+			s.advance(2)
+			continue
+		case quote:
+			s.advance(1)
+			return
+		}
+		s.advance(1)
+	}
+}
+
+// str parses a string. Invalid characters and escapes are reported without
+// giving up on the string, so a stray newline costs a single error and the
+// string is still well-formed as far as the enclosing value is concerned.
+func (s *jsonScanner) str() bool {
+	quote := s.data[s.pos]
+	s.advance(1)
+	for !s.eof() {
+		c := s.data[s.pos]
+		switch {
+		case c == quote:
+			s.advance(1)
+			return true
+		case c < 0x20 && (s.dialect != dialectJSON5 || c == '\n' || c == '\r'):
+			s.fail(s.pos, "invalid character %s in string literal", quoteChar(c))
+			s.advance(1)
+		case c == '\\':
+			s.advance(1)
+			if s.eof() {
+				break
+			}
+			switch e := s.data[s.pos]; {
+			case e == '"' || e == '\\' || e == '/' || e == 'b' || e == 'f' || e == 'n' || e == 'r' || e == 't':
+				s.advance(1)
+			case e == 'x' && s.dialect == dialectJSON5:
+				s.advance(1)
+				for i := 0; i < 2 && !s.eof(); i++ {
+					if !isHex(s.data[s.pos]) {
+						s.fail(s.pos, "invalid character %s in \\x hexadecimal character escape", quoteChar(s.data[s.pos]))
+						break
+					}
+					s.advance(1)
+				}
+			case s.dialect == dialectJSON5 && e != 'u' && (e == '0' || !isDigit(e)):
+				// JSON5 escapes any other character, including line breaks.
+				s.advance(1)
+			case e == 'u':
+				s.advance(1)
+				for i := 0; i < 4 && !s.eof(); i++ {
+					if !isHex(s.data[s.pos]) {
+						s.fail(s.pos, "invalid character %s in \\u hexadecimal character escape", quoteChar(s.data[s.pos]))
+						break
+					}
+					s.advance(1)
+				}
+			default:
+				s.fail(s.pos, "invalid character %s in string escape code", quoteChar(s.data[s.pos]))
+				s.advance(1)
+			}
+		default:
+			s.advance(1)
+		}
+	}
+	s.failEOF()
+	return false
+}
+
+func (s *jsonScanner) number() bool {
+	if s.dialect == dialectJSON5 {
+		return s.number5()
+	}
+	if s.data[s.pos] == '-' {
+		s.advance(1)
+		if s.eof() {
+			s.failEOF()
+			return false
+		}
+		if !isDigit(s.data[s.pos]) {
+			s.fail(s.pos, "invalid character %s in numeric literal", quoteChar(s.data[s.pos]))
+			return false
+		}
+	}
+
+	if s.data[s.pos] == '0' {
+		s.advance(1)
+	} else {
+		s.digits()
+	}
+
+	if !s.eof() && s.data[s.pos] == '.' {
+		s.advance(1)
+		if s.eof() {
+			s.failEOF()
+			return false
+		}
+		if !isDigit(s.data[s.pos]) {
+			s.fail(s.pos, "invalid character %s after decimal point in numeric literal", quoteChar(s.data[s.pos]))
+			return false
+		}
+		s.digits()
+	}
+
+	if !s.eof() && (s.data[s.pos] == 'e' || s.data[s.pos] == 'E') {
+		s.advance(1)
+		if !s.eof() && (s.data[s.pos] == '+' || s.data[s.pos] == '-') {
+			s.advance(1)
+		}
+		if s.eof() {
+			s.failEOF()
+			return false
+		}
+		if !isDigit(s.data[s.pos]) {
+			s.fail(s.pos, "invalid character %s in exponent of numeric literal", quoteChar(s.data[s.pos]))
+			return false
+		}
+		s.digits()
+	}
+
+	return true
+}
+
+func (s *jsonScanner) digits() {
+	for !s.eof() && isDigit(s.data[s.pos]) {
+		s.advance(1)
+	}
+}
+
+func (s *jsonScanner) literal(word string) bool {
+	for i := 0; i < len(word); i++ {
+		if s.eof() {
+			s.failEOF()
+			return false
+		}
+		if s.data[s.pos] != word[i] {
+			s.fail(s.pos, "invalid character %s in literal %s (expecting %s)", quoteChar(s.data[s.pos]), word, quoteChar(word[i]))
+			return false
+		}
+		s.advance(1)
+	}
+	return true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHex(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isValueStart(c byte, d dialect) bool {
+	if d == dialectJSON5 && (c == '\'' || c == '+' || c == '.' || c == 'I' || c == 'N') {
+		return true
+	}
+	return c == '{' || c == '[' || c == '"' || c == '-' || c == 't' || c == 'f' || c == 'n' || isDigit(c)
+}
+
+// quoteChar formats c the way encoding/json does in its error messages.
+func quoteChar(c byte) string {
+	if c == '\'' {
+		return `'\''`
+	}
+	if c == '"' {
+		return `'"'`
+	}
+	quoted := strconv.Quote(string(rune(c)))
+	return "'" + quoted[1:len(quoted)-1] + "'"
+}
+
+// errorOffset returns the byte offset of the character an encoding/json
+// error refers to. Their Offset counts the bytes read, including the
+// offending one.
+func errorOffset(data []byte, err error) (int, bool) {
+	var offset int
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		if e.Offset >= int64(len(data)) || e.Error() == "unexpected end of JSON input" {
+			return endOffset(data), true
+		}
+		offset = int(e.Offset)
+	case *json.UnmarshalTypeError:
+		offset = int(e.Offset)
+	default:
+		return 0, false
+	}
+	if offset > 0 {
+		offset--
+	}
+	return offset, true
+}
+
+// position converts a byte offset into a 1-based line and column; columns
+// count characters, not bytes.
+func position(data []byte, offset int) (line, column int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	lineStart := 0
+	line = 1
+	for i := 0; i < offset; i++ {
+		if data[i] == '\n' {
+			line++
+			lineStart = i + 1
+		}
+	}
+	return line, utf8.RuneCount(data[lineStart:offset]) + 1
+}
+
+// maxSnippetWidth bounds the snippet of long lines such as minified JSON.
+const maxSnippetWidth = 80
+
+// snippet returns the line containing offset followed by a line with a
+// caret under the offending character. Long lines are cut to a window
+// around it.
+func snippet(data []byte, offset int) string {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	start := offset
+	for start > 0 && data[start-1] != '\n' {
+		start--
+	}
+	end := offset
+	for end < len(data) && data[end] != '\n' {
+		end++
+	}
+
+	before := []rune(string(data[start:offset]))
+	after := []rune(strings.TrimRight(string(data[offset:end]), "\r"))
+	prefix, suffix := "", ""
+	if len(before) > maxSnippetWidth/2 {
+		before = before[len(before)-maxSnippetWidth/2:]
+		prefix = "..."
+	}
+	if len(after) > maxSnippetWidth/2 {
+		after = after[:maxSnippetWidth/2]
+		suffix = "..."
+	}
+
+	// Keep tabs in the caret line so it lines up with the text above.
+	indent := []rune(prefix + string(before))
+	for i, r := range indent {
+		if r != '\t' {
+			indent[i] = ' '
+		}
+	}
+	return prefix + string(before) + string(after) + suffix + "\n" + string(indent) + "^"
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// errUnknownRef is returned for a $ref that does not point into a schema of
+// the request or of the catalog.
+var errUnknownRef = errors.New("is not a submitted or catalog schema")
+
+// schemaSources are the schemas a $ref may point to: the files of the
+// request, known by file URLs relative to the request root, and the schemas
+// of the catalog, known by the URLs they are published at.
+type schemaSources struct {
+	files   map[string]string
+	catalog *schemaCatalog
+}
+
+// fileSchemaID returns the id of the request file at p, so that relative
+// references between files resolve as they do on disk.
+func fileSchemaID(p string) string {
+	return (&url.URL{Scheme: "file", Path: "/" + cleanPath(p)}).String()
+}
+
+func (s schemaSources) lookup(id string) (string, bool) {
+	u, err := url.Parse(id)
+	if err != nil {
+		return "", false
+	}
+	if u.Scheme == "file" && u.Host == "" {
+		content, ok := s.files[cleanPath(u.Path)]
+		return content, ok
+	}
+	if known := s.catalog.forURL(id); known != nil && known.data != nil {
+		return string(known.data), true
+	}
+	return "", false
+}
+
+// resolveRefs returns the schemas the schema refers to, directly or through
+// other schemas, by id. gojsonschema fetches any $ref it does not already
+// know, over http or from the local file system, so a $ref that is neither a
+// JSON pointer into a loaded schema nor the id of one of sources is rejected
+// before anything is compiled.
+func resolveRefs(id, content string, sources schemaSources) (map[string]string, error) {
+	documents := map[string]string{id: content}
+	ids := make(map[string]bool)
+	var unresolved []schemaRef
+
+	for pending := []string{id}; len(pending) > 0; pending = pending[1:] {
+		var document interface{}
+		if err := json.Unmarshal([]byte(documents[pending[0]]), &document); err != nil {
+			continue // reported when the schema is compiled
+		}
+		base, err := url.Parse(pending[0])
+		if err != nil {
+			continue
+		}
+		ids[pending[0]] = true
+
+		for _, ref := range collectRefs(document, base, ids) {
+			if _, ok := documents[ref.target]; ok {
+				continue
+			}
+			if content, ok := sources.lookup(ref.target); ok {
+				documents[ref.target] = content
+				pending = append(pending, ref.target)
+				continue
+			}
+			unresolved = append(unresolved, ref)
+		}
+	}
+
+	// A $ref may name a subschema by the $id it declares.
+	for _, ref := range unresolved {
+		if !ids[ref.target] {
+			return nil, fmt.Errorf("$ref %q %w", ref.value, errUnknownRef)
+		}
+	}
+
+	delete(documents, id)
+	return documents, nil
+}
+
+// schemaRef is a $ref and the schema it points into, without the fragment.
+type schemaRef struct {
+	value  string
+	target string
+}
+
+// collectRefs returns the references of a schema document and adds the ids
+// it declares to ids. Both are resolved against the closest enclosing id,
+// the way gojsonschema resolves them.
+func collectRefs(node interface{}, base *url.URL, ids map[string]bool) []schemaRef {
+	var refs []schemaRef
+	switch node := node.(type) {
+	case []interface{}:
+		for _, element := range node {
+			refs = append(refs, collectRefs(element, base, ids)...)
+		}
+	case map[string]interface{}:
+		key := "$id"
+		if _, ok := node["id"]; ok {
+			key = "id"
+		}
+		if id, ok := node[key].(string); ok {
+			if u, err := base.Parse(id); err == nil {
+				base = u
+				ids[withoutFragment(u)] = true
+			}
+		}
+		if ref, ok := node["$ref"].(string); ok {
+			if u, err := base.Parse(ref); err == nil {
+				refs = append(refs, schemaRef{value: ref, target: withoutFragment(u)})
+			}
+		}
+		for _, value := range node {
+			refs = append(refs, collectRefs(value, base, ids)...)
+		}
+	}
+	return refs
+}
+
+func withoutFragment(u *url.URL) string {
+	document := *u
+	document.Fragment, document.RawFragment = "", ""
+	return document.String()
+}
+
+// compileSchema compiles the schema at id with the schemas it refers to
+// preloaded, so gojsonschema finds every $ref without loading anything.
+func compileSchema(id, content string, documents map[string]string) (*gojsonschema.Schema, error) {
+	loader := gojsonschema.NewSchemaLoader()
+	for documentID, document := range documents {
+		if err := loader.AddSchema(documentID, gojsonschema.NewStringLoader(document)); err != nil {
+			return nil, err
+		}
+	}
+	if err := loader.AddSchema(id, gojsonschema.NewStringLoader(content)); err != nil {
+		return nil, err
+	}
+	return loader.Compile(gojsonschema.NewReferenceLoader(id))
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+	"json_analyzer_service/internal/models"
+)
+
+// documentSchema is the JSON Schema a document is validated against. It is
+// shared by all documents of a request that use the same schema, so the
+// schema is compiled once per request.
+type documentSchema struct {
+	source   string // where the schema comes from, used in messages
+	declared bool   // named by the document's own $schema key
+	id       string // the URL its references are resolved against
+	data     []byte
+	refs     map[string]string // the schemas it refers to, by id
+
+	// problem explains why a declared schema cannot be used; the document
+	// is then not validated.
+	problem  string
+	severity string
+	ruleID   string
+
+	schema     *gojsonschema.Schema
+	compileErr error
+}
+
+// cleanPath normalizes a submitted path so references between files of a
+// request can be compared.
+func cleanPath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(p, "\\", "/")), "/")
+}
+
+// validateSchemas checks the schema mappings of a request. Inline schemas are
+// compiled here so a broken schema is reported once as a bad request. They
+// are resolved against the request root.
+func validateSchemas(req *models.AnalyzeRequest, catalog *schemaCatalog) (map[int]*documentSchema, error) {
+	files := requestFiles(req)
+	sources := schemaSources{files: files, catalog: catalog}
+	mapped := make(map[int]*documentSchema, len(req.Schemas))
+
+	for i, mapping := range req.Schemas {
+		for _, pattern := range mapping.Files {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("%w: schemas[%d]: invalid file pattern %q", ErrInvalidOptions, i, pattern)
+			}
+		}
+
+		switch {
+		case len(mapping.Schema) > 0 && mapping.Ref != "":
+			return nil, fmt.Errorf("%w: schemas[%d]: set either schema or ref, not both", ErrInvalidOptions, i)
+		case len(mapping.Schema) > 0:
+			schema := &documentSchema{source: fmt.Sprintf("schemas[%d]", i), id: fileSchemaID(""), data: mapping.Schema}
+			schema.resolveRefs(sources)
+			schema.compile()
+			if schema.compileErr != nil {
+				return nil, fmt.Errorf("%w: schemas[%d]: %v", ErrInvalidOptions, i, schema.compileErr)
+			}
+			mapped[i] = schema
+		case mapping.Ref != "":
+			content, ok := files[cleanPath(mapping.Ref)]
+			if !ok {
+				return nil, fmt.Errorf("%w: schemas[%d]: ref %q is not a file of the request", ErrInvalidOptions, i, mapping.Ref)
+			}
+			schema := &documentSchema{source: mapping.Ref, id: fileSchemaID(mapping.Ref), data: []byte(content)}
+			schema.resolveRefs(sources)
+			mapped[i] = schema
+		default:
+			return nil, fmt.Errorf("%w: schemas[%d]: schema or ref is required", ErrInvalidOptions, i)
+		}
+	}
+
+	return mapped, nil
+}
+
+func requestFiles(req *models.AnalyzeRequest) map[string]string {
+	files := make(map[string]string, len(req.Files))
+	for _, file := range req.Files {
+		files[cleanPath(file.Path)] = file.Content
+	}
+	return files
+}
+
+// resolveSchemas returns the schema of every pending document that has one.
+// A matching entry of the request's schemas wins over the document's own
+// $schema key, which may point to another file of the request or to a schema
+// of the catalog. Otherwise the catalog picks a schema by file name.
+func resolveSchemas(req *models.AnalyzeRequest, mapped map[int]*documentSchema, pending []int, catalog *schemaCatalog) map[int]*documentSchema {
+	sources := schemaSources{files: requestFiles(req), catalog: catalog}
+	declared := make(map[string]*documentSchema)
+	schemas := make(map[int]*documentSchema, len(pending))
+
+	for _, i := range pending {
+		file := req.Files[i]
+		if m := matchSchemaMapping(req.Schemas, file.Path); m >= 0 {
+			schemas[i] = mapped[m]
+			continue
+		}
+
+		var schema *documentSchema
+		if ref := declaredSchema(file.Path, file.Content); ref != "" {
+			target := resolveSchemaRef(file.Path, ref)
+			var ok bool
+			if schema, ok = declared[target]; !ok {
+				schema = declaredSchemaSource(ref, target, sources)
+				declared[target] = schema
+			}
+		}
+		if schema == nil || schema.problem != "" {
+			if known := catalog.forPath(file.Path); known != nil {
+				schema = known
+			}
+		}
+		schemas[i] = schema
+	}
+
+	return schemas
+}
+
+// matchSchemaMapping returns the index of the first schema mapping that
+// applies to filePath, or -1. Patterns without a slash match the base name.
+func matchSchemaMapping(mappings []models.SchemaMapping, filePath string) int {
+	filePath = cleanPath(filePath)
+	for i, mapping := range mappings {
+		if len(mapping.Files) == 0 {
+			return i
+		}
+		for _, pattern := range mapping.Files {
+			name := filePath
+			if !strings.Contains(pattern, "/") {
+				name = path.Base(filePath)
+			}
+			if matched, _ := path.Match(strings.TrimPrefix(pattern, "./"), name); matched {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// declaredSchema returns the $schema value of a document, if any. The
+// document is read in its dialect, so JSONC files can declare a schema too.
+func declaredSchema(filePath, content string) string {
+	root, issues := parseDialect([]byte(content), dialectFor(filePath))
+	if len(issues) > 0 || root == nil || root.kind != nodeObject {
+		return ""
+	}
+	schema := ""
+	for _, member := range root.members {
+		if member.key == "$schema" && member.value.kind == nodeString {
+			schema = member.value.text
+		}
+	}
+	return strings.TrimSpace(schema)
+}
+
+// resolveSchemaRef turns a $schema value into the path of a request file
+// relative to the document, or returns remote URLs unchanged.
+func resolveSchemaRef(documentPath, ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	switch u.Scheme {
+	case "":
+		return cleanPath(path.Join(path.Dir(cleanPath(documentPath)), u.Path))
+	case "file":
+		return cleanPath(u.Path)
+	default:
+		return ref
+	}
+}
+
+// declaredSchemaSource loads the schema named by a document's $schema key.
+// It returns nil for documents that declare a meta-schema, which are schemas
+// themselves.
+func declaredSchemaSource(ref, target string, sources schemaSources) *documentSchema {
+	if strings.Contains(ref, "json-schema.org/") {
+		return nil
+	}
+	if known := sources.catalog.forURL(ref); known != nil {
+		return known
+	}
+
+	schema := &documentSchema{source: ref, declared: true}
+
+	if u, err := url.Parse(target); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		schema.problem = fmt.Sprintf("Schema %s is not available offline; the document was not validated", ref)
+		schema.severity = models.SeverityInfo
+		schema.ruleID = "schema-unavailable"
+		return schema
+	}
+
+	content, ok := sources.files[target]
+	if !ok {
+		schema.problem = fmt.Sprintf("Schema %s was not found among the submitted files", ref)
+		schema.severity = models.SeverityWarning
+		schema.ruleID = "schema-not-found"
+		return schema
+	}
+	schema.id = fileSchemaID(target)
+	schema.data = []byte(content)
+	schema.resolveRefs(sources)
+	return schema
+}
+
+// resolveRefs loads the schemas the schema refers to. A $ref to anything
+// else is a problem of the schema: it is never fetched.
+func (s *documentSchema) resolveRefs(sources schemaSources) {
+	refs, err := resolveRefs(s.id, string(s.data), sources)
+	if err != nil {
+		s.problem = fmt.Sprintf("Schema %s cannot be used: %v; the document was not validated", s.source, err)
+		s.severity = models.SeverityWarning
+		s.ruleID = "schema-ref-rejected"
+		return
+	}
+	s.refs = refs
+}
+
+// compile compiles a schema whose references were resolved.
+func (s *documentSchema) compile() {
+	if s.problem != "" || s.schema != nil || s.compileErr != nil {
+		return
+	}
+	s.schema, s.compileErr = compileSchema(s.id, string(s.data), s.refs)
+}
+
+// compileSchemas compiles the schemas still needed by the given documents.
+func compileSchemas(schemas map[int]*documentSchema, pending []int) {
+	for _, i := range pending {
+		if schema := schemas[i]; schema != nil && schema.data != nil {
+			schema.compile()
+		}
+	}
+}
+
+// validateSchema validates a syntactically valid document, loaded from the
+// document's value, and reports every schema error at the position of the
+// value it concerns.
+func validateSchema(data []byte, root *jsonNode, document gojsonschema.JSONLoader, schema *documentSchema) []models.LineComment {
+	at := func(lineComment models.LineComment, tokens []string) models.LineComment {
+		start, end := locate(root, tokens)
+		lineComment.Line, lineComment.Column = position(data, start)
+		lineComment.EndLine, lineComment.EndColumn = position(data, end)
+		return lineComment
+	}
+	// Problems with a declared schema are reported at the $schema key.
+	var schemaKey []string
+	if schema.declared {
+		schemaKey = []string{"$schema"}
+	}
+
+	if schema.problem != "" {
+		return []models.LineComment{at(models.LineComment{
+			RuleID:   schema.ruleID,
+			Severity: schema.severity,
+			Tool:     "gojsonschema",
+			Comment:  schema.problem,
+		}, schemaKey)}
+	}
+	if schema.compileErr != nil {
+		return []models.LineComment{at(models.LineComment{
+			RuleID:   "schema-invalid",
+			Severity: models.SeverityError,
+			Tool:     "gojsonschema",
+			Comment:  fmt.Sprintf("Schema %s cannot be used: %v", schema.source, schema.compileErr),
+		}, schemaKey)}
+	}
+
+	result, err := schema.schema.Validate(document)
+	if err != nil {
+		return []models.LineComment{at(models.LineComment{
+			RuleID:   "schema-invalid",
+			Severity: models.SeverityError,
+			Tool:     "gojsonschema",
+			Comment:  fmt.Sprintf("Schema %s cannot be applied: %v", schema.source, err),
+		}, schemaKey)}
+	}
+
+	var lineComments []models.LineComment
+	for _, desc := range result.Errors() {
+		lineComments = append(lineComments, at(models.LineComment{
+			RuleID:   desc.Type(),
+			Severity: models.SeverityError,
+			Tool:     "gojsonschema",
+			Comment:  desc.String(),
+		}, contextTokens(desc.Context())))
+	}
+	return lineComments
+}
+
+// contextTokens splits a gojsonschema context such as (root).items.0 into
+// its keys. A separator that cannot occur in keys keeps dotted keys intact.
+func contextTokens(context *gojsonschema.JsonContext) []string {
+	if context == nil {
+		return nil
+	}
+	tokens := strings.Split(context.String("\x00"), "\x00")
+	return tokens[1:] // (root)
+}
+
+// locate returns the byte range of the value a path of keys and array
+// indices points to, or of its closest existing ancestor. Members are
+// located from their key, where editors underline them; duplicate keys
+// resolve to the last one, like encoding/json does.
+func locate(root *jsonNode, tokens []string) (start, end int) {
+	if root == nil {
+		return 0, 0
+	}
+
+	node := root
+	start, end = node.start, node.end
+	for _, token := range tokens {
+		var next *jsonNode
+		nextStart := 0
+		switch node.kind {
+		case nodeObject:
+			for _, member := range node.members {
+				if member.key == token {
+					next, nextStart = member.value, member.keyStart
+				}
+			}
+		case nodeArray:
+			var index int
+			if _, err := fmt.Sscanf(token, "%d", &index); err == nil && index >= 0 && index < len(node.elements) {
+				next = node.elements[index]
+				nextStart = next.start
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+		start, end = nextStart, node.end
+	}
+	return start, end
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"json_analyzer_service/internal/models"
+)
+
+const personSchema = `{
+  "type": "object",
+  "required": ["name"],
+  "properties": {
+    "name": {"type": "string"},
+    "tags": {"type": "array", "items": {"type": "string"}}
+  }
+}`
+
+func TestJSONAnalyzerService_InlineSchema(t *testing.T) {
+	service := NewJSONAnalyzerService()
+
+	resp, err := service.Analyze(context.Background(), &models.AnalyzeRequest{
+		Files: []models.FileInput{
+			{Path: "people/ann.json", Content: "{\n  \"name\": \"Ann\",\n  \"tags\": [\"a\", 2]\n}\n"},
+			{Path: "people/bob.json", Content: "{\n  \"name\": 42\n}\n"},
+			{Path: "other.json", Content: "{\"name\": 42}"},
+		},
+		Schemas: []models.SchemaMapping{
+			{Files: []string{"people/*.json"}, Schema: json.RawMessage(personSchema)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	ann := resp.Files[0].LineComments
+	if len(ann) != 1 || ann[0].Line != 3 || ann[0].Column != 17 || ann[0].RuleID != "invalid_type" {
+		t.Errorf("people/ann.json findings = %+v, want invalid_type at 3:17", ann)
+	}
+
+	bob := resp.Files[1].LineComments
+	if len(bob) != 1 || bob[0].Line != 2 || bob[0].Column != 3 || bob[0].EndLine != 2 || bob[0].EndColumn != 13 {
+		t.Errorf("people/bob.json findings = %+v, want the name member at 2:3-2:13", bob)
+	}
+
+	if resp.Files[2].Comment != "OK" {
+		t.Errorf("other.json comment = %q, want OK (no schema applies)", resp.Files[2].Comment)
+	}
+}
+
+func TestJSONAnalyzerService_SchemaReferences(t *testing.T) {
+	service := NewJSONAnalyzerService()
+
+	resp, err := service.Analyze(context.Background(), &models.AnalyzeRequest{
+		Files: []models.FileInput{
+			{Path: "schemas/person.json", Content: personSchema},
+			{Path: "data/ref.json", Content: `{"tags": []}`},
+			{Path: "data/declared.json", Content: "{\n  \"$schema\": \"../schemas/person.json\",\n  \"name\": \"Ann\"\n}"},
+			{Path: "data/missing.json", Content: "{\n  \"$schema\": \"./nope.json\"\n}"},
+			{Path: "data/remote.json", Content: `{"$schema": "https://example.com/schema.json"}`},
+		},
+		Schemas: []models.SchemaMapping{
+			{Files: []string{"ref.json"}, Ref: "schemas/person.json"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	tests := []struct {
+		path   string
+		ruleID string
+		line   int
+	}{
+		{path: "schemas/person.json"},
+		{path: "data/ref.json", ruleID: "required", line: 1},
+		{path: "data/declared.json"},
+		{path: "data/missing.json", ruleID: "schema-not-found", line: 2},
+		{path: "data/remote.json", ruleID: "schema-unavailable", line: 1},
+	}
+	for i, tt := range tests {
+		got := resp.Files[i]
+		if tt.ruleID == "" {
+			if got.Comment != "OK" {
+				t.Errorf("%s = %+v, want OK", tt.path, got)
+			}
+			continue
+		}
+		if len(got.LineComments) != 1 || got.LineComments[0].RuleID != tt.ruleID || got.LineComments[0].Line != tt.line {
+			t.Errorf("%s findings = %+v, want %s at line %d", tt.path, got.LineComments, tt.ruleID, tt.line)
+		}
+	}
+}
+
+func TestJSONAnalyzerService_SchemaRefs(t *testing.T) {
+	service := NewJSONAnalyzerService()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"required": ["name"]}`)
+	}))
+	defer server.Close()
+
+	local := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(local, []byte(`{"required": ["name"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fileURL := (&url.URL{Scheme: "file", Path: filepath.ToSlash(local)}).String()
+
+	resp, err := service.Analyze(context.Background(), &models.AnalyzeRequest{
+		Files: []models.FileInput{
+			{Path: "schemas/defs.json", Content: `{"definitions": {"name": {"type": "string"}}}`},
+			{Path: "schemas/person.json", Content: `{"properties": {"name": {"$ref": "defs.json#/definitions/name"}}}`},
+			{Path: "schemas/local.json", Content: `{"allOf": [{"$ref": "` + fileURL + `"}]}`},
+			{Path: "data/person.json", Content: "{\n  \"$schema\": \"../schemas/person.json\",\n  \"name\": 42\n}"},
+			{Path: "data/local.json", Content: "{\n  \"$schema\": \"../schemas/local.json\"\n}"},
+			{Path: "remote.json", Content: `{}`},
+			{Path: "file.json", Content: `{}`},
+			{Path: "package.json", Content: `{"private": "yes"}`},
+		},
+		Schemas: []models.SchemaMapping{
+			{Files: []string{"remote.json"}, Schema: json.RawMessage(`{"properties": {"a": {"$ref": "` + server.URL + `/schema.json"}}}`)},
+			{Files: []string{"file.json"}, Schema: json.RawMessage(`{"$ref": "` + fileURL + `"}`)},
+			{Files: []string{"package.json"}, Schema: json.RawMessage(`{"$ref": "https://json.schemastore.org/package.json"}`)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	tests := []struct {
+		path   string
+		ruleID string
+		line   int
+	}{
+		{path: "data/person.json", ruleID: "invalid_type", line: 3},
+		{path: "data/local.json", ruleID: "schema-ref-rejected", line: 2},
+		{path: "remote.json", ruleID: "schema-ref-rejected", line: 1},
+		{path: "file.json", ruleID: "schema-ref-rejected", line: 1},
+		{path: "package.json", ruleID: "invalid_type", line: 1},
+	}
+	for _, tt := range tests {
+		var got models.FileResult
+		for _, result := range resp.Files {
+			if result.Path == tt.path {
+				got = result
+			}
+		}
+		if len(got.LineComments) != 1 || got.LineComments[0].RuleID != tt.ruleID || got.LineComments[0].Line != tt.line {
+			t.Errorf("%s findings = %+v, want %s at line %d", tt.path, got.LineComments, tt.ruleID, tt.line)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("schema server got %d requests, want none", requests)
+	}
+}
+
+func TestJSONAnalyzerService_InvalidSchemas(t *testing.T) {
+	service := NewJSONAnalyzerService()
+
+	tests := []struct {
+		name    string
+		mapping models.SchemaMapping
+	}{
+		{name: "no schema", mapping: models.SchemaMapping{Files: []string{"*.json"}}},
+		{name: "schema and ref", mapping: models.SchemaMapping{Schema: json.RawMessage(`{}`), Ref: "a.json"}},
+		{name: "unknown ref", mapping: models.SchemaMapping{Ref: "missing.json"}},
+		{name: "broken schema", mapping: models.SchemaMapping{Schema: json.RawMessage(`{"type": 12}`)}},
+		{name: "bad pattern", mapping: models.SchemaMapping{Files: []string{"["}, Schema: json.RawMessage(`{}`)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Analyze(context.Background(), &models.AnalyzeRequest{
+				Files:   []models.FileInput{{Path: "a.json", Content: `{}`}},
+				Schemas: []models.SchemaMapping{tt.mapping},
+			})
+			if !errors.Is(err, ErrInvalidOptions) {
+				t.Errorf("Analyze() error = %v, want ErrInvalidOptions", err)
+			}
+		})
+	}
+}
+
+func TestLocate(t *testing.T) {
+	data := []byte(`{"a": [10, {"b": true}], "a.b": 1, "d": 1, "d": 2}`)
+	root, issues := parseJSON(data)
+	if len(issues) != 0 {
+		t.Fatalf("parseJSON() issues = %v", issues)
+	}
+
+	tests := []struct {
+		tokens []string
+		want   string
+	}{
+		{tokens: nil, want: string(data)},
+		{tokens: []string{"a", "1", "b"}, want: `"b": true`},
+		{tokens: []string{"a", "0"}, want: `10`},
+		{tokens: []string{"a.b"}, want: `"a.b": 1`},
+		{tokens: []string{"d"}, want: `"d": 2`},
+		{tokens: []string{"a", "5"}, want: `"a": [10, {"b": true}]`},
+	}
+	for _, tt := range tests {
+		start, end := locate(root, tt.tokens)
+		if got := string(data[start:end]); got != tt.want {
+			t.Errorf("locate(%q) = %q, want %q", tt.tokens, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package sarif
+
+import (
+	"encoding/json"
+	"testing"
+
+	"python_analyzer_service/internal/models"
+)
+
+func TestFromResponse(t *testing.T) {
+	resp := &models.AnalyzeResponse{
+		Files: []models.FileResult{
+			{
+				Path:    "src/my app/main.py",
+				Comment: "Issues found",
+				LineComments: []models.LineComment{
+					{Line: 1, Column: 1, RuleID: "F401", Severity: models.SeverityError, Tool: "flake8", Comment: "'os' imported but unused"},
+					{Line: 3, Column: 80, RuleID: "E501", Severity: models.SeverityWarning, Tool: "flake8", Comment: "line too long"},
+					{Line: 7, Column: 1, RuleID: "F401", Severity: models.SeverityError, Tool: "flake8", Comment: "'sys' imported but unused"},
+				},
+			},
+			{
+				Path:    "app.js",
+				Comment: "Issues found",
+				LineComments: []models.LineComment{
+					{Line: 2, Column: 5, EndLine: 2, EndColumn: 9, RuleID: "no-unused-vars", Severity: models.SeverityInfo, Tool: "eslint", Comment: "'x' is defined but never used."},
+					{Line: 0, Comment: "file-level note"},
+				},
+			},
+		},
+	}
+
+	log := FromResponse(resp, "default-tool")
+	if log.Version != Version {
+		t.Errorf("FromResponse() version = %q, want %q", log.Version, Version)
+	}
+	if len(log.Runs) != 3 {
+		t.Fatalf("FromResponse() returned %d runs, want 3", len(log.Runs))
+	}
+
+	flake8 := log.Runs[0]
+	if flake8.Tool.Driver.Name != "flake8" || len(flake8.Results) != 3 {
+		t.Fatalf("first run = %s with %d results, want flake8 with 3", flake8.Tool.Driver.Name, len(flake8.Results))
+	}
+	if len(flake8.Tool.Driver.Rules) != 2 {
+		t.Errorf("flake8 run has %d rules, want 2 (rules must be de-duplicated)", len(flake8.Tool.Driver.Rules))
+	}
+	if index := flake8.Results[2].RuleIndex; index == nil || *index != 0 {
+		t.Errorf("third flake8 result ruleIndex = %v, want 0", index)
+	}
+	if uri := flake8.Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI; uri != "src/my%20app/main.py" {
+		t.Errorf("artifact URI = %q, want escaped relative path", uri)
+	}
+
+	eslint := log.Runs[1]
+	region := eslint.Results[0].Locations[0].PhysicalLocation.Region
+	if region == nil || *region != (Region{StartLine: 2, StartColumn: 5, EndLine: 2, EndColumn: 9}) {
+		t.Errorf("eslint region = %+v, want 2:5-2:9", region)
+	}
+	if eslint.Results[0].Level != "note" {
+		t.Errorf("eslint level = %q, want note", eslint.Results[0].Level)
+	}
+
+	fallback := log.Runs[2]
+	if fallback.Tool.Driver.Name != "default-tool" {
+		t.Errorf("fallback run tool = %q, want default-tool", fallback.Tool.Driver.Name)
+	}
+	if fallback.Results[0].Locations[0].PhysicalLocation.Region != nil {
+		t.Error("a finding without a line must not produce a region")
+	}
+
+	if _, err := json.Marshal(log); err != nil {
+		t.Errorf("failed to marshal SARIF log: %v", err)
+	}
+}
+
+func TestFromResponse_Empty(t *testing.T) {
+	log := FromResponse(&models.AnalyzeResponse{}, "default-tool")
+	if len(log.Runs) != 1 || log.Runs[0].Tool.Driver.Name != "default-tool" || len(log.Runs[0].Results) != 0 {
+		t.Errorf("FromResponse() of an empty response = %+v, want a single empty run", log.Runs)
+	}
+}
+
+func TestFromResponse_Failures(t *testing.T) {
+	resp := &models.AnalyzeResponse{
+		Files: []models.FileResult{
+			{Path: "ok.py", Status: models.StatusOK, Comment: "OK", LineComments: []models.LineComment{}},
+			{Path: "broken.py", Status: models.StatusToolError, Comment: "Error: flake8 failed: exit status 1", Stderr: "Traceback", LineComments: []models.LineComment{}},
+			{Path: "slow.py", Status: models.StatusTimeout, Comment: "Analysis timed out", LineComments: []models.LineComment{}},
+		},
+	}
+
+	log := FromResponse(resp, "default-tool")
+	if len(log.Runs) != 1 || len(log.Runs[0].Invocations) != 1 {
+		t.Fatalf("FromResponse() = %+v, want one run with an invocation", log.Runs)
+	}
+	invocation := log.Runs[0].Invocations[0]
+	if invocation.ExecutionSuccessful || len(invocation.ToolExecutionNotifications) != 2 {
+		t.Fatalf("invocation = %+v, want an unsuccessful one with 2 notifications", invocation)
+	}
+	if got := invocation.ToolExecutionNotifications[0].Message.Text; got != "Error: flake8 failed: exit status 1\nTraceback" {
+		t.Errorf("notification message = %q, want the comment and stderr", got)
+	}
+
+	if invocations := FromResponse(&models.AnalyzeResponse{Files: resp.Files[:1]}, "default-tool").Runs[0].Invocations; invocations != nil {
+		t.Errorf("FromResponse() of a successful analysis has invocations %+v, want none", invocations)
+	}
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"python_analyzer_service/internal/models"
+)
+
+func TestResultCache_LRU(t *testing.T) {
+	cache := newResultCache(2, "")
+
+	cache.put("a", models.FileResult{Comment: "a"})
+	cache.put("b", models.FileResult{Comment: "b"})
+	if _, ok := cache.get("a"); !ok {
+		t.Fatal("get(a) missed, want hit")
+	}
+	cache.put("c", models.FileResult{Comment: "c"})
+
+	if _, ok := cache.get("b"); ok {
+		t.Error("get(b) hit, want the least recently used entry to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if result, ok := cache.get(key); !ok || result.Comment != key {
+			t.Errorf("get(%s) = %+v, %v, want cached result", key, result, ok)
+		}
+	}
+
+	stats := cache.stats()
+	if stats.Entries != 2 || stats.Capacity != 2 || stats.Hits != 3 || stats.Misses != 1 {
+		t.Errorf("stats() = %+v, want 2/2 entries, 3 hits, 1 miss", stats)
+	}
+}
+
+func TestResultCache_Persistent(t *testing.T) {
+	dir := t.TempDir()
+
+	cache := newResultCache(10, dir)
+	cache.put("key", models.FileResult{Comment: "Issues found", LineComments: []models.LineComment{{Line: 3, RuleID: "E501"}}})
+
+	restarted := newResultCache(10, dir)
+	result, ok := restarted.get("key")
+	if !ok || len(result.LineComments) != 1 || result.LineComments[0].RuleID != "E501" {
+		t.Errorf("get() after restart = %+v, %v, want the persisted result", result, ok)
+	}
+	if !restarted.stats().Persistent {
+		t.Error("stats().Persistent = false, want true")
+	}
+}
+
+func TestResultCache_Disabled(t *testing.T) {
+	cache := newResultCache(0, "")
+
+	pending := cache.lookup(map[int]string{0: "key"}, []models.FileInput{{Path: "a.py"}}, make([]models.FileResult, 1), []int{0})
+	if len(pending) != 1 {
+		t.Errorf("lookup() on a disabled cache = %v, want every file pending", pending)
+	}
+	if cache.stats().Enabled {
+		t.Error("stats().Enabled = true, want false")
+	}
+}
+
+func TestCacheFromEnv(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int // capacity, 0 for a disabled cache
+	}{
+		{value: "", want: defaultCacheSize},
+		{value: "50", want: 50},
+		{value: "0", want: 0},
+		{value: "-1", want: 0},
+		{value: "many", want: defaultCacheSize},
+		{value: "50 entries", want: defaultCacheSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("CACHE_SIZE", tt.value)
+			t.Setenv("CACHE_DIR", "")
+			got := 0
+			if cache := cacheFromEnv(); cache != nil {
+				got = cache.capacity
+			}
+			if got != tt.want {
+				t.Errorf("cacheFromEnv() capacity = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPythonAnalyzerService_CachedAnalyze(t *testing.T) {
+	dir := t.TempDir()
+	runs := filepath.Join(dir, "runs")
+	script := filepath.Join(dir, "flake8")
+	content := fmt.Sprintf(`#!/bin/sh
+if [ "$1" = "--version" ]; then echo "7.0.0 (fake)"; exit 0; fi
+echo run >> %s
+for last; do :; done
+echo "$last:1:1: F401 'os' imported but unused"
+exit 1
+`, runs)
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("failed to write fake flake8: %v", err)
+	}
+
+	service := NewPythonAnalyzerService()
+	service.flake8Path = script
+	service.cache = newResultCache(10, "")
+
+	analyze := func(source string) models.FileResult {
+		t.Helper()
+		resp, err := service.Analyze(context.Background(), &models.AnalyzeRequest{
+			Files: []models.FileInput{{Path: "main.py", Content: source}},
+		})
+		if err != nil {
+			t.Fatalf("Analyze() error = %v", err)
+		}
+		return resp.Files[0]
+	}
+	countRuns := func() int {
+		data, _ := os.ReadFile(runs)
+		return strings.Count(string(data), "run")
+	}
+
+	first := analyze("import os\n")
+	second := analyze("import os\n")
+	if got := countRuns(); got != 1 {
+		t.Errorf("flake8 ran %d times for an unchanged file, want 1", got)
+	}
+	if len(second.LineComments) != 1 || second.LineComments[0] != first.LineComments[0] {
+		t.Errorf("cached result = %+v, want %+v", second, first)
+	}
+
+	analyze("import sys\n")
+	if got := countRuns(); got != 2 {
+		t.Errorf("flake8 ran %d times after the content changed, want 2", got)
+	}
+
+	stats := service.CacheStats()
+	if stats.Hits != 1 || stats.Misses != 2 {
+		t.Errorf("CacheStats() = %+v, want 1 hit and 2 misses", stats)
+	}
+}
+
+func TestPythonAnalyzerService_MayFollowImports(t *testing.T) {
+	service := NewPythonAnalyzerService()
+
+	pyproject := models.FileInput{Path: `app\pyproject.toml`, Content: "[tool.analyzer]\ntools = [\"ruff\", \"mypy\"]\n"}
+	tests := []struct {
+		name  string
+		tools []string
+		opts  *models.AnalyzeOptions
+		files []models.FileInput
+		want  bool
+	}{
+		{name: "service default", want: false},
+		{name: "service default with pylint", tools: []string{"flake8", "pylint"}, want: true},
+		{name: "project", files: []models.FileInput{pyproject}, want: true},
+		{name: "request", opts: &models.AnalyzeOptions{Tools: []string{"mypy"}}, want: true},
+		{name: "request overrides project", opts: &models.AnalyzeOptions{Tools: []string{"ruff"}}, files: []models.FileInput{pyproject}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service.tools = []string{"flake8"}
+			if tt.tools != nil {
+				service.tools = tt.tools
+			}
+			req := &models.AnalyzeRequest{Files: append([]models.FileInput{{Path: "main.py"}}, tt.files...), Options: tt.opts}
+			if got := service.mayFollowImports(req); got != tt.want {
+				t.Errorf("mayFollowImports() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// flake8ConfigFiles are the files flake8 reads its options from, in the order
+// flake8 itself checks them. Each only counts when it has a [flake8] section.
+var flake8ConfigFiles = []string{"setup.cfg", "tox.ini", ".flake8"}
+
+// configArgs returns the flake8 arguments that apply the project's own
+// configuration: a flake8 config file, a [tool.flake8] table in
+// pyproject.toml (which flake8 cannot read itself), or max_line_length from
+// .editorconfig when the project has no flake8 configuration at all.
+func (s *PythonAnalyzerService) configArgs(ws *workspace) []string {
+	for _, name := range flake8ConfigFiles {
+		if data, err := ws.readConfig(name); err == nil && hasSection(data, "flake8") {
+			return []string{"--config", name}
+		}
+	}
+
+	if data, err := ws.readConfig("pyproject.toml"); err == nil {
+		if options := pyprojectFlake8Options(data); len(options) > 0 {
+			var ini bytes.Buffer
+			ini.WriteString("[flake8]\n")
+			for _, option := range options {
+				fmt.Fprintf(&ini, "%s = %s\n", option[0], option[1])
+			}
+			if path, err := ws.writeGenerated("flake8.cfg", ini.Bytes()); err == nil {
+				return []string{"--config", path}
+			}
+		}
+	}
+
+	if data, err := ws.readConfig(".editorconfig"); err == nil {
+		if maxLineLength := editorconfigMaxLineLength(data); maxLineLength > 0 {
+			return []string{"--max-line-length", strconv.Itoa(maxLineLength)}
+		}
+	}
+
+	return nil
+}
+
+// projectTools returns the tools the project enables in the [tool.analyzer]
+// table of pyproject.toml, e.g. tools = ["flake8", "mypy"], or nil when it
+// does not choose any.
+func projectTools(ws *workspace) []string {
+	data, err := ws.readConfig("pyproject.toml")
+	if err != nil {
+		return nil
+	}
+	for _, option := range pyprojectTable(data, "tool.analyzer") {
+		if option[0] == "tools" {
+			return splitTools(option[1])
+		}
+	}
+	return nil
+}
+
+// banditConfigArgs points bandit at pyproject.toml when it has a
+// [tool.bandit] table, which bandit only reads when told to.
+func banditConfigArgs(ws *workspace) []string {
+	if data, err := ws.readConfig("pyproject.toml"); err == nil && len(pyprojectTable(data, "tool.bandit")) > 0 {
+		return []string{"-c", "pyproject.toml"}
+	}
+	return nil
+}
+
+// hasSection reports whether an INI file has a [name] section.
+func hasSection(data []byte, name string) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "["+name+"]" {
+			return true
+		}
+	}
+	return false
+}
+
+// pyprojectFlake8Options extracts the key/value pairs of the [tool.flake8]
+// table from pyproject.toml, converting TOML strings and arrays into the
+// comma-separated values flake8 expects in an INI file.
+func pyprojectFlake8Options(data []byte) [][2]string {
+	return pyprojectTable(data, "tool.flake8")
+}
+
+// pyprojectTable extracts the key/value pairs of a pyproject.toml table, with
+// values converted by tomlValue.
+func pyprojectTable(data []byte, table string) [][2]string {
+	var options [][2]string
+	inTable := false
+	var key, value string
+	inArray := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if inArray {
+			value += " " + line
+			if strings.Contains(line, "]") {
+				inArray = false
+				options = append(options, [2]string{key, tomlValue(value)})
+			}
+			continue
+		}
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			inTable = line == "["+table+"]"
+			continue
+		}
+		if !inTable {
+			continue
+		}
+
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(k)
+		value = strings.TrimSpace(v)
+		if strings.HasPrefix(value, "[") && !strings.Contains(value, "]") {
+			inArray = true
+			continue
+		}
+		options = append(options, [2]string{key, tomlValue(value)})
+	}
+
+	return options
+}
+
+// tomlValue converts a TOML scalar or array into its INI representation.
+func tomlValue(value string) string {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "[") {
+		value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = tomlValue(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return strings.Join(items, ", ")
+	}
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		return unquoted
+	}
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// editorconfigMaxLineLength returns max_line_length from the .editorconfig
+// sections that apply to Python files; later sections override earlier ones.
+func editorconfigMaxLineLength(data []byte) int {
+	maxLineLength := 0
+	applies := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			applies = editorconfigMatchesPython(line[1 : len(line)-1])
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || !applies || strings.TrimSpace(strings.ToLower(key)) != "max_line_length" {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			maxLineLength = n
+		} else {
+			maxLineLength = 0
+		}
+	}
+
+	return maxLineLength
+}
+
+// editorconfigMatchesPython reports whether a section glob such as "*",
+// "*.py" or "*.{py,pyi}" applies to .py files.
+func editorconfigMatchesPython(glob string) bool {
+	switch {
+	case glob == "*", glob == "*.py":
+		return true
+	case strings.HasPrefix(glob, "*.{") && strings.HasSuffix(glob, "}"):
+		for _, ext := range strings.Split(glob[3:len(glob)-1], ",") {
+			if strings.TrimSpace(ext) == "py" {
+				return true
+			}
+		}
+	}
+	return false
+}
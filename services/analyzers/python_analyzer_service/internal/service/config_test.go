@@ -0,0 +1,98 @@
+package service
+
+import (
+	"os"
+	"testing"
+
+	"python_analyzer_service/internal/models"
+)
+
+func TestPythonAnalyzerService_ConfigArgs(t *testing.T) {
+	service := NewPythonAnalyzerService()
+
+	tests := []struct {
+		name  string
+		files []models.FileInput
+		want  []string
+	}{
+		{
+			name:  "no configuration",
+			files: []models.FileInput{{Path: "main.py"}},
+			want:  nil,
+		},
+		{
+			name: "setup.cfg without flake8 section",
+			files: []models.FileInput{
+				{Path: "main.py"},
+				{Path: "setup.cfg", Content: "[metadata]\nname = app\n"},
+			},
+			want: nil,
+		},
+		{
+			name: "tox.ini with flake8 section",
+			files: []models.FileInput{
+				{Path: "main.py"},
+				{Path: "setup.cfg", Content: "[metadata]\nname = app\n"},
+				{Path: "tox.ini", Content: "[flake8]\nmax-line-length = 120\n"},
+			},
+			want: []string{"--config", "tox.ini"},
+		},
+		{
+			name: "editorconfig",
+			files: []models.FileInput{
+				{Path: "main.py"},
+				{Path: ".editorconfig", Content: "root = true\n\n[*]\nmax_line_length = 100\n\n[*.{py,pyi}]\nmax_line_length = 88\n\n[*.md]\nmax_line_length = off\n"},
+			},
+			want: []string{"--max-line-length", "88"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws, err := newWorkspace(tt.files)
+			if err != nil {
+				t.Fatalf("newWorkspace() error = %v", err)
+			}
+			defer ws.Close()
+
+			got := service.configArgs(ws)
+			if len(got) != len(tt.want) {
+				t.Fatalf("configArgs() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("configArgs() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPythonAnalyzerService_ConfigArgsPyproject(t *testing.T) {
+	service := NewPythonAnalyzerService()
+
+	ws, err := newWorkspace([]models.FileInput{
+		{Path: "main.py"},
+		{Path: "pyproject.toml", Content: "[project]\nname = \"app\"\n\n" +
+			"[tool.flake8]\nmax-line-length = 120\nextend-ignore = [\n    \"E203\",\n    'W503',\n]\n" +
+			"exclude = \"migrations\"\n\n[tool.black]\nline-length = 120\n"},
+	})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer ws.Close()
+
+	args := service.configArgs(ws)
+	if len(args) != 2 || args[0] != "--config" {
+		t.Fatalf("configArgs() = %v, want a generated --config", args)
+	}
+
+	config, err := os.ReadFile(args[1])
+	if err != nil {
+		t.Fatalf("failed to read generated config: %v", err)
+	}
+	want := "[flake8]\nmax-line-length = 120\nextend-ignore = E203, W503\nexclude = migrations\n"
+	if string(config) != want {
+		t.Errorf("generated config = %q, want %q", config, want)
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"python_analyzer_service/internal/models"
+)
+
+// ErrInvalidOptions is returned by Analyze when the request options cannot
+// be applied; the controller reports it as a bad request.
+var ErrInvalidOptions = errors.New("invalid options")
+
+// severityRank orders severities for the min_severity option.
+var severityRank = map[string]int{
+	models.SeverityInfo:    0,
+	models.SeverityWarning: 1,
+	models.SeverityError:   2,
+}
+
+func validateOptions(opts *models.AnalyzeOptions) error {
+	if opts == nil {
+		return nil
+	}
+	if _, ok := severityRank[opts.MinSeverity]; opts.MinSeverity != "" && !ok {
+		return fmt.Errorf("%w: min_severity must be one of error, warning, info", ErrInvalidOptions)
+	}
+	if opts.MaxFindings < 0 {
+		return fmt.Errorf("%w: max_findings must not be negative", ErrInvalidOptions)
+	}
+	for _, rules := range [][]string{opts.EnableRules, opts.DisableRules} {
+		for _, rule := range rules {
+			if strings.TrimSpace(rule) == "" {
+				return fmt.Errorf("%w: rule IDs must not be empty", ErrInvalidOptions)
+			}
+		}
+	}
+	for _, tool := range opts.Tools {
+		if len(knownTools([]string{tool})) == 0 {
+			return fmt.Errorf("%w: unknown tool %q, must be one of %s", ErrInvalidOptions, tool, strings.Join(pythonTools, ", "))
+		}
+	}
+	return nil
+}
+
+// applyOptions filters the findings of a result by the request options.
+// flake8 already applies the rule selection through --select/--extend-ignore;
+// the filter applies it to the findings of the other tools and adds
+// min_severity and max_findings, which flake8 has no flags for.
+func applyOptions(result models.FileResult, opts *models.AnalyzeOptions) models.FileResult {
+	if opts == nil || len(result.LineComments) == 0 {
+		return result
+	}
+
+	lineComments := make([]models.LineComment, 0, len(result.LineComments))
+	for _, lineComment := range result.LineComments {
+		if opts.MaxFindings > 0 && len(lineComments) == opts.MaxFindings {
+			break
+		}
+		if keepFinding(lineComment, opts) {
+			lineComments = append(lineComments, lineComment)
+		}
+	}
+
+	result.LineComments = lineComments
+	if len(lineComments) == 0 {
+		result.Status = models.StatusOK
+		result.Comment = "OK"
+	}
+	return result
+}
+
+func keepFinding(lineComment models.LineComment, opts *models.AnalyzeOptions) bool {
+	if len(opts.EnableRules) > 0 && !matchesAnyRule(lineComment.RuleID, opts.EnableRules) {
+		return false
+	}
+	if matchesAnyRule(lineComment.RuleID, opts.DisableRules) {
+		return false
+	}
+	if opts.MinSeverity != "" && severityRank[lineComment.Severity] < severityRank[opts.MinSeverity] {
+		return false
+	}
+	return true
+}
+
+// optionArgs translates the rule selection into flake8 flags.
+func optionArgs(opts *models.AnalyzeOptions) []string {
+	if opts == nil {
+		return nil
+	}
+
+	var args []string
+	if len(opts.EnableRules) > 0 {
+		args = append(args, "--select", strings.Join(opts.EnableRules, ","))
+	}
+	if len(opts.DisableRules) > 0 {
+		args = append(args, "--extend-ignore", strings.Join(opts.DisableRules, ","))
+	}
+	return args
+}
+
+func matchesAnyRule(ruleID string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matchesRule(ruleID, strings.TrimSpace(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesRule follows flake8's --select semantics: a pattern matches every
+// code it is a prefix of, so "E1" covers E101 and E128.
+func matchesRule(ruleID, pattern string) bool {
+	return strings.HasPrefix(ruleID, pattern)
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"python_analyzer_service/internal/models"
+)
+
+func TestValidateOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    *models.AnalyzeOptions
+		wantErr bool
+	}{
+		{name: "no options", opts: nil},
+		{name: "valid", opts: &models.AnalyzeOptions{EnableRules: []string{"E", "F401"}, MinSeverity: models.SeverityWarning, MaxFindings: 10}},
+		{name: "unknown severity", opts: &models.AnalyzeOptions{MinSeverity: "fatal"}, wantErr: true},
+		{name: "negative max findings", opts: &models.AnalyzeOptions{MaxFindings: -1}, wantErr: true},
+		{name: "empty rule", opts: &models.AnalyzeOptions{DisableRules: []string{" "}}, wantErr: true},
+		{name: "tools", opts: &models.AnalyzeOptions{Tools: []string{"ruff", "MyPy"}}},
+		{name: "unknown tool", opts: &models.AnalyzeOptions{Tools: []string{"flake8", "black"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOptions(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidOptions) {
+				t.Errorf("validateOptions() error = %v, want ErrInvalidOptions", err)
+			}
+		})
+	}
+}
+
+func TestOptionArgs(t *testing.T) {
+	got := optionArgs(&models.AnalyzeOptions{
+		EnableRules:  []string{"E", "F401"},
+		DisableRules: []string{"E501", "W"},
+		MinSeverity:  models.SeverityError,
+	})
+	want := []string{"--select", "E,F401", "--extend-ignore", "E501,W"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("optionArgs() = %v, want %v", got, want)
+	}
+
+	if got := optionArgs(nil); got != nil {
+		t.Errorf("optionArgs(nil) = %v, want nil", got)
+	}
+}
+
+func TestApplyOptions(t *testing.T) {
+	result := models.FileResult{
+		Path:    "main.py",
+		Comment: "Issues found",
+		LineComments: []models.LineComment{
+			{Line: 1, RuleID: "F401", Severity: models.SeverityError},
+			{Line: 2, RuleID: "E501", Severity: models.SeverityWarning},
+			{Line: 3, RuleID: "C901", Severity: models.SeverityInfo},
+			{Line: 4, RuleID: "E128", Severity: models.SeverityWarning},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		opts      *models.AnalyzeOptions
+		wantLines []int
+		comment   string
+	}{
+		{name: "no options", opts: nil, wantLines: []int{1, 2, 3, 4}, comment: "Issues found"},
+		{name: "enable prefix", opts: &models.AnalyzeOptions{EnableRules: []string{"E"}}, wantLines: []int{2, 4}, comment: "Issues found"},
+		{name: "disable", opts: &models.AnalyzeOptions{DisableRules: []string{"E5", "C901"}}, wantLines: []int{1, 4}, comment: "Issues found"},
+		{name: "min severity", opts: &models.AnalyzeOptions{MinSeverity: models.SeverityWarning}, wantLines: []int{1, 2, 4}, comment: "Issues found"},
+		{name: "max findings", opts: &models.AnalyzeOptions{MinSeverity: models.SeverityWarning, MaxFindings: 2}, wantLines: []int{1, 2}, comment: "Issues found"},
+		{name: "everything filtered", opts: &models.AnalyzeOptions{EnableRules: []string{"W"}}, wantLines: []int{}, comment: "OK"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := applyOptions(result, tt.opts)
+			lines := []int{}
+			for _, lineComment := range got.LineComments {
+				lines = append(lines, lineComment.Line)
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("applyOptions() lines = %v, want %v", lines, tt.wantLines)
+			}
+			if got.Comment != tt.comment {
+				t.Errorf("applyOptions() comment = %q, want %q", got.Comment, tt.comment)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEach(t *testing.T) {
+	const n = 50
+	results := make([]int, n)
+
+	var running, peak int32
+	forEach(n, 4, func(i int) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if current <= old || atomic.CompareAndSwapInt32(&peak, old, current) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		results[i] = i * i
+		atomic.AddInt32(&running, -1)
+	})
+
+	for i, got := range results {
+		if got != i*i {
+			t.Errorf("results[%d] = %d, want %d", i, got, i*i)
+		}
+	}
+	if peak > 4 {
+		t.Errorf("forEach() ran %d workers concurrently, want at most 4", peak)
+	}
+}
+
+func TestProcessLimiter(t *testing.T) {
+	limiter := newProcessLimiter(1)
+	if err := limiter.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := limiter.acquire(ctx); err == nil {
+		t.Fatal("acquire() should block while the only slot is taken")
+	}
+
+	limiter.release()
+	if err := limiter.acquire(context.Background()); err != nil {
+		t.Errorf("acquire() after release() error = %v", err)
+	}
+}
+
+func TestBatches(t *testing.T) {
+	items := []int{0, 1, 2, 3, 4, 5, 6}
+
+	groups := batches(items, 3)
+	if len(groups) != 3 {
+		t.Fatalf("batches() returned %d groups, want 3", len(groups))
+	}
+	var flat []int
+	for _, group := range groups {
+		if len(group) < 2 || len(group) > 3 {
+			t.Errorf("batches() group size = %d, want 2 or 3", len(group))
+		}
+		flat = append(flat, group...)
+	}
+	for i, item := range flat {
+		if item != i {
+			t.Fatalf("batches() = %v, want items in input order", groups)
+		}
+	}
+
+	if got := batches(items[:2], 8); len(got) != 2 {
+		t.Errorf("batches() with more workers than items returned %d groups, want 2", len(got))
+	}
+}
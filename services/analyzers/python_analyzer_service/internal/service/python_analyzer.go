@@ -1,148 +1,254 @@
 package service
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
-	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"python_analyzer_service/internal/models"
 )
 
+const (
+	defaultFileTimeout    = 30 * time.Second
+	defaultRequestTimeout = 2 * time.Minute
+
+	// waitDelay bounds how long we wait for output pipes after the tool
+	// process has been killed on cancellation.
+	waitDelay = 2 * time.Second
+)
+
 type PythonAnalyzerService struct {
-	flake8Path string
+	flake8Path     string
+	ruffPath       string
+	pylintPath     string
+	mypyPath       string
+	banditPath     string
+	tools          []string
+	fileTimeout    time.Duration
+	requestTimeout time.Duration
+	maxWorkers     int
+	processes      processLimiter
+	cache          *resultCache
+
+	versionOnce sync.Once
+	version     string
+	versionOK   bool
 }
 
 func NewPythonAnalyzerService() *PythonAnalyzerService {
-	flake8Path := os.Getenv("FLAKE8_PATH")
-	if flake8Path == "" {
-		flake8Path = "flake8"
+	return &PythonAnalyzerService{
+		flake8Path:     pathFromEnv("FLAKE8_PATH", "flake8"),
+		ruffPath:       pathFromEnv("RUFF_PATH", "ruff"),
+		pylintPath:     pathFromEnv("PYLINT_PATH", "pylint"),
+		mypyPath:       pathFromEnv("MYPY_PATH", "mypy"),
+		banditPath:     pathFromEnv("BANDIT_PATH", "bandit"),
+		tools:          toolsFromEnv("PYTHON_TOOLS", []string{"flake8"}),
+		fileTimeout:    durationFromEnv("ANALYZER_FILE_TIMEOUT", defaultFileTimeout),
+		requestTimeout: durationFromEnv("ANALYZER_REQUEST_TIMEOUT", defaultRequestTimeout),
+		maxWorkers:     intFromEnv("MAX_WORKERS", runtime.NumCPU()),
+		processes:      newProcessLimiter(intFromEnv("MAX_PROCESSES", runtime.NumCPU())),
+		cache:          cacheFromEnv(),
 	}
+}
 
-	return &PythonAnalyzerService{
-		flake8Path: flake8Path,
+// pathFromEnv returns the executable configured in the environment, or the
+// default when the variable is unset.
+func pathFromEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
 	}
+	return fallback
 }
 
-func (s *PythonAnalyzerService) Analyze(req *models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
-	var results []models.FileResult
+// durationFromEnv parses a Go duration (e.g. "30s") from the environment,
+// falling back to the default when the variable is unset or invalid.
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// intFromEnv parses a positive integer from the environment, falling back to
+// the default when the variable is unset or invalid.
+func intFromEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func (s *PythonAnalyzerService) Analyze(ctx context.Context, req *models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
+	if err := validateOptions(req.Options); err != nil {
+		return nil, err
+	}
 
-	for _, file := range req.Files {
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	results := make([]models.FileResult, len(req.Files))
+	var pending []int
+
+	for i, file := range req.Files {
 		if !strings.HasSuffix(strings.ToLower(file.Path), ".py") {
-			results = append(results, models.FileResult{
+			results[i] = models.FileResult{
 				Path:         file.Path,
+				Status:       models.StatusUnsupported,
 				Comment:      "Not a Python file",
 				LineComments: []models.LineComment{},
-			})
+			}
 			continue
 		}
-
-		result := s.analyzeFile(file.Path, file.Content)
-		results = append(results, result)
+		pending = append(pending, i)
 	}
 
-	return &models.AnalyzeResponse{Files: results}, nil
-}
-
-func (s *PythonAnalyzerService) analyzeFile(path, content string) models.FileResult {
-	// Create temporary file
-	tmpDir := os.TempDir()
-	tmpFile := filepath.Join(tmpDir, fmt.Sprintf("analyze_%d_%s", time.Now().UnixNano(), filepath.Base(path)))
-	defer os.Remove(tmpFile)
-
-	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
-		return models.FileResult{
-			Path:         path,
-			Comment:      fmt.Sprintf("Error: failed to create temp file: %v", err),
-			LineComments: []models.LineComment{},
-		}
+	keys := s.cacheKeys(req, pending)
+	pending = s.cache.lookup(keys, req.Files, results, pending)
+	if len(pending) == 0 {
+		return &models.AnalyzeResponse{Files: results}, nil
 	}
 
-	// Run flake8
-	cmd := exec.Command(s.flake8Path, "--format=json", tmpFile)
-	output, err := cmd.CombinedOutput()
-
+	// Every file of the request is written to the workspace, including
+	// non-Python ones, so package layout and data files stay visible.
+	ws, err := newWorkspace(req.Files)
 	if err != nil {
-		// flake8 returns non-zero exit code if issues found, so we need to parse output
-		if len(output) == 0 {
-			return models.FileResult{
-				Path:         path,
-				Comment:      "OK",
+		for _, i := range pending {
+			results[i] = models.FileResult{
+				Path:         req.Files[i].Path,
+				Status:       models.StatusToolError,
+				Comment:      fmt.Sprintf("Error: failed to create workspace: %v", err),
 				LineComments: []models.LineComment{},
 			}
 		}
+		return &models.AnalyzeResponse{Files: results}, nil
 	}
+	defer ws.Close()
 
-	// Parse flake8 JSON output
-	var flake8Results []map[string]interface{}
-	if err := json.Unmarshal(output, &flake8Results); err != nil {
-		// If JSON parsing fails, try to parse text output
-		return s.parseTextOutput(path, string(output))
+	tools := s.selectTools(ws, req.Options)
+	flake8Args := append(s.configArgs(ws), optionArgs(req.Options)...)
+	groups := batches(pending, s.maxWorkers)
+
+	// Every tool runs once per batch; runs[g][t] is tools[t] over groups[g].
+	runs := make([][]toolRun, len(groups))
+	for g := range runs {
+		runs[g] = make([]toolRun, len(tools))
+	}
+	forEach(len(groups)*len(tools), s.maxWorkers, func(n int) {
+		g, t := n/len(tools), n%len(tools)
+		runs[g][t] = s.runTool(ctx, ws, tools[t], flake8Args, batchPaths(req.Files, groups[g]))
+	})
+	for g, group := range groups {
+		for j, result := range mergeRuns(batchPaths(req.Files, group), runs[g]) {
+			results[group[j]] = result
+		}
 	}
 
-	var lineComments []models.LineComment
-	comment := "OK"
+	for i := range results {
+		results[i] = applyOptions(results[i], req.Options)
+	}
+	s.cache.store(keys, results, pending)
 
-	for _, result := range flake8Results {
-		lineNum, _ := result["line_number"].(float64)
-		code, _ := result["code"].(string)
-		text, _ := result["text"].(string)
+	return &models.AnalyzeResponse{Files: results}, nil
+}
 
-		lineComments = append(lineComments, models.LineComment{
-			Line:    int(lineNum),
-			Comment: fmt.Sprintf("%s: %s", code, text),
-		})
+func batchPaths(files []models.FileInput, indices []int) []string {
+	paths := make([]string, len(indices))
+	for j, i := range indices {
+		paths[j] = files[i].Path
+	}
+	return paths
+}
 
-		if comment == "OK" {
-			comment = "Issues found"
-		}
+// runFlake8 runs flake8 over the given files inside the workspace, with the
+// project configuration and request option arguments.
+func (s *PythonAnalyzerService) runFlake8(ctx context.Context, ws *workspace, toolArgs []string, paths []string) (map[string][]models.LineComment, []byte, error) {
+	// Run flake8 with an explicit default-like format so every field is parseable
+	args := append([]string{"--format=%(path)s:%(row)d:%(col)d: %(code)s %(text)s"}, toolArgs...)
+	for _, path := range paths {
+		args = append(args, ws.relPath(path))
 	}
+	output, stderr, runErr := ws.run(ctx, s.flake8Path, args...)
 
-	return models.FileResult{
-		Path:         path,
-		Comment:      comment,
-		LineComments: lineComments,
+	// flake8 exits with 1 when it reports findings, but also when it fails
+	// with a traceback, in which case there is nothing to parse.
+	findings := s.parseTextOutput(ws, string(output))
+	if err := exitError(runErr, 1); err != nil || (runErr != nil && len(findings) == 0) {
+		return nil, stderr, runErr
 	}
+	return findings, nil, nil
 }
 
-func (s *PythonAnalyzerService) parseTextOutput(path, output string) models.FileResult {
+// flake8Line matches "path:line:col: CODE message"; the path is matched
+// greedily so paths containing colons are still parsed correctly.
+var flake8Line = regexp.MustCompile(`^(.*):(\d+):(\d+): ([A-Z]+[0-9]+) (.*)$`)
+
+// parseTextOutput groups flake8 findings by the original path of the file
+// they were reported for.
+func (s *PythonAnalyzerService) parseTextOutput(ws *workspace, output string) map[string][]models.LineComment {
 	lines := strings.Split(strings.TrimSpace(output), "\n")
-	var lineComments []models.LineComment
-	comment := "OK"
+	findings := make(map[string][]models.LineComment)
 
 	for _, line := range lines {
-		if line == "" {
+		match := flake8Line.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
 			continue
 		}
 
-		// Parse flake8 text format: path:line:col: code message
-		parts := strings.SplitN(line, ":", 4)
-		if len(parts) >= 3 {
-			var lineNum int
-			fmt.Sscanf(parts[1], "%d", &lineNum)
-			msg := ""
-			if len(parts) >= 4 {
-				msg = strings.TrimSpace(parts[3])
-			}
+		path, ok := ws.originalPath(match[1])
+		if !ok {
+			continue
+		}
 
-			lineComments = append(lineComments, models.LineComment{
-				Line:    lineNum,
-				Comment: msg,
-			})
+		lineNum, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+		code := match[4]
+		text := match[5]
+
+		findings[path] = append(findings[path], models.LineComment{
+			Line:     lineNum,
+			Column:   column,
+			RuleID:   code,
+			Severity: flake8Severity(code),
+			Tool:     "flake8",
+			Comment:  fmt.Sprintf("%s: %s", code, text),
+		})
+	}
 
-			if comment == "OK" {
-				comment = "Issues found"
-			}
-		}
+	return findings
+}
+
+// flake8Severity derives a severity from the flake8 code prefix: pyflakes (F)
+// and runtime/syntax errors (E9) are real bugs, the remaining pycodestyle
+// codes are style warnings and plugin codes (C, N, ...) are informational.
+func flake8Severity(code string) string {
+	switch {
+	case strings.HasPrefix(code, "F"), strings.HasPrefix(code, "E9"):
+		return models.SeverityError
+	case strings.HasPrefix(code, "E"), strings.HasPrefix(code, "W"):
+		return models.SeverityWarning
+	default:
+		return models.SeverityInfo
 	}
+}
 
-	return models.FileResult{
-		Path:         path,
-		Comment:      comment,
-		LineComments: lineComments,
+func timeoutResults(paths []string) []models.FileResult {
+	results := make([]models.FileResult, len(paths))
+	for i, path := range paths {
+		results[i] = models.FileResult{
+			Path:         path,
+			Status:       models.StatusTimeout,
+			Comment:      "Analysis timed out",
+			LineComments: []models.LineComment{},
+		}
 	}
+	return results
 }
 
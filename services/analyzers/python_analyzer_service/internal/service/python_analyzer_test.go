@@ -1,7 +1,11 @@
 package service
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"python_analyzer_service/internal/models"
 )
@@ -47,7 +51,7 @@ func TestPythonAnalyzerService_Analyze(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			resp, err := service.Analyze(tt.req)
+			resp, err := service.Analyze(context.Background(), tt.req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Analyze() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -61,3 +65,131 @@ func TestPythonAnalyzerService_Analyze(t *testing.T) {
 	}
 }
 
+func TestPythonAnalyzerService_ParseTextOutput(t *testing.T) {
+	service := NewPythonAnalyzerService()
+
+	ws, err := newWorkspace([]models.FileInput{
+		{Path: "app/main.py", Content: "import os\n"},
+		{Path: "app/util.py", Content: ""},
+	})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer ws.Close()
+
+	// Both files share the top-level "app" directory, so flake8 runs from
+	// there and reports paths relative to it.
+	output := "main.py:1:1: F401 'os' imported but unused\n" +
+		"./main.py:3:80: E501 line too long (82 > 79 characters)\n" +
+		filepath.Join(ws.root, "util.py") + ":4:1: W391 blank line at end of file\n" +
+		"/elsewhere/other.py:1:1: F401 'sys' imported but unused\n"
+
+	findings := service.parseTextOutput(ws, output)
+	if len(findings) != 2 {
+		t.Fatalf("parseTextOutput() returned findings for %d files, want 2", len(findings))
+	}
+
+	want := []models.LineComment{
+		{Line: 1, Column: 1, RuleID: "F401", Severity: models.SeverityError, Tool: "flake8", Comment: "F401: 'os' imported but unused"},
+		{Line: 3, Column: 80, RuleID: "E501", Severity: models.SeverityWarning, Tool: "flake8", Comment: "E501: line too long (82 > 79 characters)"},
+	}
+	if len(findings["app/main.py"]) != len(want) {
+		t.Fatalf("parseTextOutput() returned %d comments for app/main.py, want %d", len(findings["app/main.py"]), len(want))
+	}
+	for i, got := range findings["app/main.py"] {
+		if got != want[i] {
+			t.Errorf("parseTextOutput()[%d] = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	util := findings["app/util.py"]
+	if len(util) != 1 || util[0].RuleID != "W391" || util[0].Line != 4 {
+		t.Errorf("parseTextOutput() for app/util.py = %+v, want W391 on line 4", util)
+	}
+}
+
+func TestPythonAnalyzerService_Timeout(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "flake8")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nexec sleep 10\n"), 0755); err != nil {
+		t.Fatalf("failed to write fake flake8: %v", err)
+	}
+
+	service := NewPythonAnalyzerService()
+	service.flake8Path = script
+	service.fileTimeout = 100 * time.Millisecond
+
+	start := time.Now()
+	resp, err := service.Analyze(context.Background(), &models.AnalyzeRequest{
+		Files: []models.FileInput{
+			{Path: "slow.py", Content: "print('hello')"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Analyze() took %v, the tool process should have been killed", elapsed)
+	}
+	if got := resp.Files[0].Status; got != models.StatusTimeout {
+		t.Errorf("Analyze() status = %q, want %q", got, models.StatusTimeout)
+	}
+}
+
+func TestPythonAnalyzerService_Cancelled(t *testing.T) {
+	service := NewPythonAnalyzerService()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	resp, err := service.Analyze(ctx, &models.AnalyzeRequest{
+		Files: []models.FileInput{
+			{Path: "a.py", Content: "x = 1"},
+			{Path: "b.py", Content: "y = 2"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	for _, file := range resp.Files {
+		if file.Status != models.StatusTimeout {
+			t.Errorf("Analyze() status for %s = %q, want %q", file.Path, file.Status, models.StatusTimeout)
+		}
+	}
+}
+
+func TestPythonAnalyzerService_ToolError(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "flake8")
+	content := "#!/bin/sh\nif [ \"$1\" = \"--version\" ]; then echo 7.0.0; exit 0; fi\necho 'Traceback: bad config' >&2\nexit 1\n"
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("failed to write fake flake8: %v", err)
+	}
+
+	service := NewPythonAnalyzerService()
+	service.flake8Path = script
+	service.cache = newResultCache(10, "")
+
+	for _, flake8Path := range []string{script, filepath.Join(dir, "missing")} {
+		service.flake8Path = flake8Path
+		resp, err := service.Analyze(context.Background(), &models.AnalyzeRequest{
+			Files: []models.FileInput{{Path: "main.py", Content: "import os\n"}},
+		})
+		if err != nil {
+			t.Fatalf("Analyze() error = %v", err)
+		}
+		if got := resp.Files[0]; got.Status != models.StatusToolError || got.Comment == "OK" {
+			t.Errorf("Analyze() with %s = %+v, want a tool error", filepath.Base(flake8Path), got)
+		}
+	}
+	if stats := service.CacheStats(); stats.Entries != 0 {
+		t.Errorf("CacheStats() = %+v, want tool errors not cached", stats)
+	}
+
+	service.flake8Path = script
+	resp, _ := service.Analyze(context.Background(), &models.AnalyzeRequest{
+		Files: []models.FileInput{{Path: "main.py", Content: "x = 1\n"}},
+	})
+	if got := resp.Files[0].Stderr; got != "Traceback: bad config" {
+		t.Errorf("Analyze() stderr = %q, want the tool's stderr", got)
+	}
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"python_analyzer_service/internal/models"
+)
+
+// runRuff runs "ruff check" over the given files; ruff reads the project's
+// ruff.toml or [tool.ruff] table itself.
+func (s *PythonAnalyzerService) runRuff(ctx context.Context, ws *workspace, paths []string) (map[string][]models.LineComment, []byte, error) {
+	args := []string{"check", "--output-format", "json", "--no-cache"}
+	for _, path := range paths {
+		args = append(args, ws.relPath(path))
+	}
+	output, stderr, runErr := ws.run(ctx, s.ruffPath, args...)
+
+	// ruff exits with 1 when it reports findings and with 2 when it fails.
+	if err := exitError(runErr, 1); err != nil {
+		return nil, stderr, err
+	}
+	findings, err := parseRuffOutput(ws, output)
+	if err != nil {
+		return nil, stderr, err
+	}
+	return findings, nil, nil
+}
+
+type ruffMessage struct {
+	Code     *string      `json:"code"`
+	Message  string       `json:"message"`
+	Filename string       `json:"filename"`
+	Location ruffLocation `json:"location"`
+	End      ruffLocation `json:"end_location"`
+}
+
+type ruffLocation struct {
+	Row    int `json:"row"`
+	Column int `json:"column"`
+}
+
+// parseRuffOutput groups the findings of "ruff check --output-format json"
+// by original path. Syntax errors have no code and are reported as E999, as
+// flake8 does.
+func parseRuffOutput(ws *workspace, output []byte) (map[string][]models.LineComment, error) {
+	var messages []ruffMessage
+	if err := json.Unmarshal(output, &messages); err != nil {
+		return nil, fmt.Errorf("invalid ruff output: %w", err)
+	}
+
+	findings := make(map[string][]models.LineComment)
+	for _, message := range messages {
+		path, ok := ws.originalPath(message.Filename)
+		if !ok {
+			continue
+		}
+		code := "E999"
+		if message.Code != nil {
+			code = *message.Code
+		}
+		findings[path] = append(findings[path], models.LineComment{
+			Line:      message.Location.Row,
+			Column:    message.Location.Column,
+			EndLine:   message.End.Row,
+			EndColumn: message.End.Column,
+			RuleID:    code,
+			Severity:  flake8Severity(code),
+			Tool:      "ruff",
+			Comment:   fmt.Sprintf("%s: %s", code, message.Message),
+		})
+	}
+	return findings, nil
+}
+
+// runPylint runs pylint over the given files; pylint reads the project's
+// pylintrc or [tool.pylint] table itself.
+func (s *PythonAnalyzerService) runPylint(ctx context.Context, ws *workspace, paths []string) (map[string][]models.LineComment, []byte, error) {
+	args := []string{"--output-format=json", "--persistent=n"}
+	for _, path := range paths {
+		args = append(args, ws.relPath(path))
+	}
+	output, stderr, runErr := ws.run(ctx, s.pylintPath, args...)
+
+	if err := pylintError(runErr); err != nil {
+		return nil, stderr, err
+	}
+	findings, err := parsePylintOutput(ws, output)
+	if err != nil {
+		return nil, stderr, err
+	}
+	return findings, nil, nil
+}
+
+// pylintError returns the error of a pylint run, or nil when pylint ran.
+// Its exit code is a bit mask of the message categories it reported; 32
+// means it could not run at all.
+func pylintError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 && exitErr.ExitCode()&32 == 0 {
+		return nil
+	}
+	return err
+}
+
+type pylintMessage struct {
+	Type      string `json:"type"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"endLine"`
+	EndColumn int    `json:"endColumn"`
+	Path      string `json:"path"`
+	Symbol    string `json:"symbol"`
+	Message   string `json:"message"`
+	MessageID string `json:"message-id"`
+}
+
+// parsePylintOutput groups the messages of "pylint --output-format=json" by
+// original path. pylint columns are zero-based.
+func parsePylintOutput(ws *workspace, output []byte) (map[string][]models.LineComment, error) {
+	var messages []pylintMessage
+	if len(bytes.TrimSpace(output)) > 0 {
+		if err := json.Unmarshal(output, &messages); err != nil {
+			return nil, fmt.Errorf("invalid pylint output: %w", err)
+		}
+	}
+
+	findings := make(map[string][]models.LineComment)
+	for _, message := range messages {
+		path, ok := ws.originalPath(message.Path)
+		if !ok {
+			continue
+		}
+		lineComment := models.LineComment{
+			Line:     message.Line,
+			Column:   message.Column + 1,
+			EndLine:  message.EndLine,
+			RuleID:   message.MessageID,
+			Severity: pylintSeverity(message.Type),
+			Tool:     "pylint",
+			Comment:  fmt.Sprintf("%s: %s (%s)", message.MessageID, message.Message, message.Symbol),
+		}
+		if message.EndLine > 0 {
+			lineComment.EndColumn = message.EndColumn + 1
+		}
+		findings[path] = append(findings[path], lineComment)
+	}
+	return findings, nil
+}
+
+func pylintSeverity(messageType string) string {
+	switch messageType {
+	case "fatal", "error":
+		return models.SeverityError
+	case "warning":
+		return models.SeverityWarning
+	default:
+		return models.SeverityInfo
+	}
+}
+
+// mypyArgs configure mypy on top of the project's mypy.ini or [tool.mypy]
+// table. Dependencies are never installed in the workspace, so imports that
+// cannot be resolved are not reported, and nothing is cached between runs.
+var mypyArgs = []string{
+	"--show-column-numbers",
+	"--show-error-codes",
+	"--no-error-summary",
+	"--no-color-output",
+	"--ignore-missing-imports",
+	"--cache-dir", os.DevNull,
+}
+
+// runMypy type checks the given files with mypy.
+func (s *PythonAnalyzerService) runMypy(ctx context.Context, ws *workspace, paths []string) (map[string][]models.LineComment, []byte, error) {
+	args := append([]string(nil), mypyArgs...)
+	for _, path := range paths {
+		args = append(args, ws.relPath(path))
+	}
+	output, stderr, runErr := ws.run(ctx, s.mypyPath, args...)
+
+	// mypy exits with 1 when it reports errors and with 2 when it fails;
+	// errors that stop it, such as an invalid config, have no location.
+	findings, located := parseMypyOutput(ws, output)
+	if err := exitError(runErr, 1); err != nil || (runErr != nil && located == 0) {
+		return nil, append(stderr, output...), runErr
+	}
+	return findings, nil, nil
+}
+
+// mypyLine matches "path:line:col: error: message  [code]"; the column is
+// missing for errors mypy cannot place.
+var mypyLine = regexp.MustCompile(`^(.*?):(\d+)(?::(\d+))?: (error|warning|note): (.*?)(?:  \[([a-z0-9-]+)\])?$`)
+
+// parseMypyOutput groups mypy errors and warnings by original path and
+// returns them with the number of located diagnostics in the output. Notes
+// only add context to the error before them and are not reported.
+func parseMypyOutput(ws *workspace, output []byte) (findings map[string][]models.LineComment, located int) {
+	findings = make(map[string][]models.LineComment)
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		match := mypyLine.FindStringSubmatch(strings.TrimRight(scanner.Text(), "\r"))
+		if match == nil || match[4] == "note" {
+			continue
+		}
+		located++
+		path, ok := ws.originalPath(match[1])
+		if !ok {
+			continue
+		}
+		lineNumber, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+
+		comment := match[5]
+		if match[6] != "" {
+			comment = fmt.Sprintf("%s: %s", match[6], match[5])
+		}
+		severity := models.SeverityError
+		if match[4] == "warning" {
+			severity = models.SeverityWarning
+		}
+		findings[path] = append(findings[path], models.LineComment{
+			Line:     lineNumber,
+			Column:   column,
+			RuleID:   match[6],
+			Severity: severity,
+			Tool:     "mypy",
+			Comment:  comment,
+		})
+	}
+	return findings, located
+}
+
+// runBandit runs the bandit security checks over the given files.
+func (s *PythonAnalyzerService) runBandit(ctx context.Context, ws *workspace, paths []string) (map[string][]models.LineComment, []byte, error) {
+	args := append([]string{"-f", "json", "-q"}, banditConfigArgs(ws)...)
+	for _, path := range paths {
+		args = append(args, ws.relPath(path))
+	}
+	output, stderr, runErr := ws.run(ctx, s.banditPath, args...)
+
+	// bandit exits with 1 when it reports issues.
+	if err := exitError(runErr, 1); err != nil {
+		return nil, stderr, err
+	}
+	findings, err := parseBanditOutput(ws, output)
+	if err != nil {
+		return nil, stderr, err
+	}
+	return findings, nil, nil
+}
+
+type banditReport struct {
+	Results []struct {
+		Filename     string `json:"filename"`
+		LineNumber   int    `json:"line_number"`
+		ColOffset    int    `json:"col_offset"`
+		EndColOffset int    `json:"end_col_offset"`
+		LineRange    []int  `json:"line_range"`
+		TestID       string `json:"test_id"`
+		TestName     string `json:"test_name"`
+		Severity     string `json:"issue_severity"`
+		IssueText    string `json:"issue_text"`
+	} `json:"results"`
+}
+
+// parseBanditOutput groups the issues of "bandit -f json" by original path.
+// bandit columns are zero-based.
+func parseBanditOutput(ws *workspace, output []byte) (map[string][]models.LineComment, error) {
+	var report banditReport
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, fmt.Errorf("invalid bandit output: %w", err)
+	}
+
+	findings := make(map[string][]models.LineComment)
+	for _, issue := range report.Results {
+		path, ok := ws.originalPath(issue.Filename)
+		if !ok {
+			continue
+		}
+		lineComment := models.LineComment{
+			Line:     issue.LineNumber,
+			Column:   issue.ColOffset + 1,
+			RuleID:   issue.TestID,
+			Severity: banditSeverity(issue.Severity),
+			Tool:     "bandit",
+			Comment:  fmt.Sprintf("%s: %s (%s)", issue.TestID, issue.IssueText, issue.TestName),
+		}
+		if issue.EndColOffset > 0 && len(issue.LineRange) > 0 {
+			lineComment.EndLine = issue.LineRange[len(issue.LineRange)-1]
+			lineComment.EndColumn = issue.EndColOffset + 1
+		}
+		findings[path] = append(findings[path], lineComment)
+	}
+	return findings, nil
+}
+
+func banditSeverity(severity string) string {
+	switch severity {
+	case "HIGH":
+		return models.SeverityError
+	case "MEDIUM":
+		return models.SeverityWarning
+	default:
+		return models.SeverityInfo
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"python_analyzer_service/internal/models"
+)
+
+func newRunnersWorkspace(t *testing.T) *workspace {
+	t.Helper()
+	ws, err := newWorkspace([]models.FileInput{
+		{Path: "app/main.py", Content: "import os\n"},
+		{Path: "app/util.py", Content: ""},
+	})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+func TestParseRuffOutput(t *testing.T) {
+	ws := newRunnersWorkspace(t)
+	output := `[
+		{"code": "F401", "message": "` + "`os`" + ` imported but unused", "filename": "` + filepath.Join(ws.root, "main.py") + `",
+		 "location": {"row": 1, "column": 8}, "end_location": {"row": 1, "column": 10}},
+		{"code": null, "message": "SyntaxError: Expected an expression", "filename": "` + filepath.Join(ws.root, "util.py") + `",
+		 "location": {"row": 2, "column": 5}, "end_location": {"row": 2, "column": 6}},
+		{"code": "E501", "message": "Line too long", "filename": "/elsewhere/other.py",
+		 "location": {"row": 1, "column": 89}, "end_location": {"row": 1, "column": 120}}
+	]`
+
+	findings, err := parseRuffOutput(ws, []byte(output))
+	if err != nil {
+		t.Fatalf("parseRuffOutput() error = %v", err)
+	}
+	want := models.LineComment{Line: 1, Column: 8, EndLine: 1, EndColumn: 10, RuleID: "F401", Severity: models.SeverityError, Tool: "ruff", Comment: "F401: `os` imported but unused"}
+	if got := findings["app/main.py"]; len(got) != 1 || got[0] != want {
+		t.Errorf("parseRuffOutput() for app/main.py = %+v, want %+v", got, want)
+	}
+	if got := findings["app/util.py"]; len(got) != 1 || got[0].RuleID != "E999" || got[0].Severity != models.SeverityError {
+		t.Errorf("parseRuffOutput() for app/util.py = %+v, want a E999 syntax error", got)
+	}
+	if len(findings) != 2 {
+		t.Errorf("parseRuffOutput() returned findings for %d files, want 2", len(findings))
+	}
+
+	if _, err := parseRuffOutput(ws, []byte("error: unexpected argument")); err == nil {
+		t.Error("parseRuffOutput() on text output succeeded, want an error")
+	}
+}
+
+func TestParsePylintOutput(t *testing.T) {
+	ws := newRunnersWorkspace(t)
+	output := `[
+		{"type": "warning", "module": "main", "obj": "", "line": 1, "column": 0, "endLine": 1, "endColumn": 9,
+		 "path": "main.py", "symbol": "unused-import", "message": "Unused import os", "message-id": "W0611"},
+		{"type": "convention", "module": "util", "obj": "", "line": 1, "column": 0, "endLine": null, "endColumn": null,
+		 "path": "util.py", "symbol": "missing-module-docstring", "message": "Missing module docstring", "message-id": "C0114"}
+	]`
+
+	findings, err := parsePylintOutput(ws, []byte(output))
+	if err != nil {
+		t.Fatalf("parsePylintOutput() error = %v", err)
+	}
+	want := models.LineComment{Line: 1, Column: 1, EndLine: 1, EndColumn: 10, RuleID: "W0611", Severity: models.SeverityWarning, Tool: "pylint", Comment: "W0611: Unused import os (unused-import)"}
+	if got := findings["app/main.py"]; len(got) != 1 || got[0] != want {
+		t.Errorf("parsePylintOutput() for app/main.py = %+v, want %+v", got, want)
+	}
+	if got := findings["app/util.py"]; len(got) != 1 || got[0].EndColumn != 0 || got[0].Severity != models.SeverityInfo {
+		t.Errorf("parsePylintOutput() for app/util.py = %+v, want an info finding without end", got)
+	}
+
+	if findings, err := parsePylintOutput(ws, nil); err != nil || len(findings) != 0 {
+		t.Errorf("parsePylintOutput() on empty output = %v, %v, want no findings", findings, err)
+	}
+}
+
+func TestPylintError(t *testing.T) {
+	ws := newRunnersWorkspace(t)
+	for _, tt := range []struct {
+		code    string
+		wantErr bool
+	}{
+		{code: "0"},
+		{code: "4"},
+		{code: "30"},
+		{code: "32", wantErr: true},
+	} {
+		_, _, runErr := ws.run(context.Background(), "sh", "-c", "exit "+tt.code)
+		if err := pylintError(runErr); (err != nil) != tt.wantErr {
+			t.Errorf("pylintError(exit %s) = %v, wantErr %v", tt.code, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseMypyOutput(t *testing.T) {
+	ws := newRunnersWorkspace(t)
+	output := "main.py:3:5: error: Incompatible types in assignment (expression has type \"str\", variable has type \"int\")  [assignment]\n" +
+		"main.py:3:5: note: See https://mypy.readthedocs.io\n" +
+		"util.py:7: warning: Unused \"type: ignore\" comment  [unused-ignore]\n" +
+		"/elsewhere/other.py:1:1: error: Name \"x\" is not defined  [name-defined]\n"
+
+	findings, located := parseMypyOutput(ws, []byte(output))
+	if located != 3 {
+		t.Errorf("parseMypyOutput() located = %d, want 3", located)
+	}
+	want := models.LineComment{
+		Line: 3, Column: 5, RuleID: "assignment", Severity: models.SeverityError, Tool: "mypy",
+		Comment: "assignment: Incompatible types in assignment (expression has type \"str\", variable has type \"int\")",
+	}
+	if got := findings["app/main.py"]; len(got) != 1 || got[0] != want {
+		t.Errorf("parseMypyOutput() for app/main.py = %+v, want %+v", got, want)
+	}
+	if got := findings["app/util.py"]; len(got) != 1 || got[0].Line != 7 || got[0].Column != 0 || got[0].Severity != models.SeverityWarning {
+		t.Errorf("parseMypyOutput() for app/util.py = %+v, want a warning on line 7", got)
+	}
+}
+
+func TestParseBanditOutput(t *testing.T) {
+	ws := newRunnersWorkspace(t)
+	output := `{"errors": [], "results": [
+		{"filename": "main.py", "line_number": 4, "col_offset": 4, "end_col_offset": 30, "line_range": [4, 5],
+		 "test_id": "B602", "test_name": "subprocess_popen_with_shell_equals_true",
+		 "issue_severity": "HIGH", "issue_confidence": "HIGH", "issue_text": "subprocess call with shell=True identified"}
+	]}`
+
+	findings, err := parseBanditOutput(ws, []byte(output))
+	if err != nil {
+		t.Fatalf("parseBanditOutput() error = %v", err)
+	}
+	want := models.LineComment{
+		Line: 4, Column: 5, EndLine: 5, EndColumn: 31, RuleID: "B602", Severity: models.SeverityError, Tool: "bandit",
+		Comment: "B602: subprocess call with shell=True identified (subprocess_popen_with_shell_equals_true)",
+	}
+	if got := findings["app/main.py"]; len(got) != 1 || got[0] != want {
+		t.Errorf("parseBanditOutput() for app/main.py = %+v, want %+v", got, want)
+	}
+}
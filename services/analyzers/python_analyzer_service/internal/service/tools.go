@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"python_analyzer_service/internal/models"
+)
+
+// pythonTools are the tools the service can run, in the order their findings
+// take precedence when several tools report the same problem.
+var pythonTools = []string{"flake8", "ruff", "pylint", "mypy", "bandit"}
+
+// toolsFromEnv reads a comma-separated tool list from the environment,
+// falling back to the default when the variable is unset or names no known
+// tool.
+func toolsFromEnv(key string, fallback []string) []string {
+	if tools := knownTools(splitTools(os.Getenv(key))); len(tools) > 0 {
+		return tools
+	}
+	return fallback
+}
+
+// splitTools splits a comma-separated list of tool names.
+func splitTools(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// knownTools returns the known tools among names, each once and in the order
+// of pythonTools.
+func knownTools(names []string) []string {
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		selected[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	var tools []string
+	for _, tool := range pythonTools {
+		if selected[tool] {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+// selectTools returns the tools to run for a request: those of the request
+// options, else those the project enables in pyproject.toml, else the
+// service default.
+func (s *PythonAnalyzerService) selectTools(ws *workspace, opts *models.AnalyzeOptions) []string {
+	if opts != nil && len(opts.Tools) > 0 {
+		return knownTools(opts.Tools)
+	}
+	if tools := knownTools(projectTools(ws)); len(tools) > 0 {
+		return tools
+	}
+	return s.tools
+}
+
+// toolRun holds the findings of one tool over a batch by original path, or,
+// when the tool timed out or failed, the results that replace those of the
+// batch.
+type toolRun struct {
+	findings map[string][]models.LineComment
+	failed   []models.FileResult
+}
+
+// runTool runs one tool over the given files inside the workspace. flake8Args
+// are the project configuration and request option arguments of flake8; the
+// other tools read the project configuration themselves.
+func (s *PythonAnalyzerService) runTool(ctx context.Context, ws *workspace, tool string, flake8Args []string, paths []string) toolRun {
+	// Waiting for a free process slot does not count against the deadline.
+	if err := s.processes.acquire(ctx); err != nil {
+		return toolRun{failed: timeoutResults(paths)}
+	}
+	defer s.processes.release()
+
+	ctx, cancel := context.WithTimeout(ctx, s.fileTimeout*time.Duration(len(paths)))
+	defer cancel()
+
+	var findings map[string][]models.LineComment
+	var output []byte
+	var err error
+	switch tool {
+	case "flake8":
+		findings, output, err = s.runFlake8(ctx, ws, flake8Args, paths)
+	case "ruff":
+		findings, output, err = s.runRuff(ctx, ws, paths)
+	case "pylint":
+		findings, output, err = s.runPylint(ctx, ws, paths)
+	case "mypy":
+		findings, output, err = s.runMypy(ctx, ws, paths)
+	case "bandit":
+		findings, output, err = s.runBandit(ctx, ws, paths)
+	}
+
+	if ctx.Err() != nil {
+		return toolRun{failed: timeoutResults(paths)}
+	}
+	if err != nil {
+		return toolRun{failed: toolErrorResults(paths, tool, err, output)}
+	}
+	return toolRun{findings: findings}
+}
+
+// equivalentRules maps the pylint message IDs of checks flake8 and ruff also
+// implement to the flake8 code, so the same problem is reported once.
+var equivalentRules = map[string]string{
+	"C0301": "E501", // line-too-long
+	"C0303": "W291", // trailing-whitespace
+	"C0304": "W292", // missing-final-newline
+	"C0321": "E701", // multiple-statements
+	"C0410": "E401", // multiple-imports
+	"E0001": "E999", // syntax-error
+	"E0602": "F821", // undefined-variable
+	"W0401": "F403", // wildcard-import
+	"W0404": "F811", // reimported
+	"W0611": "F401", // unused-import
+	"W0612": "F841", // unused-variable
+	"W0702": "E722", // bare-except
+}
+
+// canonicalRule returns the rule ID under which a finding is de-duplicated.
+// ruff implements bandit's checks as S codes with bandit's numbers.
+func canonicalRule(ruleID string) string {
+	if code, ok := equivalentRules[ruleID]; ok {
+		return code
+	}
+	if len(ruleID) == 4 && ruleID[0] == 'S' && strings.Trim(ruleID[1:], "0123456789") == "" {
+		return "B" + ruleID[1:]
+	}
+	return ruleID
+}
+
+type findingKey struct {
+	line int
+	rule string
+}
+
+// mergeRuns builds one result per path, in order, from the runs of every
+// tool over the same batch, given in pythonTools order. A finding is dropped
+// when an earlier tool reported the same rule on the same line; findings of
+// one tool are all kept. A file is reported as timed out or failed when any
+// tool timed out or failed for it, the first such tool taking precedence.
+func mergeRuns(paths []string, runs []toolRun) []models.FileResult {
+	merged := make(map[string][]models.LineComment, len(paths))
+	for _, p := range paths {
+		seen := make(map[findingKey]bool)
+		var lineComments []models.LineComment
+		for _, run := range runs {
+			var added []findingKey
+			for _, lineComment := range run.findings[p] {
+				key := findingKey{lineComment.Line, canonicalRule(lineComment.RuleID)}
+				if key.rule != "" && seen[key] {
+					continue
+				}
+				lineComments = append(lineComments, lineComment)
+				added = append(added, key)
+			}
+			for _, key := range added {
+				seen[key] = true
+			}
+		}
+		sort.SliceStable(lineComments, func(a, b int) bool {
+			if lineComments[a].Line != lineComments[b].Line {
+				return lineComments[a].Line < lineComments[b].Line
+			}
+			return lineComments[a].Column < lineComments[b].Column
+		})
+		merged[p] = lineComments
+	}
+
+	results := fileResults(paths, merged)
+	failed := make(map[string]models.FileResult)
+	for _, run := range runs {
+		for _, result := range run.failed {
+			if _, ok := failed[result.Path]; !ok {
+				failed[result.Path] = result
+			}
+		}
+	}
+	for i := range results {
+		if result, ok := failed[results[i].Path]; ok {
+			results[i] = result
+		}
+	}
+	return results
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"python_analyzer_service/internal/models"
+)
+
+func TestKnownTools(t *testing.T) {
+	got := knownTools([]string{"bandit", " Ruff", "black", "flake8", "ruff"})
+	want := []string{"flake8", "ruff", "bandit"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("knownTools() = %v, want %v", got, want)
+	}
+
+	t.Setenv("PYTHON_TOOLS", "mypy, pylint")
+	if got, want := toolsFromEnv("PYTHON_TOOLS", []string{"flake8"}), []string{"pylint", "mypy"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("toolsFromEnv() = %v, want %v", got, want)
+	}
+	t.Setenv("PYTHON_TOOLS", "black")
+	if got, want := toolsFromEnv("PYTHON_TOOLS", []string{"flake8"}), []string{"flake8"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("toolsFromEnv() with no known tool = %v, want %v", got, want)
+	}
+}
+
+func TestPythonAnalyzerService_SelectTools(t *testing.T) {
+	service := NewPythonAnalyzerService()
+	service.tools = []string{"flake8"}
+
+	plain, err := newWorkspace([]models.FileInput{{Path: "main.py"}})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer plain.Close()
+
+	configured, err := newWorkspace([]models.FileInput{
+		{Path: "main.py"},
+		{Path: "pyproject.toml", Content: "[tool.analyzer]\ntools = [\n    \"mypy\",\n    \"ruff\",\n]\n"},
+	})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer configured.Close()
+
+	tests := []struct {
+		name string
+		ws   *workspace
+		opts *models.AnalyzeOptions
+		want []string
+	}{
+		{name: "service default", ws: plain, want: []string{"flake8"}},
+		{name: "project", ws: configured, want: []string{"ruff", "mypy"}},
+		{name: "request", ws: configured, opts: &models.AnalyzeOptions{Tools: []string{"bandit"}}, want: []string{"bandit"}},
+		{name: "request without tools", ws: configured, opts: &models.AnalyzeOptions{MinSeverity: models.SeverityError}, want: []string{"ruff", "mypy"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := service.selectTools(tt.ws, tt.opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectTools() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeRuns(t *testing.T) {
+	runs := []toolRun{
+		{findings: map[string][]models.LineComment{
+			"a.py": {
+				{Line: 3, Column: 80, RuleID: "E501", Tool: "flake8"},
+				{Line: 1, Column: 1, RuleID: "F401", Tool: "flake8"},
+				{Line: 1, Column: 12, RuleID: "F401", Tool: "flake8"},
+			},
+		}},
+		{findings: map[string][]models.LineComment{
+			"a.py": {
+				{Line: 1, Column: 8, RuleID: "F401", Tool: "ruff"},
+				{Line: 2, Column: 1, RuleID: "S101", Tool: "ruff"},
+			},
+		}},
+		{findings: map[string][]models.LineComment{
+			"a.py": {
+				{Line: 3, Column: 1, RuleID: "C0301", Tool: "pylint"},
+				{Line: 4, Column: 1, RuleID: "W0612", Tool: "pylint"},
+			},
+		}},
+		{findings: map[string][]models.LineComment{
+			"a.py": {{Line: 2, Column: 1, RuleID: "B101", Tool: "bandit"}},
+		}, failed: []models.FileResult{
+			{Path: "b.py", Status: models.StatusTimeout},
+		}},
+	}
+
+	results := mergeRuns([]string{"a.py", "b.py"}, runs)
+
+	var got []string
+	for _, lineComment := range results[0].LineComments {
+		got = append(got, fmt.Sprintf("%d:%d %s %s", lineComment.Line, lineComment.Column, lineComment.RuleID, lineComment.Tool))
+	}
+	want := []string{
+		"1:1 F401 flake8",
+		"1:12 F401 flake8",
+		"2:1 S101 ruff",
+		"3:80 E501 flake8",
+		"4:1 W0612 pylint",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeRuns() findings = %v, want %v", got, want)
+	}
+	if results[0].Status != models.StatusIssues {
+		t.Errorf("mergeRuns() status = %q, want %q", results[0].Status, models.StatusIssues)
+	}
+	if results[1].Status != models.StatusTimeout {
+		t.Errorf("mergeRuns() status of a timed out file = %q, want %q", results[1].Status, models.StatusTimeout)
+	}
+}
+
+func TestPythonAnalyzerService_MultipleTools(t *testing.T) {
+	dir := t.TempDir()
+	scripts := map[string]string{
+		"flake8": `for last; do :; done
+echo "$last:1:1: F401 'os' imported but unused"
+exit 1
+`,
+		"ruff": `for last; do :; done
+echo "[{\"code\": \"F401\", \"message\": \"os imported but unused\", \"filename\": \"$PWD/$last\", \"location\": {\"row\": 1, \"column\": 8}, \"end_location\": {\"row\": 1, \"column\": 10}},
+{\"code\": \"S101\", \"message\": \"Use of assert detected\", \"filename\": \"$PWD/$last\", \"location\": {\"row\": 2, \"column\": 1}, \"end_location\": {\"row\": 2, \"column\": 7}}]"
+exit 1
+`,
+		"mypy": `echo "mypy: error: Cannot find implementation or library stub" >&2
+exit 2
+`,
+	}
+	service := NewPythonAnalyzerService()
+	for tool, body := range scripts {
+		script := filepath.Join(dir, tool)
+		content := "#!/bin/sh\nif [ \"$1\" = \"--version\" ]; then echo 1.0; exit 0; fi\n" + body
+		if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+			t.Fatalf("failed to write fake %s: %v", tool, err)
+		}
+	}
+	service.flake8Path = filepath.Join(dir, "flake8")
+	service.ruffPath = filepath.Join(dir, "ruff")
+	service.mypyPath = filepath.Join(dir, "mypy")
+
+	resp, err := service.Analyze(context.Background(), &models.AnalyzeRequest{
+		Files:   []models.FileInput{{Path: "main.py", Content: "import os\nassert os\n"}},
+		Options: &models.AnalyzeOptions{Tools: []string{"ruff", "flake8"}},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	got := resp.Files[0].LineComments
+	if len(got) != 2 || got[0].Tool != "flake8" || got[0].RuleID != "F401" || got[1].Tool != "ruff" || got[1].RuleID != "S101" {
+		t.Errorf("Analyze() line comments = %+v, want F401 from flake8 and S101 from ruff", got)
+	}
+
+	resp, err = service.Analyze(context.Background(), &models.AnalyzeRequest{
+		Files:   []models.FileInput{{Path: "main.py", Content: "import os\n"}},
+		Options: &models.AnalyzeOptions{Tools: []string{"flake8", "mypy"}},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if got := resp.Files[0]; got.Status != models.StatusToolError || got.Stderr == "" {
+		t.Errorf("Analyze() with a failing mypy = %+v, want a tool error with stderr", got)
+	}
+
+	if _, err := service.Analyze(context.Background(), &models.AnalyzeRequest{
+		Files:   []models.FileInput{{Path: "main.py"}},
+		Options: &models.AnalyzeOptions{Tools: []string{"black"}},
+	}); err == nil {
+		t.Error("Analyze() with an unknown tool succeeded, want ErrInvalidOptions")
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"python_analyzer_service/internal/models"
+)
+
+func TestRelativePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "pkg/module.py", want: "pkg/module.py"},
+		{path: "./pkg/../module.py", want: "module.py"},
+		{path: "/etc/passwd", want: "etc/passwd"},
+		{path: "../../escape.py", want: "escape.py"},
+		{path: `C:\src\app.py`, want: "src/app.py"},
+		{path: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := relativePath(tt.path); got != tt.want {
+				t.Errorf("relativePath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkspace(t *testing.T) {
+	ws, err := newWorkspace([]models.FileInput{
+		{Path: "pkg/__init__.py", Content: ""},
+		{Path: "pkg/module.py", Content: "x = 1\n"},
+		{Path: "../outside.py", Content: "y = 2\n"},
+	})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(ws.tree, "pkg", "module.py"))
+	if err != nil || string(content) != "x = 1\n" {
+		t.Errorf("pkg/module.py = %q, %v, want the request content", content, err)
+	}
+	if _, err := os.Stat(filepath.Join(ws.tree, "outside.py")); err != nil {
+		t.Errorf("../outside.py should be written inside the workspace: %v", err)
+	}
+
+	if got, ok := ws.originalPath("outside.py"); !ok || got != "../outside.py" {
+		t.Errorf("originalPath() = %q, %v, want ../outside.py", got, ok)
+	}
+	if _, ok := ws.originalPath(filepath.Join(ws.dir, "flake8.cfg")); ok {
+		t.Error("originalPath() should reject paths outside the workspace")
+	}
+
+	if ws.root != ws.tree {
+		t.Errorf("root = %q, want the tree itself when files do not share a top-level directory", ws.root)
+	}
+	if got := ws.relPath("pkg/module.py"); got != filepath.Join("pkg", "module.py") {
+		t.Errorf("relPath() = %q, want pkg/module.py", got)
+	}
+
+	if err := ws.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := os.Stat(ws.dir); !os.IsNotExist(err) {
+		t.Errorf("Close() should remove the workspace directory, stat error = %v", err)
+	}
+}
+
+func TestWorkspace_CommonTopDir(t *testing.T) {
+	ws, err := newWorkspace([]models.FileInput{
+		{Path: "repo/setup.cfg", Content: "[flake8]\n"},
+		{Path: "repo/pkg/module.py", Content: "x = 1\n"},
+	})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer ws.Close()
+
+	if want := filepath.Join(ws.tree, "repo"); ws.root != want {
+		t.Errorf("root = %q, want %q", ws.root, want)
+	}
+	if got := ws.relPath("repo/pkg/module.py"); got != filepath.Join("pkg", "module.py") {
+		t.Errorf("relPath() = %q, want pkg/module.py", got)
+	}
+	if got, ok := ws.originalPath("pkg/module.py"); !ok || got != "repo/pkg/module.py" {
+		t.Errorf("originalPath() = %q, %v, want repo/pkg/module.py", got, ok)
+	}
+	if got := ws.findConfig(".flake8", "setup.cfg"); got != "setup.cfg" {
+		t.Errorf("findConfig() = %q, want setup.cfg", got)
+	}
+}
@@ -0,0 +1,95 @@
+package models
+
+// Statuses of a file result: the tool ran and found nothing (ok) or
+// findings (issues), it failed (tool_error), the file is not one the
+// analyzer handles (unsupported), or the analysis did not finish in time.
+const (
+	StatusOK          = "ok"
+	StatusIssues      = "issues"
+	StatusToolError   = "tool_error"
+	StatusUnsupported = "unsupported"
+	StatusTimeout     = "timeout"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+type AnalyzeRequest struct {
+	Files   []FileInput     `json:"files"`
+	Options *AnalyzeOptions `json:"options,omitempty"`
+}
+
+// AnalyzeOptions tunes which findings are reported. EnableRules restricts the
+// report to the listed rules, DisableRules drops rules, MinSeverity drops
+// findings below the given severity and MaxFindings caps findings per file.
+type AnalyzeOptions struct {
+	EnableRules  []string `json:"enable_rules,omitempty"`
+	DisableRules []string `json:"disable_rules,omitempty"`
+	MinSeverity  string   `json:"min_severity,omitempty"`
+	MaxFindings  int      `json:"max_findings,omitempty"`
+}
+
+type FileInput struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+type AnalyzeResponse struct {
+	Files []FileResult `json:"files"`
+}
+
+type FileResult struct {
+	Path         string        `json:"path"`
+	Status       string        `json:"status,omitempty"`
+	Comment      string        `json:"comment"`
+	Stderr       string        `json:"stderr,omitempty"`
+	LineComments []LineComment `json:"line_comments"`
+}
+
+// LineComment carries the common finding fields plus the fix shellcheck
+// suggests for the finding, if any.
+type LineComment struct {
+	Line      int    `json:"line"`
+	Column    int    `json:"column,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+	EndColumn int    `json:"end_column,omitempty"`
+	RuleID    string `json:"rule_id,omitempty"`
+	Severity  string `json:"severity,omitempty"`
+	Tool      string `json:"tool,omitempty"`
+	Comment   string `json:"comment"`
+	Fix       *Fix   `json:"fix,omitempty"`
+}
+
+// Fix is an automatic fix: applying all of its replacements resolves the
+// finding.
+type Fix struct {
+	Replacements []Replacement `json:"replacements"`
+}
+
+// Replacement replaces the text from Line:Column up to EndLine:EndColumn
+// (exclusive; equal positions insert) with Text. Columns count characters,
+// with a tab as one. InsertionPoint ("beforeStart" or "afterEnd") and
+// Precedence are shellcheck's, and order replacements that touch the same
+// position.
+type Replacement struct {
+	Line           int    `json:"line"`
+	Column         int    `json:"column"`
+	EndLine        int    `json:"end_line"`
+	EndColumn      int    `json:"end_column"`
+	Text           string `json:"text"`
+	InsertionPoint string `json:"insertion_point"`
+	Precedence     int    `json:"precedence"`
+}
+
+// CacheStats describes the result cache of an analyzer service.
+type CacheStats struct {
+	Enabled    bool   `json:"enabled"`
+	Persistent bool   `json:"persistent"`
+	Entries    int    `json:"entries"`
+	Capacity   int    `json:"capacity"`
+	Hits       uint64 `json:"hits"`
+	Misses     uint64 `json:"misses"`
+}
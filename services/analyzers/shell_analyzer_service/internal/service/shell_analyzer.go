@@ -0,0 +1,302 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"shell_analyzer_service/internal/models"
+)
+
+const (
+	defaultFileTimeout    = 30 * time.Second
+	defaultRequestTimeout = 2 * time.Minute
+
+	// waitDelay bounds how long we wait for output pipes after the tool
+	// process has been killed on cancellation.
+	waitDelay = 2 * time.Second
+)
+
+type ShellAnalyzerService struct {
+	shellcheckPath string
+	fileTimeout    time.Duration
+	requestTimeout time.Duration
+	maxWorkers     int
+	processes      processLimiter
+	cache          *resultCache
+
+	versionOnce sync.Once
+	version     string
+	versionOK   bool
+}
+
+func NewShellAnalyzerService() *ShellAnalyzerService {
+	shellcheckPath := os.Getenv("SHELLCHECK_PATH")
+	if shellcheckPath == "" {
+		shellcheckPath = "shellcheck"
+	}
+
+	return &ShellAnalyzerService{
+		shellcheckPath: shellcheckPath,
+		fileTimeout:    durationFromEnv("ANALYZER_FILE_TIMEOUT", defaultFileTimeout),
+		requestTimeout: durationFromEnv("ANALYZER_REQUEST_TIMEOUT", defaultRequestTimeout),
+		maxWorkers:     intFromEnv("MAX_WORKERS", runtime.NumCPU()),
+		processes:      newProcessLimiter(intFromEnv("MAX_PROCESSES", runtime.NumCPU())),
+		cache:          cacheFromEnv(),
+	}
+}
+
+// durationFromEnv parses a Go duration (e.g. "30s") from the environment,
+// falling back to the default when the variable is unset or invalid.
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// intFromEnv parses a positive integer from the environment, falling back to
+// the default when the variable is unset or invalid.
+func intFromEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// shellExtensions are the extensions of the scripts shellcheck checks.
+var shellExtensions = map[string]bool{".sh": true, ".bash": true, ".dash": true, ".ksh": true}
+
+// shellShebang matches the interpreter line of a shell script, e.g.
+// "#!/bin/bash", "#!/bin/sh -e" or "#!/usr/bin/env zsh".
+var shellShebang = regexp.MustCompile(`^#!\s*/(?:usr/)?bin/(?:env\s+)?(?:ba|da|k|z)?sh(?:\s|$)`)
+
+// isShellScript reports whether the analyzer handles the file: a script
+// with a shell extension, or a file without extension whose shebang names
+// a shell.
+func isShellScript(file models.FileInput) bool {
+	if ext := strings.ToLower(filepath.Ext(file.Path)); ext != "" {
+		return shellExtensions[ext]
+	}
+	line, _, _ := strings.Cut(file.Content, "\n")
+	return shellShebang.MatchString(strings.TrimRight(line, "\r"))
+}
+
+func (s *ShellAnalyzerService) Analyze(ctx context.Context, req *models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
+	if err := validateOptions(req.Options); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	results := make([]models.FileResult, len(req.Files))
+	var pending []int
+
+	for i, file := range req.Files {
+		if !isShellScript(file) {
+			results[i] = models.FileResult{
+				Path:         file.Path,
+				Status:       models.StatusUnsupported,
+				Comment:      "Not a shell script",
+				LineComments: []models.LineComment{},
+			}
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	keys := s.cacheKeys(req, pending)
+	pending = s.cache.lookup(keys, req.Files, results, pending)
+	if len(pending) == 0 {
+		return &models.AnalyzeResponse{Files: results}, nil
+	}
+
+	// Every file of the request is written to the workspace, so sourced files and the project's .shellcheckrc are found.
+	ws, err := newWorkspace(req.Files)
+	if err != nil {
+		for _, i := range pending {
+			results[i] = models.FileResult{
+				Path:         req.Files[i].Path,
+				Status:       models.StatusToolError,
+				Comment:      fmt.Sprintf("Error: failed to create workspace: %v", err),
+				LineComments: []models.LineComment{},
+			}
+		}
+		return &models.AnalyzeResponse{Files: results}, nil
+	}
+	defer ws.Close()
+
+	groups := batches(pending, s.maxWorkers)
+	forEach(len(groups), s.maxWorkers, func(g int) {
+		paths := make([]string, len(groups[g]))
+		for j, i := range groups[g] {
+			paths[j] = req.Files[i].Path
+		}
+		for j, result := range s.analyzeBatch(ctx, ws, paths) {
+			results[groups[g][j]] = result
+		}
+	})
+
+	for i := range results {
+		results[i] = applyOptions(results[i], req.Options)
+	}
+	s.cache.store(keys, results, pending)
+
+	return &models.AnalyzeResponse{Files: results}, nil
+}
+
+// analyzeBatch runs shellcheck once over the given files inside the
+// workspace and returns one result per path, in the same order.
+func (s *ShellAnalyzerService) analyzeBatch(ctx context.Context, ws *workspace, paths []string) []models.FileResult {
+	// Waiting for a free process slot does not count against the deadline.
+	if err := s.processes.acquire(ctx); err != nil {
+		return timeoutResults(paths)
+	}
+	defer s.processes.release()
+
+	ctx, cancel := context.WithTimeout(ctx, s.fileTimeout*time.Duration(len(paths)))
+	defer cancel()
+
+	// Sourced files are followed, relative to the project root and to the
+	// sourcing script.
+	args := []string{"--format=json1", "--external-sources", "--source-path=SCRIPTDIR"}
+	for _, path := range paths {
+		args = append(args, ws.relPath(path))
+	}
+	output, stderr, runErr := ws.run(ctx, s.shellcheckPath, args...)
+
+	if ctx.Err() != nil {
+		return timeoutResults(paths)
+	}
+
+	// shellcheck exits with 1 when it reports problems; anything else, e.g.
+	// an invalid .shellcheckrc, means it did not check the files.
+	err := exitError(runErr, 1)
+	var findings map[string][]models.LineComment
+	if err == nil {
+		findings, err = s.parseOutput(ws, output)
+	}
+	if err != nil {
+		if len(bytes.TrimSpace(stderr)) == 0 {
+			stderr = output
+		}
+		return toolErrorResults(paths, "shellcheck", err, stderr)
+	}
+
+	return fileResults(paths, findings)
+}
+
+// shellcheckReport mirrors shellcheck's json1 format, which counts a tab as
+// one column.
+type shellcheckReport struct {
+	Comments []shellcheckComment `json:"comments"`
+}
+
+type shellcheckComment struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	EndLine   int    `json:"endLine"`
+	Column    int    `json:"column"`
+	EndColumn int    `json:"endColumn"`
+	Level     string `json:"level"`
+	Code      int    `json:"code"`
+	Message   string `json:"message"`
+	Fix       *struct {
+		Replacements []shellcheckReplacement `json:"replacements"`
+	} `json:"fix"`
+}
+
+type shellcheckReplacement struct {
+	Line           int    `json:"line"`
+	Column         int    `json:"column"`
+	EndLine        int    `json:"endLine"`
+	EndColumn      int    `json:"endColumn"`
+	Replacement    string `json:"replacement"`
+	InsertionPoint string `json:"insertionPoint"`
+	Precedence     int    `json:"precedence"`
+}
+
+// parseOutput decodes a shellcheck json1 report and groups the findings by
+// the original path of the file they were reported for, with the SC code as
+// rule and the fix shellcheck suggests, if any. Output that is not a report
+// means shellcheck failed.
+func (s *ShellAnalyzerService) parseOutput(ws *workspace, output []byte) (map[string][]models.LineComment, error) {
+	findings := make(map[string][]models.LineComment)
+
+	var report shellcheckReport
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, fmt.Errorf("invalid JSON report: %w", err)
+	}
+
+	for _, comment := range report.Comments {
+		path, ok := ws.originalPath(comment.File)
+		if !ok {
+			continue
+		}
+		lineComment := models.LineComment{
+			Line:      comment.Line,
+			Column:    comment.Column,
+			EndLine:   comment.EndLine,
+			EndColumn: comment.EndColumn,
+			RuleID:    fmt.Sprintf("SC%d", comment.Code),
+			Severity:  shellcheckSeverity(comment.Level),
+			Tool:      "shellcheck",
+			Comment:   comment.Message,
+		}
+		if comment.Fix != nil && len(comment.Fix.Replacements) > 0 {
+			lineComment.Fix = &models.Fix{Replacements: make([]models.Replacement, len(comment.Fix.Replacements))}
+			for i, r := range comment.Fix.Replacements {
+				lineComment.Fix.Replacements[i] = models.Replacement{
+					Line:           r.Line,
+					Column:         r.Column,
+					EndLine:        r.EndLine,
+					EndColumn:      r.EndColumn,
+					Text:           r.Replacement,
+					InsertionPoint: r.InsertionPoint,
+					Precedence:     r.Precedence,
+				}
+			}
+		}
+		findings[path] = append(findings[path], lineComment)
+	}
+
+	return findings, nil
+}
+
+// shellcheckSeverity maps shellcheck levels onto error/warning/info; style
+// suggestions are reported as info.
+func shellcheckSeverity(level string) string {
+	switch level {
+	case "error":
+		return models.SeverityError
+	case "warning":
+		return models.SeverityWarning
+	default:
+		return models.SeverityInfo
+	}
+}
+
+func timeoutResults(paths []string) []models.FileResult {
+	results := make([]models.FileResult, len(paths))
+	for i, path := range paths {
+		results[i] = models.FileResult{
+			Path:         path,
+			Status:       models.StatusTimeout,
+			Comment:      "Analysis timed out",
+			LineComments: []models.LineComment{},
+		}
+	}
+	return results
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"shell_analyzer_service/internal/models"
+)
+
+// shellcheck runs from the project root, the directory the files share, and
+// reports paths as they were passed to it.
+const shellcheckOutput = `{"comments":[
+{"file":"deploy.sh","line":3,"endLine":3,"column":6,"endColumn":11,"level":"info","code":2086,"message":"Double quote to prevent globbing and word splitting.","fix":{"replacements":[{"column":6,"endColumn":6,"endLine":3,"insertionPoint":"afterEnd","line":3,"precedence":7,"replacement":"\""},{"column":11,"endColumn":11,"endLine":3,"insertionPoint":"beforeStart","line":3,"precedence":7,"replacement":"\""}]}},
+{"file":"deploy.sh","line":5,"endLine":5,"column":1,"endColumn":9,"level":"style","code":2164,"message":"Use 'cd ... || exit' in case cd fails.","fix":null},
+{"file":"bin/run","line":2,"endLine":2,"column":9,"endColumn":10,"level":"error","code":1037,"message":"Braces are required for positionals over 9, e.g. ${10}."},
+{"file":"/elsewhere/lib.sh","line":1,"endLine":1,"column":1,"endColumn":2,"level":"warning","code":2034,"message":"x appears unused."}
+]}`
+
+func TestShellAnalyzerService_ParseOutput(t *testing.T) {
+	service := NewShellAnalyzerService()
+
+	ws, err := newWorkspace([]models.FileInput{
+		{Path: "scripts/deploy.sh", Content: ""},
+		{Path: "scripts/bin/run", Content: ""},
+	})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer ws.Close()
+
+	findings, err := service.parseOutput(ws, []byte(shellcheckOutput))
+	if err != nil {
+		t.Fatalf("parseOutput() error = %v", err)
+	}
+
+	deploy := findings["scripts/deploy.sh"]
+	if len(deploy) != 2 {
+		t.Fatalf("parseOutput() returned %d findings for scripts/deploy.sh, want 2", len(deploy))
+	}
+	want := models.LineComment{
+		Line: 3, Column: 6, EndLine: 3, EndColumn: 11,
+		RuleID: "SC2086", Severity: models.SeverityInfo, Tool: "shellcheck",
+		Comment: "Double quote to prevent globbing and word splitting.",
+		Fix: &models.Fix{Replacements: []models.Replacement{
+			{Line: 3, Column: 6, EndLine: 3, EndColumn: 6, Text: `"`, InsertionPoint: "afterEnd", Precedence: 7},
+			{Line: 3, Column: 11, EndLine: 3, EndColumn: 11, Text: `"`, InsertionPoint: "beforeStart", Precedence: 7},
+		}},
+	}
+	if !reflect.DeepEqual(deploy[0], want) {
+		t.Errorf("finding = %+v, want %+v", deploy[0], want)
+	}
+	if deploy[1].RuleID != "SC2164" || deploy[1].Severity != models.SeverityInfo || deploy[1].Fix != nil {
+		t.Errorf("finding = %+v, want a style finding without fix reported as info", deploy[1])
+	}
+
+	run := findings["scripts/bin/run"]
+	if len(run) != 1 || run[0].RuleID != "SC1037" || run[0].Severity != models.SeverityError || run[0].Column != 9 {
+		t.Errorf("parseOutput() for scripts/bin/run = %+v, want SC1037 as error at column 9", run)
+	}
+	if len(findings) != 2 {
+		t.Errorf("parseOutput() returned findings for %d files, want 2", len(findings))
+	}
+
+	if _, err := service.parseOutput(ws, []byte("shellcheck: invalid option")); err == nil {
+		t.Error("parseOutput() of output that is not a report succeeded, want an error")
+	}
+}
+
+func TestIsShellScript(t *testing.T) {
+	tests := []struct {
+		file models.FileInput
+		want bool
+	}{
+		{file: models.FileInput{Path: "deploy.sh"}, want: true},
+		{file: models.FileInput{Path: "lib/common.BASH"}, want: true},
+		{file: models.FileInput{Path: "bin/run", Content: "#!/bin/bash\nset -e\n"}, want: true},
+		{file: models.FileInput{Path: "bin/start", Content: "#!/bin/sh -e\r\n"}, want: true},
+		{file: models.FileInput{Path: "bin/zrun", Content: "#!/usr/bin/env zsh\n"}, want: true},
+		{file: models.FileInput{Path: "bin/tool", Content: "#!/usr/bin/env python3\n"}, want: false},
+		{file: models.FileInput{Path: "bin/shell", Content: "#!/bin/shell\n"}, want: false},
+		{file: models.FileInput{Path: "Makefile", Content: "all:\n"}, want: false},
+		{file: models.FileInput{Path: "notes.txt", Content: "#!/bin/sh\n"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file.Path, func(t *testing.T) {
+			if got := isShellScript(tt.file); got != tt.want {
+				t.Errorf("isShellScript(%q) = %v, want %v", tt.file.Path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShellAnalyzerService_Analyze(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "shellcheck")
+	content := `#!/bin/sh
+if [ "$1" = "--version" ]; then echo "version: 0.10.0"; exit 0; fi
+for last; do :; done
+echo '{"comments":[{"file":"'"$last"'","line":2,"endLine":2,"column":6,"endColumn":8,"level":"info","code":2086,"message":"Double quote to prevent globbing and word splitting.","fix":{"replacements":[{"column":6,"endColumn":6,"endLine":2,"insertionPoint":"afterEnd","line":2,"precedence":7,"replacement":"\""},{"column":8,"endColumn":8,"endLine":2,"insertionPoint":"beforeStart","line":2,"precedence":7,"replacement":"\""}]}}]}'
+exit 1
+`
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("failed to write fake shellcheck: %v", err)
+	}
+	service := NewShellAnalyzerService()
+	service.shellcheckPath = script
+
+	resp, err := service.Analyze(context.Background(), &models.AnalyzeRequest{
+		Files: []models.FileInput{
+			{Path: "bin/run", Content: "#!/bin/sh\necho $1\n"},
+			{Path: "README.md", Content: "# Tools\n"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	got := resp.Files[0]
+	if got.Status != models.StatusIssues || len(got.LineComments) != 1 {
+		t.Fatalf("Analyze() result = %+v, want the SC2086 finding", got)
+	}
+	if lineComment := got.LineComments[0]; lineComment.RuleID != "SC2086" || lineComment.Fix == nil || len(lineComment.Fix.Replacements) != 2 {
+		t.Errorf("finding = %+v, want SC2086 with its two replacements", lineComment)
+	}
+	if resp.Files[1].Status != models.StatusUnsupported {
+		t.Errorf("Analyze() status of README.md = %q, want %q", resp.Files[1].Status, models.StatusUnsupported)
+	}
+}
+
+func TestShellAnalyzerService_AnalyzeToolError(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "shellcheck")
+	content := "#!/bin/sh\necho 'Failure parsing .shellcheckrc' >&2\nexit 4\n"
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("failed to write fake shellcheck: %v", err)
+	}
+	service := NewShellAnalyzerService()
+	service.shellcheckPath = script
+
+	resp, err := service.Analyze(context.Background(), &models.AnalyzeRequest{
+		Files: []models.FileInput{{Path: "deploy.sh", Content: "#!/bin/sh\necho hi\n"}},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if got := resp.Files[0]; got.Status != models.StatusToolError || got.Stderr == "" {
+		t.Errorf("Analyze() result = %+v, want a tool error with stderr", got)
+	}
+}
@@ -0,0 +1,12 @@
+package controller
+
+import (
+	"github.com/gorilla/mux"
+)
+
+func NewRouter(analyzerController *AnalyzerController) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/analyzer/yaml", analyzerController.Analyze).Methods("POST")
+	router.HandleFunc("/api/analyzer/yaml/cache", analyzerController.CacheStats).Methods("GET")
+	return router
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"os"
+	"strings"
+)
+
+// yamllintConfigFiles are the project configurations we look for, relative
+// to the project root, in order of preference. They are the files yamllint
+// itself reads from the working directory.
+var yamllintConfigFiles = []string{
+	".yamllint",
+	".yamllint.yaml",
+	".yamllint.yml",
+}
+
+// yamllintPresets are the configurations bundled with yamllint. "default"
+// reports duplicate keys, indentation and truthy values such as "yes" or
+// "on"; "relaxed" still reports duplicate keys as errors, downgrades
+// indentation and other layout rules to warnings and does not check truthy
+// values, comments or document start markers.
+var yamllintPresets = map[string]bool{
+	"default": true,
+	"relaxed": true,
+}
+
+const defaultPreset = "default"
+
+// presetFromEnv reads the bundled configuration used for projects without
+// their own from the environment, falling back to the default when the
+// variable is unset or names no bundled configuration.
+func presetFromEnv(key string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); yamllintPresets[value] {
+		return value
+	}
+	return defaultPreset
+}
+
+// configArgs returns the yamllint arguments that select its configuration:
+// the project's own when it has one, the configured bundled one otherwise.
+func (s *YAMLAnalyzerService) configArgs(ws *workspace) []string {
+	if name := ws.findConfig(yamllintConfigFiles...); name != "" {
+		return []string{"--config-file", name}
+	}
+	return []string{"--config-data", s.preset}
+}
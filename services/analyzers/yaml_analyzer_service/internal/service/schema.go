@@ -0,0 +1,515 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/xeipuuv/gojsonschema"
+	"gopkg.in/yaml.v3"
+	"yaml_analyzer_service/internal/models"
+)
+
+// documentSchema is the JSON Schema the documents of a file are validated
+// against. It is shared by all files of a request that use the same schema,
+// so the schema is compiled once per request.
+type documentSchema struct {
+	source string // where the schema comes from, used in messages
+	line   int    // line of the modeline declaring the schema, 0 for mappings
+	id     string // the URL its references are resolved against
+	loader gojsonschema.JSONLoader
+	refs   map[string]gojsonschema.JSONLoader // the schemas it refers to, by id
+
+	// problem explains why a declared schema cannot be used; the documents
+	// are then not validated.
+	problem  string
+	severity string
+	ruleID   string
+
+	schema     *gojsonschema.Schema
+	compileErr error
+}
+
+// cleanPath normalizes a submitted path so references between files of a
+// request can be compared.
+func cleanPath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(p, "\\", "/")), "/")
+}
+
+// validateSchemas checks the schema mappings of a request. Inline schemas are
+// compiled here so a broken schema is reported once as a bad request. They
+// are resolved against the request root.
+func validateSchemas(req *models.AnalyzeRequest) (map[int]*documentSchema, error) {
+	files := requestFiles(req)
+	sources := schemaSources{files: files}
+	mapped := make(map[int]*documentSchema, len(req.Schemas))
+
+	for i, mapping := range req.Schemas {
+		for _, pattern := range mapping.Files {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("%w: schemas[%d]: invalid file pattern %q", ErrInvalidOptions, i, pattern)
+			}
+		}
+
+		switch {
+		case len(mapping.Schema) > 0 && mapping.Ref != "":
+			return nil, fmt.Errorf("%w: schemas[%d]: set either schema or ref, not both", ErrInvalidOptions, i)
+		case len(mapping.Schema) > 0:
+			schema := &documentSchema{source: fmt.Sprintf("schemas[%d]", i), id: fileSchemaID(""), loader: gojsonschema.NewBytesLoader(mapping.Schema)}
+			schema.resolveRefs(sources)
+			schema.compile()
+			if schema.compileErr != nil {
+				return nil, fmt.Errorf("%w: schemas[%d]: %v", ErrInvalidOptions, i, schema.compileErr)
+			}
+			mapped[i] = schema
+		case mapping.Ref != "":
+			content, ok := files[cleanPath(mapping.Ref)]
+			if !ok {
+				return nil, fmt.Errorf("%w: schemas[%d]: ref %q is not a file of the request", ErrInvalidOptions, i, mapping.Ref)
+			}
+			mapped[i] = schemaFile(mapping.Ref, mapping.Ref, content, sources)
+		default:
+			return nil, fmt.Errorf("%w: schemas[%d]: schema or ref is required", ErrInvalidOptions, i)
+		}
+	}
+
+	return mapped, nil
+}
+
+func requestFiles(req *models.AnalyzeRequest) map[string]string {
+	files := make(map[string]string, len(req.Files))
+	for _, file := range req.Files {
+		files[cleanPath(file.Path)] = file.Content
+	}
+	return files
+}
+
+// schemaFile reads a schema from the submitted file at filePath. A file that
+// does not parse is reported when a document is validated against it.
+func schemaFile(source, filePath, content string, sources schemaSources) *documentSchema {
+	schema := &documentSchema{source: source, id: fileSchemaID(filePath)}
+	schema.loader, schema.compileErr = schemaLoader(content)
+	if schema.compileErr == nil {
+		schema.resolveRefs(sources)
+	}
+	return schema
+}
+
+// schemaLoader reads a schema written in JSON or YAML. JSON is a subset of
+// YAML, so both are read the same way.
+func schemaLoader(content string) (gojsonschema.JSONLoader, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &node); err != nil {
+		return nil, err
+	}
+	value, err := documentValue(&node)
+	if err != nil {
+		return nil, err
+	}
+	return gojsonschema.NewGoLoader(value), nil
+}
+
+// resolveRefs loads the schemas the schema refers to. A $ref to anything
+// else is a problem of the schema: it is never fetched.
+func (s *documentSchema) resolveRefs(sources schemaSources) {
+	refs, err := resolveRefs(s.id, s.loader, sources)
+	switch {
+	case errors.Is(err, errUnknownRef):
+		s.problem = fmt.Sprintf("Schema %s cannot be used: %v; the document was not validated", s.source, err)
+		s.severity = models.SeverityWarning
+		s.ruleID = "schema-ref-rejected"
+	case err != nil:
+		s.compileErr = err
+	default:
+		s.refs = refs
+	}
+}
+
+// compile compiles a schema whose references were resolved.
+func (s *documentSchema) compile() {
+	if s.problem != "" || s.schema != nil || s.compileErr != nil {
+		return
+	}
+	s.schema, s.compileErr = compileSchema(s.id, s.loader, s.refs)
+}
+
+// resolveSchemas returns the schema of every pending file that has one. A
+// matching entry of the request's schemas wins over the schema the file
+// declares with a yaml-language-server modeline.
+func resolveSchemas(req *models.AnalyzeRequest, mapped map[int]*documentSchema, pending []int) map[int]*documentSchema {
+	sources := schemaSources{files: requestFiles(req)}
+	declared := make(map[string]*documentSchema)
+	schemas := make(map[int]*documentSchema, len(pending))
+
+	for _, i := range pending {
+		file := req.Files[i]
+		if m := matchSchemaMapping(req.Schemas, file.Path); m >= 0 {
+			schemas[i] = mapped[m]
+			continue
+		}
+
+		ref, line := declaredSchema(file.Content)
+		if ref == "" {
+			continue
+		}
+		target := resolveSchemaRef(file.Path, ref)
+		key := fmt.Sprintf("%d:%s", line, target)
+		schema, ok := declared[key]
+		if !ok {
+			schema = declaredSchemaSource(ref, target, line, sources)
+			declared[key] = schema
+		}
+		schemas[i] = schema
+	}
+
+	return schemas
+}
+
+// matchSchemaMapping returns the index of the first schema mapping that
+// applies to filePath, or -1. Patterns without a slash match the base name.
+func matchSchemaMapping(mappings []models.SchemaMapping, filePath string) int {
+	filePath = cleanPath(filePath)
+	for i, mapping := range mappings {
+		if len(mapping.Files) == 0 {
+			return i
+		}
+		for _, pattern := range mapping.Files {
+			name := filePath
+			if !strings.Contains(pattern, "/") {
+				name = path.Base(filePath)
+			}
+			if matched, _ := path.Match(strings.TrimPrefix(pattern, "./"), name); matched {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// schemaModeline matches the comment editors use to declare the schema of a
+// YAML file, e.g. "# yaml-language-server: $schema=../schemas/app.json".
+var schemaModeline = regexp.MustCompile(`^\s*#\s*yaml-language-server:\s*\$schema=(\S+)`)
+
+// declaredSchema returns the schema named by the file's first
+// yaml-language-server modeline and the line it is on, if any.
+func declaredSchema(content string) (string, int) {
+	for n, line := range strings.Split(content, "\n") {
+		if match := schemaModeline.FindStringSubmatch(line); match != nil {
+			return match[1], n + 1
+		}
+	}
+	return "", 0
+}
+
+// resolveSchemaRef turns a modeline schema into the path of a request file
+// relative to the document, or returns remote URLs unchanged.
+func resolveSchemaRef(documentPath, ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	switch u.Scheme {
+	case "":
+		return cleanPath(path.Join(path.Dir(cleanPath(documentPath)), u.Path))
+	case "file":
+		return cleanPath(u.Path)
+	default:
+		return ref
+	}
+}
+
+// declaredSchemaSource loads the schema named by a file's modeline.
+func declaredSchemaSource(ref, target string, line int, sources schemaSources) *documentSchema {
+	if u, err := url.Parse(target); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return &documentSchema{
+			source:   ref,
+			line:     line,
+			problem:  fmt.Sprintf("Schema %s is not available offline; the document was not validated", ref),
+			severity: models.SeverityInfo,
+			ruleID:   "schema-unavailable",
+		}
+	}
+
+	content, ok := sources.files[target]
+	if !ok {
+		return &documentSchema{
+			source:   ref,
+			line:     line,
+			problem:  fmt.Sprintf("Schema %s was not found among the submitted files", ref),
+			severity: models.SeverityWarning,
+			ruleID:   "schema-not-found",
+		}
+	}
+	schema := schemaFile(ref, target, content, sources)
+	schema.line = line
+	return schema
+}
+
+// compileSchemas compiles the schemas still needed by the given files.
+func compileSchemas(schemas map[int]*documentSchema, pending []int) {
+	for _, i := range pending {
+		if schema := schemas[i]; schema != nil && schema.loader != nil {
+			schema.compile()
+		}
+	}
+}
+
+// validateDocuments validates every document of a YAML stream and reports
+// each schema error at the position of the value it concerns. Files that do
+// not parse are left to yamllint's syntax check.
+func validateDocuments(content string, schema *documentSchema) []models.LineComment {
+	// Problems with the schema itself are reported at the modeline.
+	at := func(lineComment models.LineComment) []models.LineComment {
+		lineComment.Line, lineComment.Column = schema.line, 1
+		if lineComment.Line == 0 {
+			lineComment.Line = 1
+		}
+		return []models.LineComment{lineComment}
+	}
+
+	if schema.problem != "" {
+		return at(models.LineComment{
+			RuleID:   schema.ruleID,
+			Severity: schema.severity,
+			Tool:     "gojsonschema",
+			Comment:  schema.problem,
+		})
+	}
+	if schema.compileErr != nil {
+		return at(models.LineComment{
+			RuleID:   "schema-invalid",
+			Severity: models.SeverityError,
+			Tool:     "gojsonschema",
+			Comment:  fmt.Sprintf("Schema %s cannot be used: %v", schema.source, schema.compileErr),
+		})
+	}
+
+	documents, err := parseDocuments(content)
+	if err != nil {
+		return nil
+	}
+
+	var lineComments []models.LineComment
+	for _, document := range documents {
+		value, err := documentValue(document)
+		if err != nil {
+			lineComments = append(lineComments, models.LineComment{
+				Line:     document.Line,
+				Column:   document.Column,
+				RuleID:   "schema-invalid",
+				Severity: models.SeverityError,
+				Tool:     "gojsonschema",
+				Comment:  fmt.Sprintf("Document cannot be validated: %v", err),
+			})
+			continue
+		}
+
+		result, err := schema.schema.Validate(gojsonschema.NewGoLoader(value))
+		if err != nil {
+			return at(models.LineComment{
+				RuleID:   "schema-invalid",
+				Severity: models.SeverityError,
+				Tool:     "gojsonschema",
+				Comment:  fmt.Sprintf("Schema %s cannot be applied: %v", schema.source, err),
+			})
+		}
+		for _, desc := range result.Errors() {
+			node := locate(document, contextTokens(desc.Context()))
+			lineComments = append(lineComments, models.LineComment{
+				Line:     max(node.Line, 1),
+				Column:   node.Column,
+				RuleID:   desc.Type(),
+				Severity: models.SeverityError,
+				Tool:     "gojsonschema",
+				Comment:  desc.String(),
+			})
+		}
+	}
+	return lineComments
+}
+
+// parseDocuments returns the documents of a YAML stream.
+func parseDocuments(content string) ([]*yaml.Node, error) {
+	decoder := yaml.NewDecoder(strings.NewReader(content))
+	var documents []*yaml.Node
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			return documents, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, &document)
+	}
+}
+
+// maxDocumentValues bounds the values built from one document, so aliases
+// that expand exponentially cannot exhaust memory.
+const maxDocumentValues = 1 << 20
+
+// documentValue converts a YAML node into the value encoding/json would
+// decode from the equivalent JSON document: mappings become objects with
+// string keys, with merge keys ("<<") applied, and aliases are expanded.
+// Timestamps and non-finite numbers, which JSON cannot represent, are kept
+// as the strings they were written as.
+func documentValue(node *yaml.Node) (interface{}, error) {
+	b := &valueBuilder{expanding: make(map[*yaml.Node]bool)}
+	return b.value(node)
+}
+
+type valueBuilder struct {
+	values    int
+	expanding map[*yaml.Node]bool // anchors whose alias is being expanded
+}
+
+func (b *valueBuilder) value(node *yaml.Node) (interface{}, error) {
+	b.values++
+	if b.values > maxDocumentValues {
+		return nil, errors.New("document expands to too many values")
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return b.value(node.Content[0])
+	case yaml.AliasNode:
+		if b.expanding[node.Alias] {
+			return nil, fmt.Errorf("alias *%s refers to itself", node.Value)
+		}
+		b.expanding[node.Alias] = true
+		defer delete(b.expanding, node.Alias)
+		return b.value(node.Alias)
+	case yaml.SequenceNode:
+		values := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := b.value(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case yaml.MappingNode:
+		return b.mapping(node)
+	default:
+		return scalarValue(node)
+	}
+}
+
+// mapping converts a mapping node. Keys set in the mapping itself win over
+// keys it merges in, and earlier merged mappings win over later ones.
+func (b *valueBuilder) mapping(node *yaml.Node) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(node.Content)/2)
+	var merged []map[string]interface{}
+
+	for k := 0; k+1 < len(node.Content); k += 2 {
+		key, valueNode := node.Content[k], node.Content[k+1]
+		value, err := b.value(valueNode)
+		if err != nil {
+			return nil, err
+		}
+
+		if key.ShortTag() == "!!merge" {
+			switch value := value.(type) {
+			case map[string]interface{}:
+				merged = append(merged, value)
+			case []interface{}:
+				for _, item := range value {
+					if m, ok := item.(map[string]interface{}); ok {
+						merged = append(merged, m)
+					}
+				}
+			}
+			continue
+		}
+
+		if key.Kind == yaml.AliasNode {
+			key = key.Alias
+		}
+		values[key.Value] = value
+	}
+
+	for _, m := range merged {
+		for name, value := range m {
+			if _, ok := values[name]; !ok {
+				values[name] = value
+			}
+		}
+	}
+	return values, nil
+}
+
+func scalarValue(node *yaml.Node) (interface{}, error) {
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case time.Time:
+		return node.Value, nil
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return node.Value, nil
+		}
+	}
+	return value, nil
+}
+
+// contextTokens splits a gojsonschema context such as (root).items.0 into
+// its keys. A separator that cannot occur in keys keeps dotted keys intact.
+func contextTokens(context *gojsonschema.JsonContext) []string {
+	if context == nil {
+		return nil
+	}
+	tokens := strings.Split(context.String("\x00"), "\x00")
+	return tokens[1:] // (root)
+}
+
+// locate returns the node a path of keys and sequence indices points to, or
+// its closest existing ancestor. Mapping entries are located at their key,
+// where editors underline them; duplicate keys resolve to the last one, like
+// the decoded value does.
+func locate(document *yaml.Node, tokens []string) *yaml.Node {
+	node := document
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	position := node
+	for _, token := range tokens {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+
+		var next, nextPosition *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for k := 0; k+1 < len(node.Content); k += 2 {
+				if node.Content[k].Value == token {
+					next, nextPosition = node.Content[k+1], node.Content[k]
+				}
+			}
+		case yaml.SequenceNode:
+			var index int
+			if _, err := fmt.Sscanf(token, "%d", &index); err == nil && index >= 0 && index < len(node.Content) {
+				next = node.Content[index]
+				nextPosition = next
+			}
+		}
+		if next == nil {
+			break
+		}
+		node, position = next, nextPosition
+	}
+	return position
+}
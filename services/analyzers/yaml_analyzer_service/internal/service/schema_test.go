@@ -0,0 +1,191 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"yaml_analyzer_service/internal/models"
+)
+
+func TestDocumentValue(t *testing.T) {
+	documents, err := parseDocuments(`defaults: &defaults
+  image: nginx
+  replicas: 1
+service:
+  <<: *defaults
+  replicas: 3
+  enabled: yes
+  created: 2024-01-02
+  ports: [80, 443]
+---
+- .inf
+- ~
+`)
+	if err != nil {
+		t.Fatalf("parseDocuments() error = %v", err)
+	}
+	if len(documents) != 2 {
+		t.Fatalf("parseDocuments() returned %d documents, want 2", len(documents))
+	}
+
+	value, err := documentValue(documents[0])
+	if err != nil {
+		t.Fatalf("documentValue() error = %v", err)
+	}
+	got, _ := json.Marshal(value)
+	want := `{"defaults":{"image":"nginx","replicas":1},"service":{"created":"2024-01-02","enabled":"yes","image":"nginx","ports":[80,443],"replicas":3}}`
+	if string(got) != want {
+		t.Errorf("documentValue() = %s, want %s", got, want)
+	}
+
+	value, err = documentValue(documents[1])
+	if err != nil {
+		t.Fatalf("documentValue() error = %v", err)
+	}
+	if want := []interface{}{".inf", nil}; !reflect.DeepEqual(value, want) {
+		t.Errorf("documentValue() = %#v, want %#v", value, want)
+	}
+}
+
+func TestValidateDocuments(t *testing.T) {
+	req := &models.AnalyzeRequest{
+		Files: []models.FileInput{
+			{Path: "k8s/app.yaml", Content: "# yaml-language-server: $schema=../schemas/app.yaml\nname: app\nspec:\n  replicas: many\n---\nspec:\n  replicas: 2\n"},
+			{Path: "k8s/remote.yaml", Content: "# yaml-language-server: $schema=https://json.schemastore.org/github-workflow.json\non: push\n"},
+			{Path: "k8s/missing.yaml", Content: "# yaml-language-server: $schema=missing.json\nname: app\n"},
+			{Path: "schemas/app.yaml", Content: "type: object\nrequired: [name]\nproperties:\n  spec:\n    properties:\n      replicas: {type: integer}\n"},
+		},
+	}
+	pending := []int{0, 1, 2}
+	schemas := resolveSchemas(req, nil, pending)
+	compileSchemas(schemas, pending)
+
+	var got []string
+	for _, lineComment := range validateDocuments(req.Files[0].Content, schemas[0]) {
+		got = append(got, lineComment.RuleID)
+		if lineComment.RuleID == "invalid_type" && (lineComment.Line != 4 || lineComment.Column != 3) {
+			t.Errorf("invalid_type finding at %d:%d, want 4:3", lineComment.Line, lineComment.Column)
+		}
+		if lineComment.RuleID == "required" && lineComment.Line != 6 {
+			t.Errorf("required finding on line %d, want 6, the second document", lineComment.Line)
+		}
+	}
+	if want := []string{"invalid_type", "required"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("validateDocuments() rules = %v, want %v", got, want)
+	}
+
+	if got := validateDocuments(req.Files[1].Content, schemas[1]); len(got) != 1 || got[0].RuleID != "schema-unavailable" || got[0].Line != 1 {
+		t.Errorf("validateDocuments() with a remote schema = %+v, want schema-unavailable on line 1", got)
+	}
+	if got := validateDocuments(req.Files[2].Content, schemas[2]); len(got) != 1 || got[0].RuleID != "schema-not-found" {
+		t.Errorf("validateDocuments() with a missing schema = %+v, want schema-not-found", got)
+	}
+	if got := validateDocuments("name: [app\n", schemas[0]); len(got) != 0 {
+		t.Errorf("validateDocuments() on invalid YAML = %+v, want nothing, yamllint reports syntax errors", got)
+	}
+}
+
+func TestValidateDocuments_SchemaRefs(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"required": ["name"]}`)
+	}))
+	defer server.Close()
+
+	local := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(local, []byte(`{"required": ["name"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fileURL := (&url.URL{Scheme: "file", Path: filepath.ToSlash(local)}).String()
+
+	req := &models.AnalyzeRequest{
+		Files: []models.FileInput{
+			{Path: "schemas/defs.yaml", Content: "definitions:\n  replicas: {type: integer}\n"},
+			{Path: "schemas/app.yaml", Content: "properties:\n  replicas: {$ref: 'defs.yaml#/definitions/replicas'}\n"},
+			{Path: "schemas/local.yaml", Content: "allOf:\n  - $ref: " + fileURL + "\n"},
+			{Path: "app.yaml", Content: "# yaml-language-server: $schema=schemas/app.yaml\nreplicas: many\n"},
+			{Path: "local.yaml", Content: "# yaml-language-server: $schema=schemas/local.yaml\nreplicas: 1\n"},
+			{Path: "remote.yaml", Content: "replicas: 1\n"},
+			{Path: "file.yaml", Content: "replicas: 1\n"},
+		},
+		Schemas: []models.SchemaMapping{
+			{Files: []string{"remote.yaml"}, Schema: []byte(`{"properties": {"a": {"$ref": "` + server.URL + `/schema.json"}}}`)},
+			{Files: []string{"file.yaml"}, Schema: []byte(`{"$ref": "` + fileURL + `"}`)},
+		},
+	}
+	mapped, err := validateSchemas(req)
+	if err != nil {
+		t.Fatalf("validateSchemas() error = %v", err)
+	}
+	pending := []int{3, 4, 5, 6}
+	schemas := resolveSchemas(req, mapped, pending)
+	compileSchemas(schemas, pending)
+
+	tests := []struct {
+		file   int
+		ruleID string
+		line   int
+	}{
+		{file: 3, ruleID: "invalid_type", line: 2},
+		{file: 4, ruleID: "schema-ref-rejected", line: 1},
+		{file: 5, ruleID: "schema-ref-rejected", line: 1},
+		{file: 6, ruleID: "schema-ref-rejected", line: 1},
+	}
+	for _, tt := range tests {
+		got := validateDocuments(req.Files[tt.file].Content, schemas[tt.file])
+		if len(got) != 1 || got[0].RuleID != tt.ruleID || got[0].Line != tt.line {
+			t.Errorf("validateDocuments() for %s = %+v, want %s on line %d", req.Files[tt.file].Path, got, tt.ruleID, tt.line)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("schema server got %d requests, want none", requests)
+	}
+}
+
+func TestValidateSchemas(t *testing.T) {
+	files := []models.FileInput{{Path: "app.yaml"}, {Path: "schema.json", Content: `{"type": "object"}`}}
+	tests := []struct {
+		name    string
+		schemas []models.SchemaMapping
+		wantErr bool
+	}{
+		{name: "inline", schemas: []models.SchemaMapping{{Schema: []byte(`{"type": "object"}`)}}},
+		{name: "ref", schemas: []models.SchemaMapping{{Files: []string{"*.yaml"}, Ref: "schema.json"}}},
+		{name: "unknown ref", schemas: []models.SchemaMapping{{Ref: "other.json"}}, wantErr: true},
+		{name: "both", schemas: []models.SchemaMapping{{Schema: []byte(`{}`), Ref: "schema.json"}}, wantErr: true},
+		{name: "neither", schemas: []models.SchemaMapping{{Files: []string{"*.yaml"}}}, wantErr: true},
+		{name: "bad pattern", schemas: []models.SchemaMapping{{Files: []string{"["}, Ref: "schema.json"}}, wantErr: true},
+		{name: "invalid inline", schemas: []models.SchemaMapping{{Schema: []byte(`{"type": 1}`)}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validateSchemas(&models.AnalyzeRequest{Files: files, Schemas: tt.schemas})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateSchemas() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidOptions) {
+				t.Errorf("validateSchemas() error = %v, want ErrInvalidOptions", err)
+			}
+		})
+	}
+}
+
+func TestDocumentValueRecursiveAlias(t *testing.T) {
+	documents, err := parseDocuments("a: &a\n  b: *a\n")
+	if err != nil {
+		t.Fatalf("parseDocuments() error = %v", err)
+	}
+	if _, err := documentValue(documents[0]); err == nil {
+		t.Error("documentValue() of a recursive alias succeeded, want an error")
+	}
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"yaml_analyzer_service/internal/models"
+)
+
+const (
+	defaultFileTimeout    = 30 * time.Second
+	defaultRequestTimeout = 2 * time.Minute
+
+	// waitDelay bounds how long we wait for output pipes after the tool
+	// process has been killed on cancellation.
+	waitDelay = 2 * time.Second
+)
+
+type YAMLAnalyzerService struct {
+	yamllintPath   string
+	preset         string
+	fileTimeout    time.Duration
+	requestTimeout time.Duration
+	maxWorkers     int
+	processes      processLimiter
+	cache          *resultCache
+
+	versionOnce sync.Once
+	version     string
+	versionOK   bool
+}
+
+func NewYAMLAnalyzerService() *YAMLAnalyzerService {
+	yamllintPath := os.Getenv("YAMLLINT_PATH")
+	if yamllintPath == "" {
+		yamllintPath = "yamllint"
+	}
+
+	return &YAMLAnalyzerService{
+		yamllintPath:   yamllintPath,
+		preset:         presetFromEnv("YAMLLINT_CONFIG"),
+		fileTimeout:    durationFromEnv("ANALYZER_FILE_TIMEOUT", defaultFileTimeout),
+		requestTimeout: durationFromEnv("ANALYZER_REQUEST_TIMEOUT", defaultRequestTimeout),
+		maxWorkers:     intFromEnv("MAX_WORKERS", runtime.NumCPU()),
+		processes:      newProcessLimiter(intFromEnv("MAX_PROCESSES", runtime.NumCPU())),
+		cache:          cacheFromEnv(),
+	}
+}
+
+// durationFromEnv parses a Go duration (e.g. "30s") from the environment,
+// falling back to the default when the variable is unset or invalid.
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// intFromEnv parses a positive integer from the environment, falling back to
+// the default when the variable is unset or invalid.
+func intFromEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// isYAML reports whether the analyzer handles the file.
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+func (s *YAMLAnalyzerService) Analyze(ctx context.Context, req *models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
+	if err := validateOptions(req.Options); err != nil {
+		return nil, err
+	}
+	mapped, err := validateSchemas(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	results := make([]models.FileResult, len(req.Files))
+	var pending []int
+
+	for i, file := range req.Files {
+		if !isYAML(file.Path) {
+			results[i] = models.FileResult{
+				Path:         file.Path,
+				Status:       models.StatusUnsupported,
+				Comment:      "Not a YAML file",
+				LineComments: []models.LineComment{},
+			}
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	keys := s.cacheKeys(req, pending)
+	pending = s.cache.lookup(keys, req.Files, results, pending)
+	if len(pending) == 0 {
+		return &models.AnalyzeResponse{Files: results}, nil
+	}
+
+	// Every file of the request is written to the workspace, so the project's .yamllint config applies.
+	ws, err := newWorkspace(req.Files)
+	if err != nil {
+		for _, i := range pending {
+			results[i] = models.FileResult{
+				Path:         req.Files[i].Path,
+				Status:       models.StatusToolError,
+				Comment:      fmt.Sprintf("Error: failed to create workspace: %v", err),
+				LineComments: []models.LineComment{},
+			}
+		}
+		return &models.AnalyzeResponse{Files: results}, nil
+	}
+	defer ws.Close()
+
+	schemas := resolveSchemas(req, mapped, pending)
+	compileSchemas(schemas, pending)
+
+	config := s.configArgs(ws)
+	groups := batches(pending, s.maxWorkers)
+	forEach(len(groups), s.maxWorkers, func(g int) {
+		paths := make([]string, len(groups[g]))
+		for j, i := range groups[g] {
+			paths[j] = req.Files[i].Path
+		}
+		for j, result := range s.analyzeBatch(ctx, ws, config, paths) {
+			i := groups[g][j]
+			if schema := schemas[i]; schema != nil {
+				result = addFindings(result, validateDocuments(req.Files[i].Content, schema))
+			}
+			results[i] = result
+		}
+	})
+
+	for i := range results {
+		results[i] = applyOptions(results[i], req.Options)
+	}
+	s.cache.store(keys, results, pending)
+
+	return &models.AnalyzeResponse{Files: results}, nil
+}
+
+// analyzeBatch runs yamllint once over the given files inside the workspace,
+// with the project configuration, and returns one result per path, in the
+// same order.
+func (s *YAMLAnalyzerService) analyzeBatch(ctx context.Context, ws *workspace, config []string, paths []string) []models.FileResult {
+	// Waiting for a free process slot does not count against the deadline.
+	if err := s.processes.acquire(ctx); err != nil {
+		return timeoutResults(paths)
+	}
+	defer s.processes.release()
+
+	ctx, cancel := context.WithTimeout(ctx, s.fileTimeout*time.Duration(len(paths)))
+	defer cancel()
+
+	args := append([]string{"--format", "parsable"}, config...)
+	for _, path := range paths {
+		args = append(args, ws.relPath(path))
+	}
+	output, stderr, runErr := ws.run(ctx, s.yamllintPath, args...)
+
+	if ctx.Err() != nil {
+		return timeoutResults(paths)
+	}
+
+	// yamllint exits with 1 when it reports errors; anything else, e.g. an
+	// invalid configuration, means it did not lint the files.
+	if err := exitError(runErr, 1); err != nil {
+		if len(bytes.TrimSpace(stderr)) == 0 {
+			stderr = output
+		}
+		return toolErrorResults(paths, "yamllint", err, stderr)
+	}
+
+	return fileResults(paths, s.parseOutput(ws, output))
+}
+
+// yamllintLine matches a problem in yamllint's parsable format, e.g.
+// "config.yaml:3:7: [warning] truthy value should be one of [false, true] (truthy)".
+// Syntax errors carry "(syntax)" in place of a rule.
+var yamllintLine = regexp.MustCompile(`^(.+):(\d+):(\d+): \[(error|warning)\] (.*?)(?: \(([\w-]+)\))?$`)
+
+// parseOutput groups the problems yamllint reported by the original path of
+// the file they were reported for.
+func (s *YAMLAnalyzerService) parseOutput(ws *workspace, output []byte) map[string][]models.LineComment {
+	findings := make(map[string][]models.LineComment)
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		match := yamllintLine.FindStringSubmatch(strings.TrimRight(scanner.Text(), "\r"))
+		if match == nil {
+			continue
+		}
+		path, ok := ws.originalPath(match[1])
+		if !ok {
+			continue
+		}
+		line, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+
+		findings[path] = append(findings[path], models.LineComment{
+			Line:     line,
+			Column:   column,
+			RuleID:   match[6],
+			Severity: yamllintSeverity(match[4]),
+			Tool:     "yamllint",
+			Comment:  match[5],
+		})
+	}
+
+	return findings
+}
+
+// yamllintSeverity maps yamllint levels onto error/warning.
+func yamllintSeverity(level string) string {
+	if level == "error" {
+		return models.SeverityError
+	}
+	return models.SeverityWarning
+}
+
+// addFindings merges extra findings of a completed analysis into its result,
+// ordered by position. Timed out and failed results are left alone.
+func addFindings(result models.FileResult, extra []models.LineComment) models.FileResult {
+	if len(extra) == 0 || (result.Status != models.StatusOK && result.Status != models.StatusIssues) {
+		return result
+	}
+
+	lineComments := append(result.LineComments, extra...)
+	sort.SliceStable(lineComments, func(a, b int) bool {
+		if lineComments[a].Line != lineComments[b].Line {
+			return lineComments[a].Line < lineComments[b].Line
+		}
+		return lineComments[a].Column < lineComments[b].Column
+	})
+	result.LineComments = lineComments
+	result.Status = models.StatusIssues
+	result.Comment = "Issues found"
+	return result
+}
+
+func timeoutResults(paths []string) []models.FileResult {
+	results := make([]models.FileResult, len(paths))
+	for i, path := range paths {
+		results[i] = models.FileResult{
+			Path:         path,
+			Status:       models.StatusTimeout,
+			Comment:      "Analysis timed out",
+			LineComments: []models.LineComment{},
+		}
+	}
+	return results
+}
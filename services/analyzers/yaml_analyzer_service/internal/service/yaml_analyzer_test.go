@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"yaml_analyzer_service/internal/models"
+)
+
+// yamllint runs from the project root, the directory the files share, and
+// reports paths relative to it.
+const yamllintOutput = `app.yaml:4:3: [error] duplication of key "image" in mapping (key-duplicates)
+app.yaml:6:11: [warning] truthy value should be one of [false, true] (truthy)
+ci.yml:3:5: [error] syntax error: could not find expected ':' (syntax)
+/elsewhere/other.yaml:1:1: [error] wrong indentation: expected 2 but found 4 (indentation)
+`
+
+func TestYAMLAnalyzerService_ParseOutput(t *testing.T) {
+	service := NewYAMLAnalyzerService()
+
+	ws, err := newWorkspace([]models.FileInput{
+		{Path: "deploy/app.yaml", Content: ""},
+		{Path: "deploy/ci.yml", Content: ""},
+	})
+	if err != nil {
+		t.Fatalf("newWorkspace() error = %v", err)
+	}
+	defer ws.Close()
+
+	findings := service.parseOutput(ws, []byte(yamllintOutput))
+	app := findings["deploy/app.yaml"]
+	if len(app) != 2 {
+		t.Fatalf("parseOutput() returned %d findings for deploy/app.yaml, want 2", len(app))
+	}
+	want := models.LineComment{Line: 4, Column: 3, RuleID: "key-duplicates", Severity: models.SeverityError, Tool: "yamllint", Comment: `duplication of key "image" in mapping`}
+	if app[0] != want {
+		t.Errorf("finding = %+v, want %+v", app[0], want)
+	}
+	if app[1].RuleID != "truthy" || app[1].Severity != models.SeverityWarning {
+		t.Errorf("finding = %+v, want a truthy warning", app[1])
+	}
+
+	ci := findings["deploy/ci.yml"]
+	if len(ci) != 1 || ci[0].RuleID != "syntax" || ci[0].Line != 3 || ci[0].Column != 5 || ci[0].Comment != "syntax error: could not find expected ':'" {
+		t.Errorf("parseOutput() for deploy/ci.yml = %+v, want the syntax error at 3:5", ci)
+	}
+	if len(findings) != 2 {
+		t.Errorf("parseOutput() returned findings for %d files, want 2", len(findings))
+	}
+}
+
+func TestYAMLAnalyzerService_Analyze(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "yamllint")
+	content := `#!/bin/sh
+if [ "$1" = "--version" ]; then echo "yamllint 1.35.1"; exit 0; fi
+for last; do :; done
+echo "$last:2:8: [warning] truthy value should be one of [false, true] (truthy)"
+`
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("failed to write fake yamllint: %v", err)
+	}
+	service := NewYAMLAnalyzerService()
+	service.yamllintPath = script
+
+	resp, err := service.Analyze(context.Background(), &models.AnalyzeRequest{
+		Files: []models.FileInput{
+			{Path: "app.yaml", Content: "name: app\nenabled: yes\nreplicas: many\n"},
+			{Path: "README.md", Content: "# App\n"},
+		},
+		Schemas: []models.SchemaMapping{{
+			Files:  []string{"app.yaml"},
+			Schema: []byte(`{"type": "object", "properties": {"replicas": {"type": "integer"}}}`),
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	got := resp.Files[0]
+	if got.Status != models.StatusIssues || len(got.LineComments) != 2 {
+		t.Fatalf("Analyze() result = %+v, want the truthy warning and a schema error", got)
+	}
+	if got.LineComments[0].Tool != "yamllint" || got.LineComments[0].Line != 2 {
+		t.Errorf("first finding = %+v, want yamllint's finding on line 2", got.LineComments[0])
+	}
+	if got.LineComments[1].Tool != "gojsonschema" || got.LineComments[1].RuleID != "invalid_type" || got.LineComments[1].Line != 3 {
+		t.Errorf("second finding = %+v, want an invalid_type schema error on line 3", got.LineComments[1])
+	}
+	if resp.Files[1].Status != models.StatusUnsupported {
+		t.Errorf("Analyze() status of README.md = %q, want %q", resp.Files[1].Status, models.StatusUnsupported)
+	}
+}
+
+func TestYAMLAnalyzerService_AnalyzeToolError(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "yamllint")
+	content := "#!/bin/sh\necho 'invalid config: no such rule: \"tabs\"' >&2\nexit 255\n"
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("failed to write fake yamllint: %v", err)
+	}
+	service := NewYAMLAnalyzerService()
+	service.yamllintPath = script
+
+	resp, err := service.Analyze(context.Background(), &models.AnalyzeRequest{
+		Files: []models.FileInput{{Path: "app.yaml", Content: "name: app\n"}},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if got := resp.Files[0]; got.Status != models.StatusToolError || got.Stderr == "" {
+		t.Errorf("Analyze() result = %+v, want a tool error with stderr", got)
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"yaml_analyzer_service/internal/controller"
+	"yaml_analyzer_service/internal/service"
+)
+
+func main() {
+	analyzerService := service.NewYAMLAnalyzerService()
+	analyzerController := controller.NewAnalyzerController(analyzerService)
+	router := controller.NewRouter(analyzerController)
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8088"
+	}
+
+	log.Printf("Starting yaml_analyzer_service on port %s", port)
+	if err := http.ListenAndServe(":"+port, router); err != nil {
+		log.Fatalf("Server failed: %v", err)
+	}
+}
@@ -27,6 +27,8 @@ func setupIntegrationTest(t *testing.T) (*httptest.Server, string, func()) {
 	}
 
 	// Clean up
+	db.Exec("DROP TABLE IF EXISTS findings")
+	db.Exec("DROP TABLE IF EXISTS analysis_runs")
 	db.Exec("DROP TABLE IF EXISTS files")
 	db.Exec("DROP TABLE IF EXISTS projects")
 	repository.RunMigrations(db)
@@ -36,7 +38,8 @@ func setupIntegrationTest(t *testing.T) (*httptest.Server, string, func()) {
 
 	projectRepo := repository.NewProjectRepository(db)
 	fileRepo := repository.NewFileRepository(db)
-	projectService := service.NewProjectService(projectRepo, fileRepo, cfg.JWTSecret, cfg.AnalyzerBaseURL)
+	analysisRepo := repository.NewAnalysisRepository(db)
+	projectService := service.NewProjectService(projectRepo, fileRepo, analysisRepo, cfg.JWTSecret, cfg.AnalyzerBaseURL)
 	projectController := controller.NewProjectController(projectService)
 	router := controller.NewRouter(projectController)
 
@@ -47,6 +50,8 @@ func setupIntegrationTest(t *testing.T) (*httptest.Server, string, func()) {
 
 	cleanup := func() {
 		server.Close()
+		db.Exec("DROP TABLE IF EXISTS findings")
+		db.Exec("DROP TABLE IF EXISTS analysis_runs")
 		db.Exec("DROP TABLE IF EXISTS files")
 		db.Exec("DROP TABLE IF EXISTS projects")
 		db.Close()
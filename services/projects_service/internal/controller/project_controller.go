@@ -2,12 +2,15 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"projects_service/internal/models"
+	"projects_service/internal/sarif"
 	"projects_service/internal/service"
 )
 
@@ -321,20 +324,147 @@ func (c *ProjectController) AnalyzeFile(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	options, err := decodeAnalyzeOptions(r)
+	if err != nil {
+		respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
 	token := c.getToken(r)
-	result, err := c.service.AnalyzeFile(token, fileID, analyzerType)
+	result, err := c.service.AnalyzeFile(r.Context(), token, fileID, analyzerType, options)
 	if err != nil {
 		if err.Error() == "unauthorized" || err.Error() == "forbidden" {
 			respondError(w, err.Error(), http.StatusForbidden)
+		} else if errors.Is(err, service.ErrAnalyzerUnavailable) {
+			respondError(w, err.Error(), http.StatusBadGateway)
 		} else {
 			respondError(w, err.Error(), http.StatusBadRequest)
 		}
 		return
 	}
 
+	if wantsSARIF(r) {
+		respondSARIF(w, sarif.FromResponse(result, "static-code-analyzer"), http.StatusOK)
+		return
+	}
+
+	respondJSON(w, result, http.StatusOK)
+}
+
+func (c *ProjectController) AnalyzeProject(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	vars := mux.Vars(r)
+	projectID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondError(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	options, err := decodeAnalyzeOptions(r)
+	if err != nil {
+		respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	token := c.getToken(r)
+	result, err := c.service.AnalyzeProject(r.Context(), token, projectID, options)
+	if err != nil {
+		if err.Error() == "unauthorized" || err.Error() == "forbidden" {
+			respondError(w, err.Error(), http.StatusForbidden)
+		} else if errors.Is(err, service.ErrInvalidOptions) {
+			respondError(w, err.Error(), http.StatusBadRequest)
+		} else if err.Error() == "project not found" {
+			respondError(w, err.Error(), http.StatusNotFound)
+		} else {
+			respondError(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if wantsSARIF(r) {
+		respondSARIF(w, sarif.FromResponse(result, "static-code-analyzer"), http.StatusOK)
+		return
+	}
+
 	respondJSON(w, result, http.StatusOK)
 }
 
+// decodeAnalyzeOptions reads the optional {"options": {...}} body of the
+// analysis endpoints; an empty body means default options.
+func decodeAnalyzeOptions(r *http.Request) (*models.AnalyzeOptions, error) {
+	var body struct {
+		Options *models.AnalyzeOptions `json:"options"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return body.Options, nil
+}
+
+func (c *ProjectController) ListAnalysisRuns(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	vars := mux.Vars(r)
+	projectID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondError(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	token := c.getToken(r)
+	runs, err := c.service.ListAnalysisRuns(token, projectID)
+	if err != nil {
+		if err.Error() == "unauthorized" || err.Error() == "forbidden" {
+			respondError(w, err.Error(), http.StatusForbidden)
+		} else {
+			respondError(w, err.Error(), http.StatusNotFound)
+		}
+		return
+	}
+
+	respondJSON(w, runs, http.StatusOK)
+}
+
+func (c *ProjectController) GetAnalysisRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	vars := mux.Vars(r)
+	projectID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondError(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	runID, err := strconv.Atoi(vars["runId"])
+	if err != nil {
+		respondError(w, "Invalid run ID", http.StatusBadRequest)
+		return
+	}
+
+	token := c.getToken(r)
+	run, err := c.service.GetAnalysisRun(token, projectID, runID)
+	if err != nil {
+		if err.Error() == "unauthorized" || err.Error() == "forbidden" {
+			respondError(w, err.Error(), http.StatusForbidden)
+		} else {
+			respondError(w, err.Error(), http.StatusNotFound)
+		}
+		return
+	}
+
+	respondJSON(w, run, http.StatusOK)
+}
+
 func respondJSON(w http.ResponseWriter, data interface{}, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -347,3 +477,15 @@ func respondError(w http.ResponseWriter, message string, statusCode int) {
 	json.NewEncoder(w).Encode(map[string]string{"error": message})
 }
 
+// wantsSARIF reports whether the client asked for a SARIF log instead of the
+// unified analysis JSON.
+func wantsSARIF(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), sarif.MediaType)
+}
+
+func respondSARIF(w http.ResponseWriter, log *sarif.Log, statusCode int) {
+	w.Header().Set("Content-Type", sarif.MediaType)
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(log)
+}
+
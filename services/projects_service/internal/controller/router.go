@@ -12,6 +12,9 @@ func NewRouter(projectController *ProjectController) *mux.Router {
 	router.HandleFunc("/api/projects/{id}", projectController.GetProject).Methods("GET")
 	router.HandleFunc("/api/projects/{id}", projectController.UpdateProject).Methods("PUT")
 	router.HandleFunc("/api/projects/{id}", projectController.DeleteProject).Methods("DELETE")
+	router.HandleFunc("/api/projects/{id}/analyze", projectController.AnalyzeProject).Methods("POST")
+	router.HandleFunc("/api/projects/{id}/runs", projectController.ListAnalysisRuns).Methods("GET")
+	router.HandleFunc("/api/projects/{id}/runs/{runId}", projectController.GetAnalysisRun).Methods("GET")
 	router.HandleFunc("/api/projects/{id}/upload", projectController.CreateProjectFromZip).Methods("POST")
 	router.HandleFunc("/api/projects/{id}/files", projectController.ListFiles).Methods("GET")
 	router.HandleFunc("/api/projects/{id}/files", projectController.CreateFile).Methods("POST")
@@ -0,0 +1,120 @@
+package models
+
+import "time"
+
+const (
+	RunStatusCompleted = "completed"
+	RunStatusFailed    = "failed"
+)
+
+// Statuses of a file result, as reported by the analyzers: the tool ran and
+// found nothing (ok) or findings (issues), it or the analyzer call failed
+// (tool_error), no analyzer handles the file (unsupported), or the analysis
+// did not finish in time.
+const (
+	StatusOK          = "ok"
+	StatusIssues      = "issues"
+	StatusToolError   = "tool_error"
+	StatusUnsupported = "unsupported"
+	StatusTimeout     = "timeout"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+type AnalyzeRequest struct {
+	Files   []FileInput     `json:"files"`
+	Options *AnalyzeOptions `json:"options,omitempty"`
+}
+
+// AnalyzeOptions tunes which findings are reported. EnableRules restricts the
+// report to the listed rules, DisableRules drops rules, MinSeverity drops
+// findings below the given severity and MaxFindings caps findings per file.
+// Tools selects the tools of the Python analyzer and CheckstyleConfig the
+// bundled checkstyle configuration of the Java analyzer; the other analyzers
+// ignore them.
+type AnalyzeOptions struct {
+	EnableRules      []string `json:"enable_rules,omitempty"`
+	DisableRules     []string `json:"disable_rules,omitempty"`
+	MinSeverity      string   `json:"min_severity,omitempty"`
+	MaxFindings      int      `json:"max_findings,omitempty"`
+	Tools            []string `json:"tools,omitempty"`
+	CheckstyleConfig string   `json:"checkstyle_config,omitempty"`
+}
+
+type FileInput struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+type AnalyzeResponse struct {
+	RunID    int          `json:"run_id,omitempty"`
+	RunError string       `json:"run_error,omitempty"`
+	Files    []FileResult `json:"files"`
+}
+
+type FileResult struct {
+	Path         string        `json:"path"`
+	Status       string        `json:"status,omitempty"`
+	Comment      string        `json:"comment"`
+	Stderr       string        `json:"stderr,omitempty"`
+	LineComments []LineComment `json:"line_comments"`
+}
+
+// LineComment carries the common finding fields plus the details only the
+// C/C++ analyzer reports: the CWE ID, whether cppcheck's finding is
+// inconclusive, its verbose message and every location it reported.
+type LineComment struct {
+	Line         int        `json:"line"`
+	Column       int        `json:"column,omitempty"`
+	EndLine      int        `json:"end_line,omitempty"`
+	EndColumn    int        `json:"end_column,omitempty"`
+	RuleID       string     `json:"rule_id,omitempty"`
+	Severity     string     `json:"severity,omitempty"`
+	Tool         string     `json:"tool,omitempty"`
+	Comment      string     `json:"comment"`
+	CWE          int        `json:"cwe,omitempty"`
+	Inconclusive bool       `json:"inconclusive,omitempty"`
+	Detail       string     `json:"detail,omitempty"`
+	Locations    []Location `json:"locations,omitempty"`
+}
+
+type Location struct {
+	Path   string `json:"path"`
+	Line   int    `json:"line"`
+	Column int    `json:"column,omitempty"`
+	Info   string `json:"info,omitempty"`
+}
+
+type AnalysisRun struct {
+	ID            int       `json:"id" db:"id"`
+	ProjectID     int       `json:"project_id" db:"project_id"`
+	Status        string    `json:"status" db:"status"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	FindingsCount int       `json:"findings_count"`
+	Findings      []Finding `json:"findings,omitempty"`
+}
+
+type Finding struct {
+	ID           int        `json:"id" db:"id"`
+	RunID        int        `json:"run_id" db:"run_id"`
+	ProjectID    int        `json:"project_id" db:"project_id"`
+	FileID       *int       `json:"file_id" db:"file_id"`
+	FilePath     string     `json:"file_path" db:"file_path"`
+	Analyzer     string     `json:"analyzer" db:"analyzer"`
+	Line         int        `json:"line" db:"line"`
+	Column       int        `json:"column" db:"column_number"`
+	EndLine      int        `json:"end_line" db:"end_line"`
+	EndColumn    int        `json:"end_column" db:"end_column"`
+	RuleID       string     `json:"rule_id" db:"rule_id"`
+	Severity     string     `json:"severity" db:"severity"`
+	Tool         string     `json:"tool" db:"tool"`
+	Message      string     `json:"message" db:"message"`
+	CWE          int        `json:"cwe,omitempty" db:"cwe"`
+	Inconclusive bool       `json:"inconclusive,omitempty" db:"inconclusive"`
+	Detail       string     `json:"detail,omitempty" db:"detail"`
+	Locations    []Location `json:"locations,omitempty" db:"locations"`
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"projects_service/internal/models"
+)
+
+type AnalysisRepository struct {
+	db *sql.DB
+}
+
+func NewAnalysisRepository(db *sql.DB) *AnalysisRepository {
+	return &AnalysisRepository{db: db}
+}
+
+// CreateRun stores the run together with all of its findings in a single
+// transaction, so a run is never visible with a partial set of findings.
+func (r *AnalysisRepository) CreateRun(run *models.AnalysisRun) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO analysis_runs (project_id, status) VALUES ($1, $2) RETURNING id, created_at`
+	if err := tx.QueryRow(query, run.ProjectID, run.Status).Scan(&run.ID, &run.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create analysis run: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO findings (run_id, project_id, file_id, file_path, analyzer, line,
+		column_number, end_line, end_column, rule_id, severity, tool, message, cwe, inconclusive, detail, locations)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17) RETURNING id`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare finding insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for i := range run.Findings {
+		finding := &run.Findings[i]
+		finding.RunID = run.ID
+		finding.ProjectID = run.ProjectID
+		// Only the C/C++ analyzer reports locations; other findings store NULL.
+		var locations []byte
+		if len(finding.Locations) > 0 {
+			if locations, err = json.Marshal(finding.Locations); err != nil {
+				return fmt.Errorf("failed to encode finding locations: %w", err)
+			}
+		}
+		err := stmt.QueryRow(finding.RunID, finding.ProjectID, finding.FileID, finding.FilePath, finding.Analyzer, finding.Line,
+			finding.Column, finding.EndLine, finding.EndColumn, finding.RuleID, finding.Severity, finding.Tool, finding.Message,
+			finding.CWE, finding.Inconclusive, finding.Detail, locations).Scan(&finding.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create finding: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit analysis run: %w", err)
+	}
+
+	run.FindingsCount = len(run.Findings)
+	return nil
+}
+
+func (r *AnalysisRepository) FindRunsByProjectID(projectID int) ([]*models.AnalysisRun, error) {
+	query := `SELECT r.id, r.project_id, r.status, r.created_at, COUNT(f.id)
+		FROM analysis_runs r LEFT JOIN findings f ON f.run_id = r.id
+		WHERE r.project_id = $1
+		GROUP BY r.id
+		ORDER BY r.created_at DESC, r.id DESC`
+	rows, err := r.db.Query(query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query analysis runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []*models.AnalysisRun{}
+	for rows.Next() {
+		run := &models.AnalysisRun{}
+		if err := rows.Scan(&run.ID, &run.ProjectID, &run.Status, &run.CreatedAt, &run.FindingsCount); err != nil {
+			return nil, fmt.Errorf("failed to scan analysis run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read analysis runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+func (r *AnalysisRepository) FindRunByID(id int) (*models.AnalysisRun, error) {
+	run := &models.AnalysisRun{}
+	query := `SELECT id, project_id, status, created_at FROM analysis_runs WHERE id = $1`
+	err := r.db.QueryRow(query, id).Scan(&run.ID, &run.ProjectID, &run.Status, &run.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("analysis run not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find analysis run: %w", err)
+	}
+
+	findings, err := r.findFindingsByRunID(id)
+	if err != nil {
+		return nil, err
+	}
+	run.Findings = findings
+	run.FindingsCount = len(findings)
+
+	return run, nil
+}
+
+func (r *AnalysisRepository) findFindingsByRunID(runID int) ([]models.Finding, error) {
+	query := `SELECT id, run_id, project_id, file_id, file_path, analyzer, line,
+		column_number, end_line, end_column, rule_id, severity, tool, message, cwe, inconclusive, detail, locations
+		FROM findings WHERE run_id = $1 ORDER BY file_path, line, column_number, id`
+	rows, err := r.db.Query(query, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query findings: %w", err)
+	}
+	defer rows.Close()
+
+	var findings []models.Finding
+	for rows.Next() {
+		var finding models.Finding
+		var fileID sql.NullInt64
+		var locations []byte
+		if err := rows.Scan(&finding.ID, &finding.RunID, &finding.ProjectID, &fileID, &finding.FilePath, &finding.Analyzer, &finding.Line,
+			&finding.Column, &finding.EndLine, &finding.EndColumn, &finding.RuleID, &finding.Severity, &finding.Tool, &finding.Message,
+			&finding.CWE, &finding.Inconclusive, &finding.Detail, &locations); err != nil {
+			return nil, fmt.Errorf("failed to scan finding: %w", err)
+		}
+		if fileID.Valid {
+			id := int(fileID.Int64)
+			finding.FileID = &id
+		}
+		if locations != nil {
+			if err := json.Unmarshal(locations, &finding.Locations); err != nil {
+				return nil, fmt.Errorf("failed to decode finding locations: %w", err)
+			}
+		}
+		findings = append(findings, finding)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read findings: %w", err)
+	}
+
+	return findings, nil
+}
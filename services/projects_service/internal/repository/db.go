@@ -38,8 +38,36 @@ func RunMigrations(db *sql.DB) error {
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			UNIQUE(project_id, path)
 		);`,
+		`CREATE TABLE IF NOT EXISTS analysis_runs (
+			id SERIAL PRIMARY KEY,
+			project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			status VARCHAR(32) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS findings (
+			id SERIAL PRIMARY KEY,
+			run_id INTEGER NOT NULL REFERENCES analysis_runs(id) ON DELETE CASCADE,
+			project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			file_id INTEGER REFERENCES files(id) ON DELETE SET NULL,
+			file_path VARCHAR(1024) NOT NULL,
+			analyzer VARCHAR(64) NOT NULL,
+			line INTEGER NOT NULL,
+			message TEXT NOT NULL
+		);`,
+		`ALTER TABLE findings ADD COLUMN IF NOT EXISTS column_number INTEGER NOT NULL DEFAULT 0;`,
+		`ALTER TABLE findings ADD COLUMN IF NOT EXISTS end_line INTEGER NOT NULL DEFAULT 0;`,
+		`ALTER TABLE findings ADD COLUMN IF NOT EXISTS end_column INTEGER NOT NULL DEFAULT 0;`,
+		`ALTER TABLE findings ADD COLUMN IF NOT EXISTS rule_id VARCHAR(255) NOT NULL DEFAULT '';`,
+		`ALTER TABLE findings ADD COLUMN IF NOT EXISTS severity VARCHAR(16) NOT NULL DEFAULT '';`,
+		`ALTER TABLE findings ADD COLUMN IF NOT EXISTS tool VARCHAR(64) NOT NULL DEFAULT '';`,
+		`ALTER TABLE findings ADD COLUMN IF NOT EXISTS cwe INTEGER NOT NULL DEFAULT 0;`,
+		`ALTER TABLE findings ADD COLUMN IF NOT EXISTS inconclusive BOOLEAN NOT NULL DEFAULT FALSE;`,
+		`ALTER TABLE findings ADD COLUMN IF NOT EXISTS detail TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE findings ADD COLUMN IF NOT EXISTS locations JSONB;`,
 		`CREATE INDEX IF NOT EXISTS idx_projects_user_id ON projects(user_id);`,
 		`CREATE INDEX IF NOT EXISTS idx_files_project_id ON files(project_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_analysis_runs_project_id ON analysis_runs(project_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_findings_run_id ON findings(run_id);`,
 	}
 
 	for _, query := range queries {
@@ -0,0 +1,351 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"projects_service/internal/models"
+)
+
+// analyzerByExtension maps lower-case file extensions to the analyzer
+// service that handles them (the last segment of /api/analyzer/{type}).
+var analyzerByExtension = map[string]string{
+	".py":     "python",
+	".js":     "javascript",
+	".jsx":    "javascript",
+	".mjs":    "javascript",
+	".cjs":    "javascript",
+	".ts":     "javascript",
+	".tsx":    "javascript",
+	".mts":    "javascript",
+	".cts":    "javascript",
+	".vue":    "javascript",
+	".svelte": "javascript",
+	".java":   "java",
+	".c":      "cpp",
+	".cc":     "cpp",
+	".cpp":    "cpp",
+	".cxx":    "cpp",
+	".h":      "cpp",
+	".hpp":    "cpp",
+	".cs":     "csharp",
+	".json":   "json",
+	".jsonc":  "json",
+	".json5":  "json",
+	".yaml":   "yaml",
+	".yml":    "yaml",
+	".go":     "go",
+	".sh":     "shell",
+	".bash":   "shell",
+	".dash":   "shell",
+	".ksh":    "shell",
+}
+
+func analyzerForPath(path string) string {
+	return analyzerByExtension[strings.ToLower(filepath.Ext(path))]
+}
+
+// shellShebang matches the interpreter line of a shell script, e.g.
+// "#!/bin/bash" or "#!/usr/bin/env sh".
+var shellShebang = regexp.MustCompile(`^#!\s*/(?:usr/)?bin/(?:env\s+)?(?:ba|da|k|z)?sh(?:\s|$)`)
+
+// analyzerForFile is analyzerForPath, plus scripts without an extension
+// whose shebang names a shell, which go to the shell analyzer.
+func analyzerForFile(path, content string) string {
+	if filepath.Ext(path) != "" {
+		return analyzerForPath(path)
+	}
+	line, _, _ := strings.Cut(content, "\n")
+	if shellShebang.MatchString(strings.TrimRight(line, "\r")) {
+		return "shell"
+	}
+	return ""
+}
+
+// analyzersByPath returns a lookup of the analyzer of each of the files by
+// path, as analyzerForFile decides it from their content.
+func analyzersByPath(files []*models.File) func(path string) string {
+	analyzers := make(map[string]string, len(files))
+	for _, file := range files {
+		analyzers[file.Path] = analyzerForFile(file.Path, file.Content)
+	}
+	return func(path string) string {
+		if analyzerType, ok := analyzers[path]; ok {
+			return analyzerType
+		}
+		return analyzerForPath(path)
+	}
+}
+
+// analyzerConfigFiles lists, per analyzer, the base names (or patterns) of
+// project files that configure its tool; patterns with a slash match the end
+// of the path, e.g. "config/pmd/*.xml". They are sent along with the files
+// to analyze so the analyzer can apply the project's own settings.
+var analyzerConfigFiles = map[string][]string{
+	"python": {
+		".flake8", "setup.cfg", "tox.ini", "pyproject.toml", ".editorconfig",
+		"ruff.toml", ".ruff.toml", ".pylintrc", "pylintrc", "mypy.ini", ".mypy.ini", ".bandit",
+	},
+	"javascript": {
+		"eslint.config.js", "eslint.config.mjs", "eslint.config.cjs",
+		"eslint.config.ts", "eslint.config.mts", "eslint.config.cts",
+		".eslintrc", ".eslintrc.js", ".eslintrc.cjs", ".eslintrc.json", ".eslintrc.yml", ".eslintrc.yaml",
+		".eslintignore", "package.json", "tsconfig.json", "tsconfig.*.json",
+	},
+	"java": {
+		"checkstyle.xml", ".checkstyle.xml", "checkstyle-suppressions.xml", "suppressions.xml",
+		"pmd.xml", "pmd-ruleset.xml", "ruleset.xml", "config/pmd/*.xml",
+	},
+	"cpp":    {".cppcheck-suppressions", "cppcheck-suppressions.txt", "suppressions.txt"},
+	"csharp": {".editorconfig", ".globalconfig", "Directory.Build.props", "*.csproj", "*.sln"},
+	"yaml":   {".yamllint"},
+	"shell":  {".shellcheckrc"},
+	"go":     {"go.mod", "go.sum", "staticcheck.conf"},
+}
+
+// isAnalyzerConfig reports whether path is a configuration file of the
+// given analyzer's tool.
+func isAnalyzerConfig(analyzerType, path string) bool {
+	segments := strings.Split(strings.ReplaceAll(path, "\\", "/"), "/")
+	for _, pattern := range analyzerConfigFiles[analyzerType] {
+		n := strings.Count(pattern, "/") + 1
+		if n > len(segments) {
+			continue
+		}
+		tail := strings.Join(segments[len(segments)-n:], "/")
+		if matched, _ := filepath.Match(pattern, tail); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// analyzeFiles groups files by analyzer, calls every analyzer in parallel and
+// merges the results into a single response ordered like the input files.
+// Tool configuration files are sent to the analyzers they configure, but only
+// results for files an analyzer is responsible for are kept.
+// The returned flag reports whether at least one analyzer call, tool run or
+// file analysis failed.
+func (s *ProjectService) analyzeFiles(ctx context.Context, files []*models.File, options *models.AnalyzeOptions) (*models.AnalyzeResponse, bool) {
+	analyzerFor := analyzersByPath(files)
+	groups := make(map[string][]models.FileInput)
+	for _, file := range files {
+		analyzerType := analyzerFor(file.Path)
+		if analyzerType != "" {
+			groups[analyzerType] = append(groups[analyzerType], models.FileInput{
+				Path:    file.Path,
+				Content: file.Content,
+			})
+		}
+	}
+	for _, file := range files {
+		for analyzerType := range groups {
+			if analyzerType != analyzerFor(file.Path) && isAnalyzerConfig(analyzerType, file.Path) {
+				groups[analyzerType] = append(groups[analyzerType], models.FileInput{
+					Path:    file.Path,
+					Content: file.Content,
+				})
+			}
+		}
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		failed bool
+		byPath = make(map[string]models.FileResult, len(files))
+	)
+
+	for analyzerType, inputs := range groups {
+		wg.Add(1)
+		go func(analyzerType string, inputs []models.FileInput) {
+			defer wg.Done()
+
+			resp, err := s.callAnalyzer(ctx, analyzerType, inputs, options)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				failed = true
+				for _, input := range inputs {
+					if analyzerFor(input.Path) != analyzerType {
+						continue
+					}
+					byPath[input.Path] = models.FileResult{
+						Path:         input.Path,
+						Status:       models.StatusToolError,
+						Comment:      "Analyzer unavailable",
+						Stderr:       err.Error(),
+						LineComments: []models.LineComment{},
+					}
+				}
+				return
+			}
+			for _, result := range resp.Files {
+				if analyzerFor(result.Path) == analyzerType {
+					byPath[result.Path] = result
+					failed = failed || resultFailed(result)
+				}
+			}
+		}(analyzerType, inputs)
+	}
+	wg.Wait()
+
+	results := make([]models.FileResult, 0, len(files))
+	for _, file := range files {
+		result, ok := byPath[file.Path]
+		if !ok {
+			result = models.FileResult{
+				Path:         file.Path,
+				Status:       models.StatusUnsupported,
+				Comment:      "No analyzer available for this file type",
+				LineComments: []models.LineComment{},
+			}
+		}
+		results = append(results, result)
+	}
+
+	return &models.AnalyzeResponse{Files: results}, failed
+}
+
+// newAnalysisRun flattens the line comments of an analyzer response into
+// findings that can be stored for later retrieval. analyzerFor reports which
+// analyzer produced the result for a given path.
+func newAnalysisRun(projectID int, status string, resp *models.AnalyzeResponse, files []*models.File, analyzerFor func(path string) string) *models.AnalysisRun {
+	fileIDs := make(map[string]int, len(files))
+	for _, file := range files {
+		fileIDs[file.Path] = file.ID
+	}
+
+	run := &models.AnalysisRun{
+		ProjectID: projectID,
+		Status:    status,
+		Findings:  []models.Finding{},
+	}
+	if resp == nil {
+		return run
+	}
+
+	for _, result := range resp.Files {
+		var fileID *int
+		if id, ok := fileIDs[result.Path]; ok {
+			fileID = &id
+		}
+		for _, lineComment := range result.LineComments {
+			run.Findings = append(run.Findings, models.Finding{
+				ProjectID: projectID,
+				FileID:    fileID,
+				FilePath:  result.Path,
+				Analyzer:  analyzerFor(result.Path),
+				Line:      lineComment.Line,
+				Column:    lineComment.Column,
+				EndLine:   lineComment.EndLine,
+				EndColumn: lineComment.EndColumn,
+				RuleID:    lineComment.RuleID,
+				Severity:  lineComment.Severity,
+				Tool:      lineComment.Tool,
+				Message:   lineComment.Comment,
+
+				CWE:          lineComment.CWE,
+				Inconclusive: lineComment.Inconclusive,
+				Detail:       lineComment.Detail,
+				Locations:    lineComment.Locations,
+			})
+		}
+	}
+
+	return run
+}
+
+// resultFailed reports whether a file could not be analyzed because its tool
+// failed or did not finish in time.
+func resultFailed(result models.FileResult) bool {
+	return result.Status == models.StatusToolError || result.Status == models.StatusTimeout
+}
+
+// ErrAnalyzerUnavailable is returned when an analyzer cannot be reached or
+// does not answer with an analysis; the controller reports it as a bad
+// gateway.
+var ErrAnalyzerUnavailable = errors.New("analyzer unavailable")
+
+// ErrInvalidOptions is returned when the analysis options of a request are
+// invalid; the analyzers would reject them with 400 Bad Request.
+var ErrInvalidOptions = errors.New("invalid options")
+
+// validateAnalyzeOptions checks the options before they are forwarded, so a
+// typo is reported once instead of as a failure of every analyzer.
+func validateAnalyzeOptions(options *models.AnalyzeOptions) error {
+	if options == nil {
+		return nil
+	}
+	switch options.MinSeverity {
+	case "", models.SeverityError, models.SeverityWarning, models.SeverityInfo:
+	default:
+		return fmt.Errorf("%w: min_severity must be one of error, warning, info", ErrInvalidOptions)
+	}
+	if options.MaxFindings < 0 {
+		return fmt.Errorf("%w: max_findings must not be negative", ErrInvalidOptions)
+	}
+	return nil
+}
+
+// callAnalyzer sends files to an analyzer. The call is canceled with ctx, so
+// analyzers stop working for clients that went away.
+func (s *ProjectService) callAnalyzer(ctx context.Context, analyzerType string, files []models.FileInput, options *models.AnalyzeOptions) (*models.AnalyzeResponse, error) {
+	analyzerURL := fmt.Sprintf("%s/api/analyzer/%s", s.analyzerBaseURL, analyzerType)
+
+	jsonBody, err := json.Marshal(models.AnalyzeRequest{Files: files, Options: options})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode analyzer request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, analyzerURL, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create analyzer request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.analyzerClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to call analyzer %s: %v", ErrAnalyzerUnavailable, analyzerType, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message := analyzerError(resp.Body)
+		if resp.StatusCode == http.StatusBadRequest {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidOptions, message)
+		}
+		return nil, fmt.Errorf("%w: analyzer %s returned status %d: %s", ErrAnalyzerUnavailable, analyzerType, resp.StatusCode, message)
+	}
+
+	var result models.AnalyzeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode analyzer %s response: %v", ErrAnalyzerUnavailable, analyzerType, err)
+	}
+
+	return &result, nil
+}
+
+// analyzerError extracts the message of a failed analyzer call: the "error"
+// of the analyzers' JSON error body, or the start of any other body.
+func analyzerError(body io.Reader) string {
+	data, _ := io.ReadAll(io.LimitReader(body, 4096))
+	var errorBody struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(data, &errorBody) == nil && errorBody.Error != "" {
+		return errorBody.Error
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -3,31 +3,42 @@ package service
 import (
 	"archive/zip"
 	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"projects_service/internal/models"
 	"projects_service/internal/repository"
 )
 
+// analyzerTimeout bounds a call to an analyzer. It is longer than the
+// analyzers' own default request deadline, so they can still answer with
+// per-file timeouts first.
+const analyzerTimeout = 3 * time.Minute
+
 type ProjectService struct {
-	projectRepo    *repository.ProjectRepository
-	fileRepo       *repository.FileRepository
-	jwtSecret      string
+	projectRepo     *repository.ProjectRepository
+	fileRepo        *repository.FileRepository
+	analysisRepo    *repository.AnalysisRepository
+	jwtSecret       string
 	analyzerBaseURL string
+	analyzerClient  *http.Client
 }
 
-func NewProjectService(projectRepo *repository.ProjectRepository, fileRepo *repository.FileRepository, jwtSecret, analyzerBaseURL string) *ProjectService {
+func NewProjectService(projectRepo *repository.ProjectRepository, fileRepo *repository.FileRepository, analysisRepo *repository.AnalysisRepository, jwtSecret, analyzerBaseURL string) *ProjectService {
 	return &ProjectService{
 		projectRepo:     projectRepo,
 		fileRepo:        fileRepo,
+		analysisRepo:    analysisRepo,
 		jwtSecret:       jwtSecret,
 		analyzerBaseURL: analyzerBaseURL,
+		analyzerClient:  &http.Client{Timeout: analyzerTimeout},
 	}
 }
 
@@ -283,7 +294,11 @@ func (s *ProjectService) DeleteFile(token string, fileID int) error {
 	return s.fileRepo.Delete(fileID)
 }
 
-func (s *ProjectService) AnalyzeFile(token string, fileID int, analyzerType string) (interface{}, error) {
+func (s *ProjectService) AnalyzeFile(ctx context.Context, token string, fileID int, analyzerType string, options *models.AnalyzeOptions) (*models.AnalyzeResponse, error) {
+	if err := validateAnalyzeOptions(options); err != nil {
+		return nil, err
+	}
+
 	userID, _, err := validateToken(token, s.jwtSecret)
 	if err != nil {
 		return nil, errors.New("unauthorized")
@@ -303,29 +318,146 @@ func (s *ProjectService) AnalyzeFile(token string, fileID int, analyzerType stri
 		return nil, errors.New("forbidden")
 	}
 
-	analyzerURL := fmt.Sprintf("%s/api/analyzer/%s", s.analyzerBaseURL, analyzerType)
+	files := []*models.File{file}
+	analyzerFor := func(string) string { return analyzerType }
 
-	requestBody := map[string]interface{}{
-		"files": []map[string]string{
-			{
-				"path":    file.Path,
-				"content": file.Content,
-			},
-		},
+	inputs := []models.FileInput{{Path: file.Path, Content: file.Content}}
+	// Send the project's tool configuration along so the analyzer applies it.
+	if projectFiles, err := s.fileRepo.FindByProjectID(project.ID); err == nil {
+		for _, projectFile := range projectFiles {
+			if projectFile.ID != file.ID && isAnalyzerConfig(analyzerType, projectFile.Path) {
+				inputs = append(inputs, models.FileInput{Path: projectFile.Path, Content: projectFile.Content})
+			}
+		}
 	}
 
-	jsonBody, _ := json.Marshal(requestBody)
-	resp, err := http.Post(analyzerURL, "application/json", bytes.NewBuffer(jsonBody))
+	result, err := s.callAnalyzer(ctx, analyzerType, inputs, options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to call analyzer: %w", err)
+		// The analyzer error is what the caller needs to see; a run that
+		// cannot be saved on top of it is only logged.
+		if saveErr := s.saveAnalysisRun(newAnalysisRun(project.ID, models.RunStatusFailed, nil, files, analyzerFor)); saveErr != nil {
+			log.Printf("project %d: %v", project.ID, saveErr)
+		}
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	var result interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode analyzer response: %w", err)
+	fileResults := make([]models.FileResult, 0, 1)
+	for _, fileResult := range result.Files {
+		if fileResult.Path == file.Path {
+			fileResults = append(fileResults, fileResult)
+		}
+	}
+	result.Files = fileResults
+
+	status := models.RunStatusCompleted
+	for _, fileResult := range fileResults {
+		if resultFailed(fileResult) {
+			status = models.RunStatusFailed
+		}
 	}
 
+	s.recordRun(newAnalysisRun(project.ID, status, result, files, analyzerFor), result)
+
 	return result, nil
 }
 
+func (s *ProjectService) AnalyzeProject(ctx context.Context, token string, projectID int, options *models.AnalyzeOptions) (*models.AnalyzeResponse, error) {
+	if err := validateAnalyzeOptions(options); err != nil {
+		return nil, err
+	}
+
+	userID, _, err := validateToken(token, s.jwtSecret)
+	if err != nil {
+		return nil, errors.New("unauthorized")
+	}
+
+	project, err := s.projectRepo.FindByID(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if project.UserID != userID {
+		return nil, errors.New("forbidden")
+	}
+
+	files, err := s.fileRepo.FindByProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, failed := s.analyzeFiles(ctx, files, options)
+
+	status := models.RunStatusCompleted
+	if failed {
+		status = models.RunStatusFailed
+	}
+
+	s.recordRun(newAnalysisRun(projectID, status, result, files, analyzersByPath(files)), result)
+
+	return result, nil
+}
+
+func (s *ProjectService) ListAnalysisRuns(token string, projectID int) ([]*models.AnalysisRun, error) {
+	userID, _, err := validateToken(token, s.jwtSecret)
+	if err != nil {
+		return nil, errors.New("unauthorized")
+	}
+
+	project, err := s.projectRepo.FindByID(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if project.UserID != userID {
+		return nil, errors.New("forbidden")
+	}
+
+	return s.analysisRepo.FindRunsByProjectID(projectID)
+}
+
+func (s *ProjectService) GetAnalysisRun(token string, projectID, runID int) (*models.AnalysisRun, error) {
+	userID, _, err := validateToken(token, s.jwtSecret)
+	if err != nil {
+		return nil, errors.New("unauthorized")
+	}
+
+	project, err := s.projectRepo.FindByID(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if project.UserID != userID {
+		return nil, errors.New("forbidden")
+	}
+
+	run, err := s.analysisRepo.FindRunByID(runID)
+	if err != nil {
+		return nil, err
+	}
+
+	if run.ProjectID != projectID {
+		return nil, errors.New("analysis run not found")
+	}
+
+	return run, nil
+}
+
+// recordRun saves the run of a completed analysis and sets its ID on the
+// result. A run that cannot be saved does not discard the analysis: the
+// result is returned without run ID and with the error in run_error.
+func (s *ProjectService) recordRun(run *models.AnalysisRun, result *models.AnalyzeResponse) {
+	if err := s.saveAnalysisRun(run); err != nil {
+		log.Printf("project %d: %v", run.ProjectID, err)
+		result.RunError = err.Error()
+		return
+	}
+	result.RunID = run.ID
+}
+
+func (s *ProjectService) saveAnalysisRun(run *models.AnalysisRun) error {
+	if err := s.analysisRepo.CreateRun(run); err != nil {
+		return fmt.Errorf("failed to save analysis run: %w", err)
+	}
+	return nil
+}
+
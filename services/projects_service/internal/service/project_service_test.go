@@ -1,14 +1,22 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"projects_service/internal/models"
+	"projects_service/internal/repository"
 )
 
 func TestProjectService_ValidateToken(t *testing.T) {
-	service := NewProjectService(nil, nil, "test-secret", "http://localhost")
-
 	// Test with invalid token
 	_, _, err := validateToken("invalid-token", "test-secret")
 	if err == nil {
@@ -16,3 +24,261 @@ func TestProjectService_ValidateToken(t *testing.T) {
 	}
 }
 
+func TestProjectService_ListProjectsInvalidToken(t *testing.T) {
+	service := NewProjectService(nil, nil, nil, "test-secret", "http://localhost")
+
+	if _, err := service.ListProjects("invalid-token"); err == nil || err.Error() != "unauthorized" {
+		t.Errorf("ListProjects() error = %v, want unauthorized", err)
+	}
+}
+
+func TestAnalyzerForPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "main.py", want: "python"},
+		{path: "src/App.JSX", want: "javascript"},
+		{path: "src/main.tsx", want: "javascript"},
+		{path: "src/App.vue", want: "javascript"},
+		{path: "src/Main.java", want: "java"},
+		{path: "include/util.hpp", want: "cpp"},
+		{path: "Program.cs", want: "csharp"},
+		{path: "package.json", want: "json"},
+		{path: ".vscode/settings.jsonc", want: "json"},
+		{path: "deploy/docker-compose.YML", want: "yaml"},
+		{path: "cmd/server/main.go", want: "go"},
+		{path: "scripts/build.sh", want: "shell"},
+		{path: "README.md", want: ""},
+		{path: "Makefile", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := analyzerForPath(tt.path); got != tt.want {
+				t.Errorf("analyzerForPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsAnalyzerConfig(t *testing.T) {
+	tests := []struct {
+		analyzerType string
+		path         string
+		want         bool
+	}{
+		{analyzerType: "python", path: "setup.cfg", want: true},
+		{analyzerType: "python", path: "ruff.toml", want: true},
+		{analyzerType: "python", path: "backend/.ruff.toml", want: true},
+		{analyzerType: "python", path: ".pylintrc", want: true},
+		{analyzerType: "python", path: "pylintrc", want: true},
+		{analyzerType: "python", path: "mypy.ini", want: true},
+		{analyzerType: "python", path: ".mypy.ini", want: true},
+		{analyzerType: "python", path: ".bandit", want: true},
+		{analyzerType: "python", path: "requirements.txt", want: false},
+		{analyzerType: "java", path: "checkstyle.xml", want: true},
+		{analyzerType: "java", path: "pmd-ruleset.xml", want: true},
+		{analyzerType: "java", path: "config/pmd/pmd.xml", want: true},
+		{analyzerType: "java", path: "service/config/pmd/custom-rules.xml", want: true},
+		{analyzerType: "java", path: `config\pmd\custom-rules.xml`, want: true},
+		{analyzerType: "java", path: "pmd/custom-rules.xml", want: false},
+		{analyzerType: "java", path: "config/pmd/rules/custom.xml", want: false},
+		{analyzerType: "javascript", path: "tsconfig.build.json", want: true},
+		{analyzerType: "javascript", path: "ruff.toml", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.analyzerType+"/"+tt.path, func(t *testing.T) {
+			if got := isAnalyzerConfig(tt.analyzerType, tt.path); got != tt.want {
+				t.Errorf("isAnalyzerConfig(%q, %q) = %v, want %v", tt.analyzerType, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectService_AnalyzeFiles(t *testing.T) {
+	var mu sync.Mutex
+	received := make(map[string][]string)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/java") {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+
+		var req models.AnalyzeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode analyzer request: %v", err)
+		}
+
+		analyzerType := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		var resp models.AnalyzeResponse
+		for _, file := range req.Files {
+			mu.Lock()
+			received[analyzerType] = append(received[analyzerType], file.Path)
+			mu.Unlock()
+
+			resp.Files = append(resp.Files, models.FileResult{
+				Path:         file.Path,
+				Comment:      analyzerType,
+				LineComments: []models.LineComment{},
+			})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	service := NewProjectService(nil, nil, nil, "test-secret", server.URL)
+	files := []*models.File{
+		{Path: "README.md"},
+		{Path: "app/main.py"},
+		{Path: "app/util.py"},
+		{Path: "config.json"},
+		{Path: "src/Main.java"},
+		{Path: "setup.cfg"},
+		{Path: "bin/deploy", Content: "#!/usr/bin/env bash\nset -eu\n"},
+		{Path: "bin/notes", Content: "TODO\n"},
+	}
+
+	resp, failed := service.analyzeFiles(context.Background(), files, nil)
+	if !failed {
+		t.Error("analyzeFiles() should report the failed java analyzer call")
+	}
+	if len(resp.Files) != len(files) {
+		t.Fatalf("analyzeFiles() returned %d files, want %d", len(resp.Files), len(files))
+	}
+
+	want := []string{
+		"No analyzer available for this file type",
+		"python",
+		"python",
+		"json",
+		"Analyzer unavailable",
+		"No analyzer available for this file type",
+		"shell",
+		"No analyzer available for this file type",
+	}
+	for i, result := range resp.Files {
+		if result.Path != files[i].Path {
+			t.Errorf("analyzeFiles()[%d].Path = %q, want %q", i, result.Path, files[i].Path)
+		}
+		if result.Comment != want[i] {
+			t.Errorf("analyzeFiles()[%d].Comment = %q, want %q", i, result.Comment, want[i])
+		}
+	}
+	if got := resp.Files[4]; got.Status != models.StatusToolError || !strings.Contains(got.Stderr, "boom") {
+		t.Errorf("analyzeFiles() for the failed java analyzer = %+v, want tool_error with the analyzer's error", got)
+	}
+	if got := resp.Files[0].Status; got != models.StatusUnsupported {
+		t.Errorf("analyzeFiles() status for README.md = %q, want %q", got, models.StatusUnsupported)
+	}
+
+	if got := received["python"]; len(got) != 3 || got[2] != "setup.cfg" {
+		t.Errorf("python analyzer received %v, want the python files and setup.cfg", got)
+	}
+	for _, path := range received["json"] {
+		if path == "setup.cfg" {
+			t.Error("setup.cfg should only be sent to the python analyzer")
+		}
+	}
+}
+
+func TestProjectService_CallAnalyzerCanceled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	service := NewProjectService(nil, nil, nil, "test-secret", server.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, err := service.callAnalyzer(ctx, "python", []models.FileInput{{Path: "main.py"}}, nil)
+	if !errors.Is(err, ErrAnalyzerUnavailable) || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Errorf("callAnalyzer() error = %v, want the call canceled with the request", err)
+	}
+}
+
+func TestNewAnalysisRun(t *testing.T) {
+	files := []*models.File{
+		{ID: 7, Path: "app/main.py"},
+		{ID: 8, Path: "config.json"},
+	}
+	resp := &models.AnalyzeResponse{
+		Files: []models.FileResult{
+			{
+				Path:    "app/main.py",
+				Comment: "Issues found",
+				LineComments: []models.LineComment{
+					{Line: 1, Comment: "F401: 'os' imported but unused"},
+					{Line: 3, Column: 1, RuleID: "E302", Severity: models.SeverityWarning, Tool: "flake8", Comment: "E302: expected 2 blank lines"},
+				},
+			},
+			{Path: "config.json", Comment: "OK", LineComments: []models.LineComment{}},
+			{
+				Path:    "src/buffer.c",
+				Comment: "Issues found",
+				LineComments: []models.LineComment{{
+					Line: 9, Column: 12, RuleID: "arrayIndexOutOfBounds", Severity: models.SeverityError, Tool: "cppcheck",
+					Comment: "Array 'buf[8]' accessed at index 8, which is out of bounds.", CWE: 788, Inconclusive: true,
+					Detail:    "Array 'buf[8]' accessed at index 8, which is out of bounds. Loop ends at i <= 8.",
+					Locations: []models.Location{{Path: "src/buffer.c", Line: 9, Column: 12}, {Path: "src/buffer.c", Line: 8, Column: 19, Info: "Assuming that condition 'i<=8' is not redundant"}},
+				}},
+			},
+		},
+	}
+
+	run := newAnalysisRun(42, models.RunStatusCompleted, resp, files, analyzerForPath)
+	if run.ProjectID != 42 || run.Status != models.RunStatusCompleted {
+		t.Errorf("newAnalysisRun() = project %d status %q, want 42 %q", run.ProjectID, run.Status, models.RunStatusCompleted)
+	}
+	if len(run.Findings) != 3 {
+		t.Fatalf("newAnalysisRun() returned %d findings, want 3", len(run.Findings))
+	}
+
+	finding := run.Findings[1]
+	if finding.FileID == nil || *finding.FileID != 7 {
+		t.Errorf("finding.FileID = %v, want 7", finding.FileID)
+	}
+	if finding.Analyzer != "python" || finding.Line != 3 || finding.FilePath != "app/main.py" {
+		t.Errorf("finding = %+v, want python finding on app/main.py:3", finding)
+	}
+	if finding.Column != 1 || finding.RuleID != "E302" || finding.Severity != models.SeverityWarning || finding.Tool != "flake8" {
+		t.Errorf("finding = %+v, want flake8 E302 warning at column 1", finding)
+	}
+
+	cppcheck := run.Findings[2]
+	if cppcheck.Analyzer != "cpp" || cppcheck.CWE != 788 || !cppcheck.Inconclusive || cppcheck.Detail == "" || len(cppcheck.Locations) != 2 || cppcheck.Locations[1].Info == "" {
+		t.Errorf("finding = %+v, want the cppcheck CWE, inconclusive flag, detail and locations", cppcheck)
+	}
+
+	failedRun := newAnalysisRun(42, models.RunStatusFailed, nil, files, analyzerForPath)
+	if len(failedRun.Findings) != 0 {
+		t.Errorf("newAnalysisRun() without response returned %d findings, want 0", len(failedRun.Findings))
+	}
+}
+
+func TestProjectService_RecordRunSaveError(t *testing.T) {
+	// A closed database fails every query without a server.
+	db, err := sql.Open("postgres", "postgres://localhost/unused?sslmode=disable")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	db.Close()
+
+	service := NewProjectService(nil, nil, repository.NewAnalysisRepository(db), "test-secret", "http://localhost")
+	result := &models.AnalyzeResponse{Files: []models.FileResult{
+		{Path: "main.py", Status: models.StatusIssues, Comment: "Issues found", LineComments: []models.LineComment{{Line: 1, Comment: "F401"}}},
+	}}
+
+	service.recordRun(newAnalysisRun(42, models.RunStatusCompleted, result, []*models.File{{ID: 7, Path: "main.py"}}, analyzerForPath), result)
+	if result.RunID != 0 || !strings.Contains(result.RunError, "failed to save analysis run") {
+		t.Errorf("recordRun() = run %d, run_error %q, want no run ID and the save error", result.RunID, result.RunError)
+	}
+	if len(result.Files) != 1 || len(result.Files[0].LineComments) != 1 {
+		t.Errorf("recordRun() changed the analysis result: %+v", result.Files)
+	}
+}
@@ -26,7 +26,8 @@ func main() {
 
 	projectRepo := repository.NewProjectRepository(db)
 	fileRepo := repository.NewFileRepository(db)
-	projectService := service.NewProjectService(projectRepo, fileRepo, cfg.JWTSecret, cfg.AnalyzerBaseURL)
+	analysisRepo := repository.NewAnalysisRepository(db)
+	projectService := service.NewProjectService(projectRepo, fileRepo, analysisRepo, cfg.JWTSecret, cfg.AnalyzerBaseURL)
 	projectController := controller.NewProjectController(projectService)
 
 	router := controller.NewRouter(projectController)